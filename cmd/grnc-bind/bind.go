@@ -22,9 +22,26 @@ The results of that function are then included in your application's call to sta
 
 grnc-bind will need to be re-run whenever a component definition file is modified.
 
+Embedding resources
+
+By default the QueryManager and XMLWs facilities load templates from your application's working directory at runtime,
+so those directories must be deployed alongside your executable. If you supply a comma separated list of directories
+with the -e argument, grnc-bind will also generate a file (resources.go in the current directory by default) declaring
+an embed.FS containing those directories:
+
+	grnc-bind -e resource/queries,resource/xml
+
+The file declares a variable called resources in package main that should be passed to Granitic:
+
+	func main() {
+		granitic.StartGranitic(bindings.Components().WithResources(resources))
+	}
+
+Templates are then loaded from your application's executable instead of the working directory.
+
 Usage of grnc-bind:
 
-	grnc-bind [-c component-files] [-m merged-file-out] [-o generated-file] [-l log-level]
+	grnc-bind [-c component-files] [-m merged-file-out] [-o generated-file] [-e embed-dirs] [-ef embed-file] [-l log-level]
 
 	-c string
 		A comma separated list of component definition files or directories containing component definition files (default "resource/components")
@@ -32,6 +49,10 @@ Usage of grnc-bind:
 		The path of a file where the merged component defintion file should be written to. Execution will halt after writing.
 	-o string
 		Path to the Go source file that will be generated (default "bindings/bindings.go")
+	-e string
+		A comma separated list of resource directories to embed in your application's executable
+	-ef string
+		Path to the Go source file that will be generated to declare embedded resources (default "resources.go")
 	-l string
 		The level at which the tool will output messages: TRACE, DEBUG, INFO, ERROR, FATAL (default ERROR)
 
@@ -13,7 +13,9 @@ import (
 	"github.com/graniticio/granitic/v2/types"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -52,6 +54,17 @@ const (
 	mergeLocationDefault string = ""
 	mergeLocationHelp    string = "The path of a file where the merged component definition file should be written to. Execution will halt after writing."
 
+	embedDirsFlag    string = "e"
+	embedDirsDefault string = ""
+	embedDirsHelp    string = "A comma separated list of resource directories (e.g. resource/queries,resource/xml) to embed in your application's executable"
+
+	embedFileFlag    string = "ef"
+	embedFileDefault string = "resources.go"
+	embedFileHelp    string = "Path to the Go source file that will be generated to declare embedded resources (if -e is set)"
+
+	embedPackage = "main"
+	embedVar     = "resources"
+
 	logLevelFlag    string = "l"
 	logLevelDefault string = "WARN"
 	logLevelHelp    string = "The level at which messages will be logged to the console (TRACE, DEBUG, WARN, INFO, ERROR, FATAL)"
@@ -80,6 +93,8 @@ type Settings struct {
 	CompDefLocation *string
 	BindingsFile    *string
 	MergedDebugFile *string
+	EmbedDirs       *string
+	EmbedFile       *string
 	LogLevelLabel   *string
 	LogLevel        logging.LogLevel
 }
@@ -92,6 +107,8 @@ func SettingsFromArgs() (Settings, error) {
 	s.CompDefLocation = flag.String(compLocationFlag, compLocationDefault, compLocationHelp)
 	s.BindingsFile = flag.String(bindingsFileFlag, bindingsFileDefault, bindingsFileHelp)
 	s.MergedDebugFile = flag.String(mergeLocationFlag, mergeLocationDefault, mergeLocationHelp)
+	s.EmbedDirs = flag.String(embedDirsFlag, embedDirsDefault, embedDirsHelp)
+	s.EmbedFile = flag.String(embedFileFlag, embedFileDefault, embedFileHelp)
 	s.LogLevelLabel = flag.String(logLevelFlag, logLevelDefault, logLevelHelp)
 
 	flag.Parse()
@@ -162,12 +179,92 @@ func (b *Binder) Bind(s Settings) {
 	w := bufio.NewWriter(f)
 	b.writeBindings(w, ca)
 
+	if s.EmbedDirs != nil && *s.EmbedDirs != "" {
+		b.writeEmbedFile(*s.EmbedFile, *s.EmbedDirs)
+	}
+
 	if b.errorsFound {
 		b.exitError("Problems found. Please correct the above and re-run %s", b.ToolName)
 	}
 
 }
 
+// writeEmbedFile generates a Go source file declaring an embed.FS containing the supplied (comma separated) directories. The
+// resulting variable can be passed to ioc.ProtoComponents.WithResources so that templates are loaded from the
+// application's executable rather than the working directory.
+func (b *Binder) writeEmbedFile(embedFile string, dirs string) {
+
+	patterns, err := embedPatterns(embedFile, strings.Split(dirs, ","))
+
+	if err != nil {
+		b.Log.LogErrorf("Unable to embed resources: %s", err.Error())
+		b.fail()
+		return
+	}
+
+	b.Log.LogDebugf("Writing embedded resources declaration to %s", embedFile)
+
+	f := b.openOutputFile(embedFile)
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	b.writeEmbedDeclaration(w, patterns)
+	w.Flush()
+}
+
+// embedPatterns converts the supplied directories into go:embed patterns, which must be relative to the directory
+// containing the file that declares them.
+func embedPatterns(embedFile string, dirs []string) ([]string, error) {
+
+	base := filepath.Dir(embedFile)
+	patterns := make([]string, 0, len(dirs))
+
+	for _, d := range dirs {
+
+		d = strings.TrimSpace(d)
+
+		if d == "" {
+			continue
+		}
+
+		if !folderExists(d) {
+			return nil, fmt.Errorf("%s is not a directory", d)
+		}
+
+		rel, err := filepath.Rel(base, d)
+
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s must be a sub-directory of the directory containing %s", d, embedFile)
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if strings.ContainsAny(rel, " \t\"") {
+			rel = strconv.Quote(rel)
+		}
+
+		patterns = append(patterns, rel)
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no directories to embed")
+	}
+
+	return patterns, nil
+}
+
+func (b *Binder) writeEmbedDeclaration(w *bufio.Writer, patterns []string) {
+
+	w.WriteString(fmt.Sprintf("package %s\n\n", embedPackage))
+	w.WriteString("import \"embed\"\n\n")
+	w.WriteString(fmt.Sprintf("// %s contains resources embedded in this application's executable by %s. Pass it to Granitic with:\n", embedVar, b.ToolName))
+	w.WriteString("//\n")
+	w.WriteString(fmt.Sprintf("//\tgranitic.StartGranitic(bindings.Components().WithResources(%s))\n", embedVar))
+	w.WriteString("//\n")
+	w.WriteString(fmt.Sprintf("//go:embed %s\n", strings.Join(patterns, " ")))
+	w.WriteString(fmt.Sprintf("var %s embed.FS\n", embedVar))
+}
+
 func (b *Binder) compileRegexes() {
 	b.defaultValueRegex = regexp.MustCompile(defaultValuePattern)
 }
@@ -1,7 +1,12 @@
 package binder
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -112,3 +117,52 @@ func TestCheckConfDetection(t *testing.T) {
 	}
 
 }
+
+func TestEmbedPatterns(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for _, d := range []string{"resource/queries", "resource/xml", "resource/my templates"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ef := filepath.Join(dir, "resources.go")
+
+	p, err := embedPatterns(ef, []string{filepath.Join(dir, "resource/queries"), "", filepath.Join(dir, "resource", "my templates")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(p, " ") != `resource/queries "resource/my templates"` {
+		t.Errorf("Unexpected patterns %v", p)
+	}
+
+	invalid := [][]string{
+		{filepath.Join(dir, "resource/missing")},
+		{dir},
+		{filepath.Dir(dir)},
+		{},
+	}
+
+	for _, dirs := range invalid {
+		if _, err := embedPatterns(ef, dirs); err == nil {
+			t.Errorf("Expected %v to be rejected", dirs)
+		}
+	}
+
+	b := new(Binder)
+	b.ToolName = "grnc-bind"
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	b.writeEmbedDeclaration(w, p)
+	w.Flush()
+
+	if !strings.Contains(buf.String(), "//go:embed resource/queries \"resource/my templates\"\nvar resources embed.FS\n") {
+		t.Errorf("Unexpected declaration %s", buf.String())
+	}
+}
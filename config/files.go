@@ -6,8 +6,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 )
 
@@ -103,3 +105,42 @@ func FileListFromPath(path string) ([]string, error) {
 
 	return files, nil
 }
+
+// FileListFromFS behaves in the same way as FileListFromPath, but finds files in the supplied filesystem (for example
+// an embed.FS compiled into your application's executable) rather than the operating system's filesystem. The supplied
+// path must be slash separated and relative to the root of the filesystem.
+func FileListFromFS(fsys fs.FS, p string) ([]string, error) {
+
+	p = path.Clean(p)
+
+	info, err := fs.Stat(fsys, p)
+
+	if err != nil {
+		return []string{}, fmt.Errorf("unable to open file/dir %s", p)
+	}
+
+	if !info.IsDir() {
+		return []string{p}, nil
+	}
+
+	files := make([]string, 0)
+
+	err = fs.WalkDir(fsys, p, func(fp string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return errors.New("Unable to read contents of directory " + fp)
+		}
+
+		if !d.IsDir() {
+			files = append(files, fp)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -5,7 +5,9 @@ package config
 
 import (
 	"github.com/graniticio/granitic/v2/test"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestFindJSONFilesInDir(t *testing.T) {
@@ -39,3 +41,26 @@ func TestFileListFromPath(t *testing.T) {
 	}
 
 }
+
+func TestFileListFromFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"resource/queries/b.sql":     {Data: []byte("b")},
+		"resource/queries/a/c.sql":   {Data: []byte("c")},
+		"resource/queries/a.sql":     {Data: []byte("a")},
+		"resource/xml/template.html": {Data: []byte("x")},
+	}
+
+	f, err := FileListFromFS(fsys, "./resource/queries/")
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, strings.Join(f, ","), "resource/queries/a/c.sql,resource/queries/a.sql,resource/queries/b.sql")
+
+	f, err = FileListFromFS(fsys, "resource/xml/template.html")
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, strings.Join(f, ","), "resource/xml/template.html")
+
+	_, err = FileListFromFS(fsys, "resource/missing")
+	test.ExpectNotNil(t, err)
+}
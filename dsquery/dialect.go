@@ -0,0 +1,67 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of the SQL dialects that can be set as the Dialect of a TemplatedQueryManager.
+const (
+	MySQLDialect      = "mysql"
+	PostgreSQLDialect = "postgresql"
+	SQLiteDialect     = "sqlite"
+	SQLServerDialect  = "sqlserver"
+)
+
+// PlaceholderStyle determines how a variable in a query template is represented when a TemplatedQueryManager
+// is building queries with bind parameters.
+type PlaceholderStyle int
+
+const (
+	// QuestionMarkPlaceholder represents every variable as ? (MySQL, SQLite and most other drivers)
+	QuestionMarkPlaceholder PlaceholderStyle = iota
+	// DollarPlaceholder represents variables as $1, $2 etc (PostgreSQL)
+	DollarPlaceholder
+	// AtPPlaceholder represents variables as @p1, @p2 etc (SQL Server)
+	AtPPlaceholder
+)
+
+// Numbered returns true if placeholders in this style refer to a specific argument by its position. A variable that
+// appears more than once in a query can then be bound to a single argument.
+func (ps PlaceholderStyle) Numbered() bool {
+	return ps != QuestionMarkPlaceholder
+}
+
+// Placeholder returns the placeholder for the argument at the supplied position (starting at 1).
+func (ps PlaceholderStyle) Placeholder(position int) string {
+
+	switch ps {
+	case DollarPlaceholder:
+		return "$" + strconv.Itoa(position)
+	case AtPPlaceholder:
+		return "@p" + strconv.Itoa(position)
+	default:
+		return "?"
+	}
+}
+
+// PlaceholderStyleForDialect returns the style of placeholder used by drivers for the named dialect. An empty dialect
+// is treated as QuestionMarkPlaceholder. Returns an error if the dialect is not recognised.
+func PlaceholderStyleForDialect(dialect string) (PlaceholderStyle, error) {
+
+	switch strings.ToLower(dialect) {
+	case "", MySQLDialect, SQLiteDialect:
+		return QuestionMarkPlaceholder, nil
+	case PostgreSQLDialect:
+		return DollarPlaceholder, nil
+	case SQLServerDialect:
+		return AtPPlaceholder, nil
+	default:
+		return QuestionMarkPlaceholder, fmt.Errorf("unsupported SQL dialect %s (supported dialects are %s, %s, %s and %s)", dialect,
+			MySQLDialect, PostgreSQLDialect, SQLiteDialect, SQLServerDialect)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestPlaceholderStyleForDialect(t *testing.T) {
+
+	ps, err := PlaceholderStyleForDialect("")
+	test.ExpectNil(t, err)
+	test.ExpectString(t, ps.Placeholder(3), "?")
+	test.ExpectBool(t, ps.Numbered(), false)
+
+	ps, err = PlaceholderStyleForDialect("PostgreSQL")
+	test.ExpectNil(t, err)
+	test.ExpectString(t, ps.Placeholder(3), "$3")
+	test.ExpectBool(t, ps.Numbered(), true)
+
+	ps, err = PlaceholderStyleForDialect(SQLServerDialect)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, ps.Placeholder(1), "@p1")
+
+	ps, err = PlaceholderStyleForDialect(MySQLDialect)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, ps.Placeholder(1), "?")
+
+	_, err = PlaceholderStyleForDialect("unknown")
+	test.ExpectNotNil(t, err)
+}
@@ -0,0 +1,246 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/graniticio/granitic/v2/types"
+	"strings"
+	"time"
+)
+
+// MySQLTimeFormat is the layout used by MySQLProcessor to convert time.Time parameters if its TimeFormat is not set. MySQL
+// versions before 8.0.19 do not accept a time zone offset in datetime literals.
+const MySQLTimeFormat = "2006-01-02 15:04:05.999999"
+
+// Identifier is a parameter value that is the name of a table, column or other database object rather than a value.
+// Dialect-specific ParamValueProcessors quote an Identifier using the dialect's rules for identifiers. A name containing
+// a . (e.g. schema.table) is treated as a qualified name and each part is quoted separately.
+type Identifier string
+
+// ProcessorForDialect returns a new, unconfigured ParamValueProcessor for the named SQL dialect or nil if there is no
+// dialect-specific processor for that dialect.
+func ProcessorForDialect(dialect string) ParamValueProcessor {
+
+	switch strings.ToLower(dialect) {
+	case MySQLDialect:
+		return new(MySQLProcessor)
+	case PostgreSQLDialect:
+		return new(PostgreSQLProcessor)
+	case SQLiteDialect:
+		return new(SQLiteProcessor)
+	}
+
+	return nil
+}
+
+// literalWriter is implemented by dialect-specific ParamValueProcessors to convert values to SQL literals.
+type literalWriter interface {
+	stringLiteral(s string) string
+	bytesLiteral(b []byte) string
+	boolLiteral(b bool) string
+	quoteIdentifier(name string) string
+}
+
+// escapeForDialect converts strings, bools, times, byte slices and identifiers to literals using the supplied literalWriter.
+func escapeForDialect(lw literalWriter, timeFormat string, v *paramValueContext) {
+
+	if v.Escaped {
+		return
+	}
+
+	switch t := v.Value.(type) {
+	case string:
+		v.Value = lw.stringLiteral(t)
+	case types.NilableString:
+		v.Value = lw.stringLiteral(t.String())
+	case *types.NilableString:
+		v.Value = lw.stringLiteral(t.String())
+	case bool:
+		v.Value = lw.boolLiteral(t)
+	case types.NilableBool:
+		v.Value = lw.boolLiteral(t.Bool())
+	case *types.NilableBool:
+		v.Value = lw.boolLiteral(t.Bool())
+	case time.Time:
+		v.Value = lw.stringLiteral(formatTime(t, timeFormat))
+	case *time.Time:
+		v.Value = lw.stringLiteral(formatTime(*t, timeFormat))
+	case []byte:
+		v.Value = lw.bytesLiteral(t)
+	case Identifier:
+		parts := strings.Split(string(t), ".")
+
+		for i, p := range parts {
+			parts[i] = lw.quoteIdentifier(p)
+		}
+
+		v.Value = strings.Join(parts, ".")
+	}
+}
+
+// substituteNull replaces an unset parameter with the word null.
+func substituteNull(v *paramValueContext) error {
+
+	v.Value = "null"
+	v.Escaped = true
+
+	return nil
+}
+
+// MySQLProcessor escapes parameter values for MySQL and MariaDB. Strings are wrapped in single quotes with backslashes,
+// quotes and control characters escaped with a backslash (or, if NoBackslashEscapes is true, with single quotes
+// doubled). Bools become TRUE and FALSE, byte slices become hexadecimal literals, identifiers are wrapped in backticks and
+// unset parameters are replaced with null.
+type MySQLProcessor struct {
+	// Set to true if the server's sql_mode includes NO_BACKSLASH_ESCAPES.
+	NoBackslashEscapes bool
+
+	// The layout (see time.Time.Format) used to convert time.Time parameters to strings. If not set, MySQLTimeFormat is used.
+	TimeFormat string
+}
+
+// EscapeParamValue implements ParamValueProcessor.EscapeParamValue
+func (mp *MySQLProcessor) EscapeParamValue(v *paramValueContext) {
+
+	tf := mp.TimeFormat
+
+	if tf == "" {
+		tf = MySQLTimeFormat
+	}
+
+	escapeForDialect(mp, tf, v)
+}
+
+// SubstituteUnset implements ParamValueProcessor.SubstituteUnset by replacing the value with null
+func (mp *MySQLProcessor) SubstituteUnset(v *paramValueContext) error {
+	return substituteNull(v)
+}
+
+var mySQLEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "\\'",
+	"\"", "\\\"",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
+func (mp *MySQLProcessor) stringLiteral(s string) string {
+
+	if mp.NoBackslashEscapes {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	return "'" + mySQLEscaper.Replace(s) + "'"
+}
+
+func (mp *MySQLProcessor) bytesLiteral(b []byte) string {
+	return fmt.Sprintf("X'%X'", b)
+}
+
+func (mp *MySQLProcessor) boolLiteral(b bool) string {
+
+	if b {
+		return "TRUE"
+	}
+
+	return "FALSE"
+}
+
+func (mp *MySQLProcessor) quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// PostgreSQLProcessor escapes parameter values for PostgreSQL. Strings are wrapped in single quotes with quotes doubled.
+// Strings containing a backslash are written as escape string constants (E'...') with backslashes doubled, so they are
+// interpreted correctly whatever the value of standard_conforming_strings. Bools become TRUE and FALSE, byte slices
+// become bytea hex literals, identifiers are wrapped in double quotes and unset parameters are replaced with null.
+type PostgreSQLProcessor struct {
+	// The layout (see time.Time.Format) used to convert time.Time parameters to strings. If not set, DefaultTimeFormat is used.
+	TimeFormat string
+}
+
+// EscapeParamValue implements ParamValueProcessor.EscapeParamValue
+func (pp *PostgreSQLProcessor) EscapeParamValue(v *paramValueContext) {
+	escapeForDialect(pp, pp.TimeFormat, v)
+}
+
+// SubstituteUnset implements ParamValueProcessor.SubstituteUnset by replacing the value with null
+func (pp *PostgreSQLProcessor) SubstituteUnset(v *paramValueContext) error {
+	return substituteNull(v)
+}
+
+func (pp *PostgreSQLProcessor) stringLiteral(s string) string {
+
+	s = strings.ReplaceAll(s, "'", "''")
+
+	if strings.Contains(s, "\\") {
+		return "E'" + strings.ReplaceAll(s, "\\", "\\\\") + "'"
+	}
+
+	return "'" + s + "'"
+}
+
+func (pp *PostgreSQLProcessor) bytesLiteral(b []byte) string {
+	return "'\\x" + hex.EncodeToString(b) + "'::bytea"
+}
+
+func (pp *PostgreSQLProcessor) boolLiteral(b bool) string {
+
+	if b {
+		return "TRUE"
+	}
+
+	return "FALSE"
+}
+
+func (pp *PostgreSQLProcessor) quoteIdentifier(name string) string {
+	return quoteWithDoubleQuotes(name)
+}
+
+// SQLiteProcessor escapes parameter values for SQLite. Strings are wrapped in single quotes with quotes doubled (SQLite
+// does not treat backslashes as escape characters). Bools become 1 and 0, byte slices become blob literals, identifiers
+// are wrapped in double quotes and unset parameters are replaced with null.
+type SQLiteProcessor struct {
+	// The layout (see time.Time.Format) used to convert time.Time parameters to strings. If not set, DefaultTimeFormat is used.
+	TimeFormat string
+}
+
+// EscapeParamValue implements ParamValueProcessor.EscapeParamValue
+func (sp *SQLiteProcessor) EscapeParamValue(v *paramValueContext) {
+	escapeForDialect(sp, sp.TimeFormat, v)
+}
+
+// SubstituteUnset implements ParamValueProcessor.SubstituteUnset by replacing the value with null
+func (sp *SQLiteProcessor) SubstituteUnset(v *paramValueContext) error {
+	return substituteNull(v)
+}
+
+func (sp *SQLiteProcessor) stringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (sp *SQLiteProcessor) bytesLiteral(b []byte) string {
+	return fmt.Sprintf("X'%X'", b)
+}
+
+func (sp *SQLiteProcessor) boolLiteral(b bool) string {
+
+	if b {
+		return "1"
+	}
+
+	return "0"
+}
+
+func (sp *SQLiteProcessor) quoteIdentifier(name string) string {
+	return quoteWithDoubleQuotes(name)
+}
+
+func quoteWithDoubleQuotes(name string) string {
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"testing"
+	"time"
+)
+
+func escaped(vp ParamValueProcessor, v interface{}) string {
+
+	pvc := paramValueContext{Value: v}
+
+	if v == nil {
+		vp.SubstituteUnset(&pvc)
+	}
+
+	vp.EscapeParamValue(&pvc)
+
+	return pvc.Value.(string)
+}
+
+func TestMySQLProcessor(t *testing.T) {
+
+	mp := new(MySQLProcessor)
+
+	test.ExpectString(t, escaped(mp, "O'Brien"), `'O\'Brien'`)
+	test.ExpectString(t, escaped(mp, `a\' OR 1=1 --`), `'a\\\' OR 1=1 --'`)
+	test.ExpectString(t, escaped(mp, "\"a\"\n\x00\x1a"), `'\"a\"\n\0\Z'`)
+	test.ExpectString(t, escaped(mp, types.NewNilableString("x")), "'x'")
+	test.ExpectString(t, escaped(mp, true), "TRUE")
+	test.ExpectString(t, escaped(mp, types.NewNilableBool(false)), "FALSE")
+	test.ExpectString(t, escaped(mp, []byte{0x0a, 0xff}), "X'0AFF'")
+	test.ExpectString(t, escaped(mp, time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)), "'2019-03-04 05:06:07'")
+	test.ExpectString(t, escaped(mp, Identifier("my`table")), "`my``table`")
+	test.ExpectString(t, escaped(mp, Identifier("db.artist")), "`db`.`artist`")
+	test.ExpectString(t, escaped(mp, nil), "null")
+
+	mp.NoBackslashEscapes = true
+
+	test.ExpectString(t, escaped(mp, `O'Brien\`), `'O''Brien\'`)
+}
+
+func TestPostgreSQLProcessor(t *testing.T) {
+
+	pp := new(PostgreSQLProcessor)
+
+	test.ExpectString(t, escaped(pp, "O'Brien"), "'O''Brien'")
+	test.ExpectString(t, escaped(pp, `C:\dir\'`), `E'C:\\dir\\'''`)
+	test.ExpectString(t, escaped(pp, false), "FALSE")
+	test.ExpectString(t, escaped(pp, []byte{0x0a, 0xff}), `'\x0aff'::bytea`)
+	test.ExpectString(t, escaped(pp, time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)), "'2019-03-04 05:06:07+00:00'")
+	test.ExpectString(t, escaped(pp, Identifier(`public.my"table`)), `"public"."my""table"`)
+	test.ExpectString(t, escaped(pp, nil), "null")
+}
+
+func TestSQLiteProcessor(t *testing.T) {
+
+	sp := new(SQLiteProcessor)
+	sp.TimeFormat = "2006-01-02"
+
+	tm := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	test.ExpectString(t, escaped(sp, `O'Brien\`), `'O''Brien\'`)
+	test.ExpectString(t, escaped(sp, true), "1")
+	test.ExpectString(t, escaped(sp, types.NewNilableBool(false)), "0")
+	test.ExpectString(t, escaped(sp, []byte{0x0a, 0xff}), "X'0AFF'")
+	test.ExpectString(t, escaped(sp, &tm), "'2019-03-04'")
+	test.ExpectString(t, escaped(sp, Identifier("artist")), `"artist"`)
+	test.ExpectString(t, escaped(sp, nil), "null")
+}
+
+func TestProcessorForDialect(t *testing.T) {
+
+	_, found := ProcessorForDialect("MySQL").(*MySQLProcessor)
+	test.ExpectBool(t, found, true)
+
+	_, found = ProcessorForDialect(PostgreSQLDialect).(*PostgreSQLProcessor)
+	test.ExpectBool(t, found, true)
+
+	_, found = ProcessorForDialect(SQLiteDialect).(*SQLiteProcessor)
+	test.ExpectBool(t, found, true)
+
+	test.ExpectNil(t, ProcessorForDialect(SQLServerDialect))
+	test.ExpectNil(t, ProcessorForDialect(""))
+}
+
+func TestIdentifiersInQueries(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"q": "ID:SORTED\nSELECT name FROM ${table} WHERE name = ${name} ORDER BY ${column}"})
+
+	qm := buildQueryManager()
+	qm.ValueProcessor = new(PostgreSQLProcessor)
+	qm.TemplateLocation = dir
+
+	test.ExpectNil(t, qm.StartComponent())
+
+	p := map[string]interface{}{"table": Identifier("public.artist"), "name": "O'Brien", "column": Identifier("name")}
+
+	q, err := qm.BuildQueryFromID("SORTED", p)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, `SELECT name FROM "public"."artist" WHERE name = 'O''Brien' ORDER BY "name"`+"\n")
+
+	qm.BindParameters = true
+	qm.Dialect = MySQLDialect
+	qm.placeholder = QuestionMarkPlaceholder
+
+	q, args, err := qm.BuildParameterisedQueryFromID("SORTED", p)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "SELECT name FROM `public`.`artist` WHERE name = ? ORDER BY `name`\n")
+	test.ExpectInt(t, len(args), 1)
+
+	qm.Dialect = SQLServerDialect
+
+	_, _, err = qm.BuildParameterisedQueryFromID("SORTED", p)
+	test.ExpectNotNil(t, err)
+}
@@ -25,14 +25,25 @@ import (
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/types"
+	"io/fs"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const requiredPrefix = "!"
 
+// varStart is the start of a variable using the default VarMatchRegEx
+const varStart = "${"
+
+// Variable names may be prefixed with requiredPrefix and must not contain whitespace, braces or $
+var validVarName = regexp.MustCompile(`^!?[^\s{}$!]+$`)
+
 // A QueryManager is a type that is able to populate a pre-defined template query given a set of named parameters
 // and return a complete query ready for execution against some data source.
 type QueryManager interface {
@@ -47,6 +58,49 @@ type QueryManager interface {
 	FragmentFromID(qid string) (string, error)
 }
 
+// ParameterisedQueryManager is implemented by QueryManagers that are able to build queries where each variable is
+// replaced with a driver-specific placeholder and the values of the variables are returned separately, ready to be
+// passed as arguments to the Exec and Query methods of sql.DB.
+type ParameterisedQueryManager interface {
+	// BindsParameters returns true if queries should be built with BuildParameterisedQueryFromID rather than BuildQueryFromID.
+	BindsParameters() bool
+
+	// BuildParameterisedQueryFromID finds a template with the supplied query ID and returns a query with a placeholder
+	// in place of each variable, together with the arguments that should be bound to those placeholders when the query
+	// is executed. Returns an error if the template could not be found or a required parameter is missing.
+	BuildParameterisedQueryFromID(qid string, params map[string]interface{}) (string, []interface{}, error)
+}
+
+// ReloadNotifier is implemented by QueryManagers that can reload their templates while an application is running.
+// Components that cache anything derived from built queries (such as prepared statements) register a function that is
+// called each time the templates are reloaded.
+type ReloadNotifier interface {
+	// OnReload registers a function to be called after templates have been reloaded.
+	OnReload(f func())
+}
+
+// UpsertDetector is implemented by QueryManagers that can report whether a query is an upsert. Components that
+// capture the IDs generated by an INSERT use this to avoid assigning IDs to rows that were updated rather than inserted.
+type UpsertDetector interface {
+	// IsUpsert returns true if the template with the supplied query ID contains an upsert directive.
+	IsUpsert(qid string) bool
+}
+
+// ParameterInspector is implemented by QueryManagers that can report which parameters a query template refers to.
+// Components use this to check that a template handles a parameter they set on the caller's behalf.
+type ParameterInspector interface {
+	// UsesParameter returns true if the template with the supplied query ID refers to the named parameter, either as a
+	// variable or as the condition of a section.
+	UsesParameter(qid string, name string) bool
+}
+
+// PlaceholderReporter is implemented by ParameterisedQueryManagers that can report the style of placeholder they use,
+// allowing components to add their own bind parameters to a built query.
+type PlaceholderReporter interface {
+	// Placeholders returns the style of placeholder used in queries built by BuildParameterisedQueryFromID.
+	Placeholders() PlaceholderStyle
+}
+
 // NewTemplatedQueryManager creates a new, empty TemplatedQueryManager.
 func NewTemplatedQueryManager() *TemplatedQueryManager {
 	qm := new(TemplatedQueryManager)
@@ -63,6 +117,11 @@ type TemplatedQueryManager struct {
 	// The path to a folder where template files are stored.
 	TemplateLocation string
 
+	// An optional filesystem (normally an embed.FS compiled into your application's executable) that templates are
+	// loaded from instead of the operating system's filesystem. If set, TemplateLocation is a slash-separated
+	// path relative to the root of this filesystem.
+	TemplateFS fs.FS
+
 	// A regular expression that allows variable names to be recognised in queries.
 	VarMatchRegEx string
 
@@ -83,16 +142,127 @@ type TemplatedQueryManager struct {
 	CreateDefaultValueProcessor bool
 
 	// The character sequence that indicates a new line in a template file (e.g. \n)
-	NewLine            string
+	NewLine string
+
+	// Lines in a template file starting with this string (ignoring leading whitespace) are directives that start or end a
+	// conditional or repeating section of a query (see https://granitic.io/ref/query-management). If not set, directives
+	// are not recognised.
+	DirectivePrefix string
+
+	// If true, variables in templates are replaced with placeholders and their values passed to the database driver
+	// as arguments rather than being escaped and inserted into the query (see BuildParameterisedQueryFromID).
+	BindParameters bool
+
+	// The SQL dialect of the database in use (mysql, postgresql, sqlite or sqlserver). Determines the style of
+	// placeholder used when BindParameters is true.
+	Dialect string
+
+	// If true, StartComponent returns an error if a template file cannot be read, if more than one template has the same
+	// ID, if a template has an invalid directive or variable or if a file has content before its first query ID. If false,
+	// these problems are logged.
+	StrictStartup bool
+
+	// If true, TemplateLocation is checked for added, changed and removed files every WatchIntervalMS milliseconds and
+	// all templates are reloaded when a change is found. Intended for use during development.
+	WatchTemplates bool
+
+	// How often (in milliseconds) TemplateLocation is checked for changes if WatchTemplates is true. If not set,
+	// DefaultWatchInterval is used.
+	WatchIntervalMS int
+
+	placeholder        PlaceholderStyle
+	mu                 sync.RWMutex
 	tokenisedTemplates map[string]*queryTemplate
 	fragments          map[string]string
+	watcher            *templateWatcher
+	reloadListeners    []func()
 	state              ioc.ComponentState
 }
 
+// QueryDescription describes a query template loaded by a TemplatedQueryManager.
+type QueryDescription struct {
+	// The ID of the query.
+	ID string
+
+	// The names of the parameters used by the template, in the order they first appear. The names of required
+	// parameters are prefixed with !
+	Parameters []string
+
+	// The file the template was loaded from.
+	Source string
+}
+
+// Queries returns a description of each query template that has been loaded, sorted by query ID.
+func (qm *TemplatedQueryManager) Queries() []QueryDescription {
+
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+
+	qd := make([]QueryDescription, 0, len(qm.tokenisedTemplates))
+
+	for id, t := range qm.tokenisedTemplates {
+		qd = append(qd, QueryDescription{ID: id, Parameters: t.parameters(), Source: t.Source})
+	}
+
+	sort.Slice(qd, func(i, j int) bool { return qd[i].ID < qd[j].ID })
+
+	return qd
+}
+
+// IsUpsert implements UpsertDetector.IsUpsert
+func (qm *TemplatedQueryManager) IsUpsert(qid string) bool {
+
+	template := qm.template(qid)
+
+	if template == nil {
+		return false
+	}
+
+	for _, t := range template.Tokens {
+		if t.Type == upsertToken {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UsesParameter implements ParameterInspector.UsesParameter
+func (qm *TemplatedQueryManager) UsesParameter(qid string, name string) bool {
+
+	template := qm.template(qid)
+
+	if template == nil {
+		return false
+	}
+
+	for _, p := range template.parameters() {
+		if p, _ = splitRequired(p); p == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Placeholders implements PlaceholderReporter.Placeholders
+func (qm *TemplatedQueryManager) Placeholders() PlaceholderStyle {
+	return qm.placeholder
+}
+
+func (qm *TemplatedQueryManager) template(qid string) *queryTemplate {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+
+	return qm.tokenisedTemplates[qid]
+}
+
 // FragmentFromID implements QueryManager.FragmentFromID
 func (qm *TemplatedQueryManager) FragmentFromID(qid string) (string, error) {
 
+	qm.mu.RLock()
 	f := qm.fragments[qid]
+	qm.mu.RUnlock()
 
 	if f != "" {
 		return f, nil
@@ -103,7 +273,9 @@ func (qm *TemplatedQueryManager) FragmentFromID(qid string) (string, error) {
 	f, err := qm.BuildQueryFromID(qid, p)
 
 	if err != nil {
+		qm.mu.Lock()
 		qm.fragments[qid] = f
+		qm.mu.Unlock()
 	}
 
 	return f, err
@@ -112,7 +284,7 @@ func (qm *TemplatedQueryManager) FragmentFromID(qid string) (string, error) {
 
 // BuildQueryFromID implements QueryManager.BuildQueryFromID
 func (qm *TemplatedQueryManager) BuildQueryFromID(qid string, params map[string]interface{}) (string, error) {
-	template := qm.tokenisedTemplates[qid]
+	template := qm.template(qid)
 
 	if template == nil {
 		return "", errors.New("Unknown query " + qid)
@@ -121,82 +293,227 @@ func (qm *TemplatedQueryManager) BuildQueryFromID(qid string, params map[string]
 	return qm.buildQueryFromTemplate(qid, template, params)
 }
 
-func (qm *TemplatedQueryManager) buildQueryFromTemplate(qid string, template *queryTemplate, params map[string]interface{}) (string, error) {
+// BindsParameters implements ParameterisedQueryManager.BindsParameters
+func (qm *TemplatedQueryManager) BindsParameters() bool {
+	return qm.BindParameters
+}
+
+// BuildParameterisedQueryFromID implements ParameterisedQueryManager.BuildParameterisedQueryFromID. Unset parameters
+// that are not marked as required are passed to the ValueProcessor's SubstituteUnset method. A substituted null is
+// bound as nil, other escaped values are written into the query and any other value is bound.
+func (qm *TemplatedQueryManager) BuildParameterisedQueryFromID(qid string, params map[string]interface{}) (string, []interface{}, error) {
+	template := qm.template(qid)
+
+	if template == nil {
+		return "", nil, errors.New("Unknown query " + qid)
+	}
+
+	return qm.buildParameterisedQueryFromTemplate(qid, template, params)
+}
+
+func (qm *TemplatedQueryManager) buildParameterisedQueryFromTemplate(qid string, template *queryTemplate, params map[string]interface{}) (string, []interface{}, error) {
+
+	if template.err != nil {
+		return "", nil, template.err
+	}
 
 	var b bytes.Buffer
+	var args []interface{}
 
-	vp := qm.ValueProcessor
-	log := qm.FrameworkLogger
-	trace := log.IsLevelEnabled(logging.Trace)
+	ps := qm.placeholder
+	positions := make(map[string]int)
 
-	for _, token := range template.Tokens {
+	write := func(b *bytes.Buffer, key string, required bool, value interface{}, topLevel bool) error {
 
-		if token.Type == fragmentToken {
-			b.WriteString(token.Content)
-		} else {
+		if id, found := value.(Identifier); found {
+			//Identifiers cannot be bound so are quoted and written into the query
+			return qm.writeIdentifier(b, key, id)
+		}
+
+		if topLevel && ps.Numbered() {
+			if p, found := positions[key]; found {
+				//Variable already bound - refer to the same argument
+				b.WriteString(ps.Placeholder(p))
+				return nil
+			}
+		}
+
+		set := paramSet(value)
+
+		if !set && required {
+			return fmt.Errorf("parameter %s is required for query %s but has not been set", key, qid)
+		}
+
+		if set && isExpandable(value) {
+			//Bind each element of the slice to its own placeholder
+			for i, e := range expand(value) {
 
-			key := token.Content
+				if i > 0 {
+					b.WriteString(", ")
+				}
 
-			if trace {
-				log.LogTracef("Processing parameter %s", key)
+				v, _ := bindValue(e)
+				args = append(args, v)
+				b.WriteString(ps.Placeholder(len(args)))
 			}
 
-			required := strings.HasPrefix(key, requiredPrefix)
+			return nil
+		}
+
+		v, _ := bindValue(value)
+
+		if !set {
 
-			if required {
-				key = strings.Replace(key, requiredPrefix, "", 1)
+			vc, err := qm.substituteUnset(qid, key)
+
+			if err != nil {
+				return err
+			}
+
+			if vc.Escaped && !isNullLiteral(vc.Value) {
+				//The ValueProcessor has substituted SQL that must be written into the query
+				return qm.writeProcessedValue(b, key, vc)
 			}
 
-			paramValue := params[key]
+			v = nil
 
-			vc := paramValueContext{
-				Value:   paramValue,
-				Key:     key,
-				QueryID: qid,
+			if !vc.Escaped {
+				v, _ = bindValue(vc.Value)
 			}
+		}
 
-			if paramValue == nil {
+		args = append(args, v)
 
-				if trace {
-					log.LogTracef("Parameter %s is unset", key)
-				}
+		if topLevel {
+			positions[key] = len(args)
+		}
 
-				if required {
-					return "", fmt.Errorf("parameter %s is required for query %s but has not been set", key, qid)
-				}
+		b.WriteString(ps.Placeholder(len(args)))
 
-				if err := vp.SubstituteUnset(&vc); err != nil {
+		return nil
+	}
 
-					//ValueProcessor does not allow this parameter to be unset
-					return "", err
-				}
+	if err := newTemplateRenderer(qid, qm.Dialect, params, write).render(&b, template.Tokens); err != nil {
+		return "", nil, err
+	}
+
+	q := b.String()
+
+	if qm.FrameworkLogger.IsLevelEnabled(logging.Debug) {
+		qm.FrameworkLogger.LogDebugf("\n%s\n%v", q, args)
+	}
+
+	return q, args, nil
+}
+
+// writeIdentifier quotes the supplied Identifier using the rules of the TemplatedQueryManager's Dialect.
+func (qm *TemplatedQueryManager) writeIdentifier(b *bytes.Buffer, key string, id Identifier) error {
+
+	lw, found := ProcessorForDialect(qm.Dialect).(literalWriter)
+
+	if !found {
+		return fmt.Errorf("parameter %s is an Identifier, which is only supported if Dialect is %s, %s or %s", key, MySQLDialect, PostgreSQLDialect, SQLiteDialect)
+	}
+
+	vc := paramValueContext{Key: key, Value: id}
+	escapeForDialect(lw, "", &vc)
+
+	b.WriteString(vc.Value.(string))
+
+	return nil
+}
+
+// bindValue converts Granitic nilable types into values that can be understood by a database driver and indicates
+// whether or not the value is set.
+func bindValue(v interface{}) (interface{}, bool) {
+
+	switch t := v.(type) {
+	case nil:
+		return nil, false
+	case *types.NilableString:
+		if t == nil || !t.IsSet() {
+			return nil, false
+		}
+		return t.String(), true
+	case types.NilableString:
+		return bindValue(&t)
+	case *types.NilableInt64:
+		if t == nil || !t.IsSet() {
+			return nil, false
+		}
+		return t.Int64(), true
+	case types.NilableInt64:
+		return bindValue(&t)
+	case *types.NilableFloat64:
+		if t == nil || !t.IsSet() {
+			return nil, false
+		}
+		return t.Float64(), true
+	case types.NilableFloat64:
+		return bindValue(&t)
+	case *types.NilableBool:
+		if t == nil || !t.IsSet() {
+			return nil, false
+		}
+		return t.Bool(), true
+	case types.NilableBool:
+		return bindValue(&t)
+	default:
+		if isNilPointer(v) {
+			return nil, false
+		}
+
+		return v, true
+	}
+}
+
+func isNilPointer(v interface{}) bool {
+
+	if v == nil {
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+func (qm *TemplatedQueryManager) buildQueryFromTemplate(qid string, template *queryTemplate, params map[string]interface{}) (string, error) {
+
+	if template.err != nil {
+		return "", template.err
+	}
+
+	var b bytes.Buffer
+
+	write := func(b *bytes.Buffer, key string, required bool, value interface{}, topLevel bool) error {
 
+		if isExpandable(value) {
+
+			if !paramSet(value) {
+				//Treat empty slices as unset
+				return qm.writeValue(b, qid, key, required, nil)
 			}
 
-			//Perform any required escaping on the parameter value
-			vp.EscapeParamValue(&vc)
-
-			switch t := vc.Value.(type) {
-			default:
-				return "", fmt.Errorf("value for parameter %s is not a supported type. (type is %T)", key, t)
-			case string:
-				b.WriteString(t)
-			case *types.NilableString:
-				b.WriteString(t.String())
-			case types.NilableString:
-				b.WriteString(t.String())
-			case int:
-				b.WriteString(strconv.Itoa(t))
-			case int64:
-				b.WriteString(strconv.FormatInt(t, 10))
-			case *types.NilableInt64:
-				b.WriteString(strconv.FormatInt(t.Int64(), 10))
-			case types.NilableInt64:
-				b.WriteString(strconv.FormatInt(t.Int64(), 10))
+			for i, e := range expand(value) {
+
+				if i > 0 {
+					b.WriteString(", ")
+				}
+
+				if err := qm.writeValue(b, qid, key, required, e); err != nil {
+					return err
+				}
 			}
 
+			return nil
 		}
 
+		return qm.writeValue(b, qid, key, required, value)
+	}
+
+	if err := newTemplateRenderer(qid, qm.Dialect, params, write).render(&b, template.Tokens); err != nil {
+		return "", err
 	}
 
 	q := b.String()
@@ -209,6 +526,134 @@ func (qm *TemplatedQueryManager) buildQueryFromTemplate(qid string, template *qu
 
 }
 
+func (qm *TemplatedQueryManager) writeValue(b *bytes.Buffer, qid string, key string, required bool, paramValue interface{}) error {
+
+	vp := qm.ValueProcessor
+	log := qm.FrameworkLogger
+	trace := log.IsLevelEnabled(logging.Trace)
+
+	if trace {
+		log.LogTracef("Processing parameter %s", key)
+	}
+
+	if isNilPointer(paramValue) {
+		paramValue = nil
+	}
+
+	vc := paramValueContext{
+		Value:   paramValue,
+		Key:     key,
+		QueryID: qid,
+	}
+
+	if paramValue == nil {
+
+		if trace {
+			log.LogTracef("Parameter %s is unset", key)
+		}
+
+		if required {
+			return fmt.Errorf("parameter %s is required for query %s but has not been set", key, qid)
+		}
+
+		if err := vp.SubstituteUnset(&vc); err != nil {
+
+			//ValueProcessor does not allow this parameter to be unset
+			return err
+		}
+
+	}
+
+	return qm.writeProcessedValue(b, key, &vc)
+}
+
+// substituteUnset asks the ValueProcessor (if one is set) for the value to use in place of an unset parameter.
+func (qm *TemplatedQueryManager) substituteUnset(qid string, key string) (*paramValueContext, error) {
+
+	vc := &paramValueContext{
+		Key:     key,
+		QueryID: qid,
+	}
+
+	if qm.ValueProcessor == nil {
+		return vc, nil
+	}
+
+	//Returns an error if the ValueProcessor does not allow this parameter to be unset
+	return vc, qm.ValueProcessor.SubstituteUnset(vc)
+}
+
+// isNullLiteral returns true if the supplied value is nil or the SQL keyword null
+func isNullLiteral(v interface{}) bool {
+
+	if v == nil {
+		return true
+	}
+
+	s, found := v.(string)
+
+	return found && strings.EqualFold(s, "null")
+}
+
+// writeProcessedValue escapes a value that has been checked (and substituted if unset) and writes it into the query.
+func (qm *TemplatedQueryManager) writeProcessedValue(b *bytes.Buffer, key string, vc *paramValueContext) error {
+
+	//Perform any required escaping on the parameter value
+	qm.ValueProcessor.EscapeParamValue(vc)
+
+	switch t := vc.Value.(type) {
+	default:
+		return writeNumericValue(b, key, t)
+	case string:
+		b.WriteString(t)
+	case []byte:
+		b.Write(t)
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case time.Time, *time.Time:
+		return fmt.Errorf("value for parameter %s is a %T which must be converted to a string by the ParamValueProcessor", key, t)
+	case *types.NilableString:
+		b.WriteString(t.String())
+	case types.NilableString:
+		b.WriteString(t.String())
+	case *types.NilableInt64:
+		b.WriteString(strconv.FormatInt(t.Int64(), 10))
+	case types.NilableInt64:
+		b.WriteString(strconv.FormatInt(t.Int64(), 10))
+	case *types.NilableFloat64:
+		b.WriteString(strconv.FormatFloat(t.Float64(), 'g', -1, 64))
+	case types.NilableFloat64:
+		b.WriteString(strconv.FormatFloat(t.Float64(), 'g', -1, 64))
+	case *types.NilableBool:
+		b.WriteString(strconv.FormatBool(t.Bool()))
+	case types.NilableBool:
+		b.WriteString(strconv.FormatBool(t.Bool()))
+	}
+
+	return nil
+}
+
+// writeNumericValue writes any value whose underlying type is an int, uint or float type.
+func writeNumericValue(b *bytes.Buffer, key string, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32:
+		b.WriteString(strconv.FormatFloat(rv.Float(), 'g', -1, 32))
+	case reflect.Float64:
+		b.WriteString(strconv.FormatFloat(rv.Float(), 'g', -1, 64))
+	default:
+		return fmt.Errorf("value for parameter %s is not a supported type. (type is %T)", key, v)
+	}
+
+	return nil
+}
+
 // StartComponent is called by the IoC container. Loads, parses and tokenizes query templates. Returns an error
 // if there was a problem loading, parsing or tokenizing.
 func (qm *TemplatedQueryManager) StartComponent() error {
@@ -222,61 +667,196 @@ func (qm *TemplatedQueryManager) StartComponent() error {
 		return errors.New(m)
 	}
 
+	if qm.BindParameters {
+
+		ps, err := PlaceholderStyleForDialect(qm.Dialect)
+
+		if err != nil {
+			return fmt.Errorf("Unable to start QueryManager: %s", err.Error())
+		}
+
+		qm.placeholder = ps
+	}
+
 	qm.state = ioc.StartingState
 
 	fl := qm.FrameworkLogger
 	fl.LogDebugf("Starting QueryManager")
 	fl.LogDebugf(qm.TemplateLocation)
 
-	queryFiles, err := config.FileListFromPath(qm.TemplateLocation)
+	queryFiles, err := qm.templateFiles()
 
-	if err == nil {
+	if err != nil {
+		return fmt.Errorf("Unable to start QueryManager due to problem loading query files: %s", err.Error())
+	}
 
-		qm.tokenisedTemplates = qm.parseQueryFiles(queryFiles)
-		fl.LogDebugf("Started QueryManager with %d queries", len(qm.tokenisedTemplates))
+	if qm.fragments == nil {
+		qm.fragments = make(map[string]string)
+	}
 
-		qm.state = ioc.RunningState
+	templates, problems := qm.loadTemplates(queryFiles)
 
-		return nil
+	if qm.StrictStartup && len(problems) > 0 {
+		return fmt.Errorf("Unable to start QueryManager due to problems with query templates: %s", joinErrors(problems))
+	}
+
+	qm.logProblems(problems)
+
+	qm.mu.Lock()
+	qm.tokenisedTemplates = templates
+	qm.mu.Unlock()
+
+	fl.LogDebugf("Started QueryManager with %d queries", len(templates))
+
+	if qm.WatchTemplates {
+		qm.watch()
+	}
+
+	qm.state = ioc.RunningState
+
+	return nil
+}
+
+// PrepareToStop implements ioc.Stoppable.PrepareToStop
+func (qm *TemplatedQueryManager) PrepareToStop() {
+}
+
+// ReadyToStop implements ioc.Stoppable.ReadyToStop
+func (qm *TemplatedQueryManager) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop implements ioc.Stoppable.Stop. Stops watching TemplateLocation for changes.
+func (qm *TemplatedQueryManager) Stop() error {
 
+	if qm.watcher != nil {
+		qm.watcher.stopWatching()
+		qm.watcher = nil
 	}
 
-	return fmt.Errorf("Unable to start QueryManager due to problem loading query files: %s", err.Error())
+	qm.state = ioc.StoppedState
 
+	return nil
 }
 
 func (qm *TemplatedQueryManager) parseQueryFiles(files []string) map[string]*queryTemplate {
+
+	templates, problems := qm.loadTemplates(files)
+
+	qm.logProblems(problems)
+
+	return templates
+}
+
+// loadTemplates parses the supplied files, returning the templates found and a description of any problems with the
+// files or templates.
+func (qm *TemplatedQueryManager) loadTemplates(files []string) (map[string]*queryTemplate, []error) {
 	fl := qm.FrameworkLogger
 	tokenisedTemplates := map[string]*queryTemplate{}
 	re := regexp.MustCompile(qm.VarMatchRegEx)
 
+	var problems []error
+
 	for _, filePath := range files {
 
 		fl.LogDebugf("Parsing query file %s", filePath)
 
-		file, err := os.Open(filePath)
+		file, err := qm.openTemplate(filePath)
 
 		if err != nil {
-			fl.LogErrorf("Unable to open %s for parsing: %s", filePath, err.Error())
+			problems = append(problems, fmt.Errorf("unable to open %s for parsing: %s", filePath, err.Error()))
 			continue
 		}
 
-		defer file.Close()
-
 		scanner := bufio.NewScanner(file)
-		qm.scanAndParse(scanner, tokenisedTemplates, re)
+		problems = append(problems, qm.scanAndParse(scanner, filePath, tokenisedTemplates, re)...)
+
+		file.Close()
+	}
+
+	ids := make([]string, 0, len(tokenisedTemplates))
+
+	for id := range tokenisedTemplates {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := tokenisedTemplates[id].err; err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	return tokenisedTemplates, problems
+}
+
+// templateFiles lists the files in TemplateLocation, using TemplateFS if it has been set.
+func (qm *TemplatedQueryManager) templateFiles() ([]string, error) {
+
+	if qm.TemplateFS != nil {
+		return config.FileListFromFS(qm.TemplateFS, qm.TemplateLocation)
+	}
+
+	return config.FileListFromPath(qm.TemplateLocation)
+}
+
+// openTemplate opens a template file, using TemplateFS if it has been set.
+func (qm *TemplatedQueryManager) openTemplate(path string) (fs.File, error) {
+
+	if qm.TemplateFS != nil {
+		return qm.TemplateFS.Open(path)
+	}
+
+	return os.Open(path)
+}
+
+// statTemplate returns information about a template file, using TemplateFS if it has been set.
+func (qm *TemplatedQueryManager) statTemplate(path string) (fs.FileInfo, error) {
+
+	if qm.TemplateFS != nil {
+		return fs.Stat(qm.TemplateFS, path)
+	}
+
+	return os.Stat(path)
+}
+
+func (qm *TemplatedQueryManager) logProblems(problems []error) {
+
+	for _, p := range problems {
+		qm.FrameworkLogger.LogErrorf("Invalid query template: %s", p.Error())
+	}
+}
+
+func joinErrors(errs []error) string {
+
+	m := make([]string, len(errs))
+
+	for i, e := range errs {
+		m[i] = e.Error()
 	}
 
-	return tokenisedTemplates
+	return strings.Join(m, "; ")
 }
 
-func (qm *TemplatedQueryManager) scanAndParse(scanner *bufio.Scanner, tokenisedTemplates map[string]*queryTemplate, re *regexp.Regexp) {
+func (qm *TemplatedQueryManager) scanAndParse(scanner *bufio.Scanner, source string, tokenisedTemplates map[string]*queryTemplate, re *regexp.Regexp) []error {
 
 	var currentTemplate *queryTemplate
 	var fragmentBuffer bytes.Buffer
+	var problems []error
+
+	lineNumber := 0
+
+	// Only check for malformed variables if the default variable syntax is in use
+	checkVars := strings.HasPrefix(qm.VarMatchRegEx, `\$\{`)
+
+	problem := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s line %d: %s", source, lineNumber, fmt.Sprintf(format, a...)))
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 
 		idLine, id := qm.isIDLine(line)
 
@@ -286,7 +866,12 @@ func (qm *TemplatedQueryManager) scanAndParse(scanner *bufio.Scanner, tokenisedT
 				currentTemplate.Finalise()
 			}
 
+			if existing := tokenisedTemplates[id]; existing != nil {
+				problem("query %s has already been defined in %s", id, existing.Source)
+			}
+
 			currentTemplate = newQueryTemplate(id, &fragmentBuffer)
+			currentTemplate.Source = source
 			tokenisedTemplates[id] = currentTemplate
 			continue
 		}
@@ -295,14 +880,33 @@ func (qm *TemplatedQueryManager) scanAndParse(scanner *bufio.Scanner, tokenisedT
 			continue
 		}
 
+		if currentTemplate == nil {
+			problem("content found before the first query ID")
+			continue
+		}
+
+		if directiveLine, directive := qm.isDirectiveLine(line); directiveLine {
+			currentTemplate.AddDirective(directive)
+			continue
+		}
+
 		varTokens := re.FindAllStringSubmatch(line, -1)
 
 		if varTokens == nil {
 			currentTemplate.AddFragmentContent(line)
+
+			if checkVars && strings.Contains(line, varStart) {
+				problem("unterminated or unrecognised variable in query %s", currentTemplate.ID)
+			}
+
 		} else {
 
 			fragments := re.Split(line, -1)
 
+			if checkVars {
+				qm.checkVars(fragments, varTokens, func(m string) { problem("%s in query %s", m, currentTemplate.ID) })
+			}
+
 			firstMatch := re.FindStringIndex(line)
 
 			startsWithVar := (firstMatch[0] == 0)
@@ -348,6 +952,27 @@ func (qm *TemplatedQueryManager) scanAndParse(scanner *bufio.Scanner, tokenisedT
 		currentTemplate.Finalise()
 	}
 
+	if err := scanner.Err(); err != nil {
+		problems = append(problems, fmt.Errorf("unable to read %s: %s", source, err.Error()))
+	}
+
+	return problems
+}
+
+// checkVars reports any fragments of a line that contain the start of a variable and any variables with invalid names.
+func (qm *TemplatedQueryManager) checkVars(fragments []string, varTokens [][]string, report func(string)) {
+
+	for _, f := range fragments {
+		if strings.Contains(f, varStart) {
+			report("unterminated or unrecognised variable")
+		}
+	}
+
+	for _, vt := range varTokens {
+		if !validVarName.MatchString(vt[1]) {
+			report(fmt.Sprintf("invalid variable name '%s'", vt[1]))
+		}
+	}
 }
 
 func intMax(x, y int) int {
@@ -391,24 +1016,184 @@ func (qm *TemplatedQueryManager) isBlankLine(line string) bool {
 	return len(strings.TrimSpace(line)) == 0
 }
 
+func (qm *TemplatedQueryManager) isDirectiveLine(line string) (bool, string) {
+	prefix := qm.DirectivePrefix
+
+	if prefix == "" {
+		return false, ""
+	}
+
+	trimmed := strings.TrimSpace(line)
+
+	if strings.HasPrefix(trimmed, prefix) {
+		return true, strings.TrimPrefix(trimmed, prefix)
+	}
+
+	return false, ""
+}
+
 type queryTokenType int
 
 const (
 	fragmentToken = iota
 	varNameToken
 	varIndexToken
+	sectionToken
+	endToken
+	upsertToken
 )
 
 type queryTemplate struct {
 	Tokens         []*queryTemplateToken
 	ID             string
+	Source         string
 	currentToken   *queryTemplateToken
 	fragmentBuffer *bytes.Buffer
+	err            error
 }
 
 func (qt *queryTemplate) Finalise() {
 	qt.closeFragmentToken()
 	qt.fragmentBuffer = nil
+
+	// Record the number of tokens inside each section so that sections can be skipped when the template is populated
+	var open []int
+
+	for i, t := range qt.Tokens {
+
+		switch t.Type {
+		case sectionToken:
+			open = append(open, i)
+		case endToken:
+			if len(open) == 0 {
+				qt.recordError("%s directive without a matching section", EndDirective)
+				return
+			}
+
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+
+			qt.Tokens[start].length = i - start - 1
+		}
+	}
+
+	if len(open) > 0 {
+		qt.recordError("%s section is missing an %s directive", qt.Tokens[open[len(open)-1]].Directive, EndDirective)
+	}
+}
+
+// AddDirective parses the text following a directive prefix and starts or ends a section.
+func (qt *queryTemplate) AddDirective(text string) {
+
+	fields := strings.Fields(text)
+
+	if len(fields) == 0 {
+		qt.recordError("empty directive")
+		return
+	}
+
+	d := fields[0]
+	args := fields[1:]
+
+	qt.closeFragmentToken()
+
+	t := newQueryTemplateToken(sectionToken)
+	t.Directive = d
+
+	switch d {
+	case EndDirective:
+		t.Type = endToken
+
+	case WhereDirective:
+
+	case IfDirective, RangeDirective:
+		if len(args) == 0 {
+			qt.recordError("%s directive must specify the name of a parameter", d)
+			return
+		}
+
+		t.Content = args[0]
+
+		if d == RangeDirective {
+			t.Separator = defaultRangeSeparator
+
+			if len(args) > 1 {
+				t.Separator = args[1]
+			}
+		}
+
+	case UpsertDirective:
+		if len(args) == 0 {
+			qt.recordError("%s directive must specify the columns of a unique key", d)
+			return
+		}
+
+		t.Type = upsertToken
+		t.Content = args[0]
+		t.Keys = columnList(args[0])
+		t.Updates = columnList(strings.Join(args[1:], ""))
+
+		if len(t.Keys) == 0 {
+			qt.recordError("%s directive must specify the columns of a unique key (found %s)", d, args[0])
+			return
+		}
+
+	default:
+		qt.recordError("unknown directive %s", d)
+		return
+	}
+
+	qt.Tokens = append(qt.Tokens, t)
+	qt.currentToken = t
+}
+
+// columnList splits a comma separated list of column names.
+func columnList(s string) []string {
+
+	var cols []string
+
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+
+	return cols
+}
+
+// parameters returns the names of the variables and section parameters in the template, in the order they first appear.
+func (qt *queryTemplate) parameters() []string {
+
+	params := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, t := range qt.Tokens {
+
+		var p string
+
+		switch t.Type {
+		case varNameToken:
+			p = t.Content
+		case varIndexToken:
+			p = strconv.Itoa(t.Index)
+		case sectionToken:
+			p = t.Content
+		}
+
+		if p != "" && !seen[p] {
+			seen[p] = true
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+func (qt *queryTemplate) recordError(format string, a ...interface{}) {
+
+	if qt.err == nil {
+		qt.err = fmt.Errorf("query %s: %s", qt.ID, fmt.Sprintf(format, a...))
+	}
 }
 
 func (qt *queryTemplate) AddFragmentContent(fragment string) {
@@ -472,9 +1257,14 @@ func newQueryTemplate(id string, buffer *bytes.Buffer) *queryTemplate {
 }
 
 type queryTemplateToken struct {
-	Type    queryTokenType
-	Content string
-	Index   int
+	Type      queryTokenType
+	Content   string
+	Index     int
+	Directive string
+	Separator string
+	Keys      []string
+	Updates   []string
+	length    int
 }
 
 func newQueryTemplateToken(tokenType queryTokenType) *queryTemplateToken {
@@ -498,6 +1288,12 @@ func (qtt *queryTemplateToken) String() string {
 		return fmt.Sprintf("VN:%s", qtt.Content)
 	case varIndexToken:
 		return fmt.Sprintf("VI:%d", qtt.Index)
+	case sectionToken:
+		return fmt.Sprintf("S:%s:%s", qtt.Directive, qtt.Content)
+	case endToken:
+		return "E"
+	case upsertToken:
+		return fmt.Sprintf("U:%s", qtt.Content)
 	default:
 		return ""
 
@@ -7,10 +7,13 @@ import (
 	"bytes"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestSingleSingleQueryNoVars(t *testing.T) {
@@ -152,3 +155,404 @@ func buildQueryManager() *TemplatedQueryManager {
 	return qm
 
 }
+
+func TestParameterisedQueries(t *testing.T) {
+
+	f := filepath.Join("querymanager", "bind", "bind-params")
+	queryFiles := []string{test.FilePath(f)}
+	qm := buildQueryManager()
+	qm.BindParameters = true
+
+	qm.tokenisedTemplates = qm.parseQueryFiles(queryFiles)
+
+	test.ExpectBool(t, qm.BindsParameters(), true)
+
+	p := map[string]interface{}{"name": "Beatles", "genre": types.NewNilableString("Pop")}
+
+	q, args, err := qm.BuildParameterisedQueryFromID("BIND_PARAMS", p)
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "name = ?\n"), true)
+	test.ExpectBool(t, strings.Contains(q, "(genre = ? OR ? IS NULL)"), true)
+	test.ExpectInt(t, len(args), 3)
+	test.ExpectString(t, args[0].(string), "Beatles")
+	test.ExpectString(t, args[1].(string), "Pop")
+
+	qm.placeholder = DollarPlaceholder
+
+	q, args, err = qm.BuildParameterisedQueryFromID("BIND_PARAMS", map[string]interface{}{"name": "Beatles"})
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "name = $1\n"), true)
+	test.ExpectBool(t, strings.Contains(q, "(genre = $2 OR $2 IS NULL)"), true)
+	test.ExpectInt(t, len(args), 2)
+	test.ExpectNil(t, args[1])
+
+	_, _, err = qm.BuildParameterisedQueryFromID("BIND_PARAMS", map[string]interface{}{"genre": "Pop"})
+	test.ExpectNotNil(t, err)
+
+	_, _, err = qm.BuildParameterisedQueryFromID("MISSING", p)
+	test.ExpectNotNil(t, err)
+}
+
+func TestUnsetBoundParametersSubstituted(t *testing.T) {
+
+	qm := buildQueryManager()
+	qm.BindParameters = true
+	qm.tokenisedTemplates = qm.parseQueryFiles([]string{test.FilePath(filepath.Join("querymanager", "bind", "bind-params"))})
+
+	cp := new(ConfigurableProcessor)
+	qm.ValueProcessor = cp
+
+	p := map[string]interface{}{"name": "Beatles"}
+
+	_, _, err := qm.BuildParameterisedQueryFromID("BIND_PARAMS", p)
+	test.ExpectNotNil(t, err)
+
+	cp.UseDefaultForMissingParameter = true
+	cp.DefaultParameterValue = "Rock"
+	cp.EscapeDefaultValues = true
+
+	q, args, err := qm.BuildParameterisedQueryFromID("BIND_PARAMS", p)
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "(genre = ? OR ? IS NULL)"), true)
+	test.ExpectInt(t, len(args), 3)
+	test.ExpectString(t, args[1].(string), "Rock")
+
+	cp.DefaultParameterValue = "DEFAULT_GENRE"
+	cp.EscapeDefaultValues = false
+
+	q, args, err = qm.BuildParameterisedQueryFromID("BIND_PARAMS", p)
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "(genre = DEFAULT_GENRE OR DEFAULT_GENRE IS NULL)"), true)
+	test.ExpectInt(t, len(args), 1)
+}
+
+func TestBindParametersDialectValidatedOnStart(t *testing.T) {
+
+	qm := buildQueryManager()
+	qm.BindParameters = true
+	qm.Dialect = "oracle"
+	qm.TemplateLocation = test.FilePath(filepath.Join("querymanager", "bind"))
+
+	test.ExpectNotNil(t, qm.StartComponent())
+
+	qm = buildQueryManager()
+	qm.BindParameters = true
+	qm.Dialect = SQLServerDialect
+	qm.TemplateLocation = test.FilePath(filepath.Join("querymanager", "bind"))
+
+	test.ExpectNil(t, qm.StartComponent())
+	test.ExpectInt(t, int(qm.placeholder), int(AtPPlaceholder))
+}
+
+func TestConditionalSections(t *testing.T) {
+
+	qm := sectionsQueryManager()
+
+	q, err := qm.BuildQueryFromID("ARTIST_SEARCH", map[string]interface{}{})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "SELECT\n    id\nFROM\n    artist\nORDER BY name\n")
+
+	q, err = qm.BuildQueryFromID("ARTIST_SEARCH", map[string]interface{}{"genres": []string{"Pop", "Rock"}})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "SELECT\n    id\nFROM\n    artist\nWHERE genre IN ('Pop', 'Rock')\nORDER BY name\n")
+
+	q, err = qm.BuildQueryFromID("ARTIST_SEARCH", map[string]interface{}{"name": "Blur", "genres": []int64{1, 2}})
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "WHERE name = 'Blur'\n    AND genre IN (1, 2)\n"), true)
+
+	q, err = qm.BuildQueryFromID("ARTIST_SEARCH", map[string]interface{}{"name": new(types.NilableString), "genres": []string{}})
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "WHERE"), false)
+
+	_, err = qm.BuildQueryFromID("UNBALANCED", map[string]interface{}{"name": "Blur"})
+	test.ExpectNotNil(t, err)
+}
+
+func TestRepeatingSections(t *testing.T) {
+
+	qm := sectionsQueryManager()
+
+	artists := []map[string]interface{}{
+		{"name": "Blur", "genre": "Pop"},
+		{"name": "Oasis"},
+	}
+
+	q, err := qm.BuildQueryFromID("ARTIST_INSERT", map[string]interface{}{"artists": artists})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "INSERT INTO artist (name, genre) VALUES\n    ('Blur', 'Pop'),\n    ('Oasis', null)\n")
+
+	_, err = qm.BuildQueryFromID("ARTIST_INSERT", map[string]interface{}{})
+	test.ExpectNotNil(t, err)
+
+	_, err = qm.BuildQueryFromID("ARTIST_INSERT", map[string]interface{}{"artists": []string{"Blur"}})
+	test.ExpectNotNil(t, err)
+
+	qm.placeholder = DollarPlaceholder
+
+	q, args, err := qm.BuildParameterisedQueryFromID("ARTIST_INSERT", map[string]interface{}{"artists": artists})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "INSERT INTO artist (name, genre) VALUES\n    ($1, $2),\n    ($3, $4)\n")
+	test.ExpectInt(t, len(args), 4)
+	test.ExpectString(t, args[2].(string), "Oasis")
+	test.ExpectNil(t, args[3])
+
+	q, args, err = qm.BuildParameterisedQueryFromID("ARTIST_SEARCH", map[string]interface{}{"genres": []string{"Pop", "Rock"}})
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "WHERE genre IN ($1, $2)\n"), true)
+	test.ExpectInt(t, len(args), 2)
+}
+
+func TestUpsertDirective(t *testing.T) {
+
+	qm := sectionsQueryManager()
+
+	rows := map[string]interface{}{"rows": []map[string]interface{}{{"id": 1, "name": "Blur", "genre": "Pop"}}}
+	row := map[string]interface{}{"id": 1, "name": "Blur"}
+
+	expected := map[string][]string{
+		MySQLDialect: {"ON DUPLICATE KEY UPDATE name = VALUES(name), genre = VALUES(genre)\n", "ON DUPLICATE KEY UPDATE id = id\n"},
+		PostgreSQLDialect: {"ON CONFLICT (id) DO UPDATE SET name = excluded.name, genre = excluded.genre\n",
+			"ON CONFLICT (id) DO NOTHING\n"},
+		SQLiteDialect: {"ON CONFLICT (id) DO UPDATE SET name = excluded.name, genre = excluded.genre\n",
+			"ON CONFLICT (id) DO NOTHING\n"},
+	}
+
+	for dialect, clauses := range expected {
+
+		qm.Dialect = dialect
+
+		q, err := qm.BuildQueryFromID("ARTIST_UPSERT", rows)
+		test.ExpectNil(t, err)
+		test.ExpectBool(t, strings.HasSuffix(q, "(1, 'Blur', 'Pop')\n"+clauses[0]), true)
+
+		q, err = qm.BuildQueryFromID("ARTIST_INSERT_IGNORE", row)
+		test.ExpectNil(t, err)
+		test.ExpectBool(t, strings.HasSuffix(q, "(1, 'Blur')\n"+clauses[1]), true)
+	}
+
+	qm.Dialect = PostgreSQLDialect
+	qm.placeholder = DollarPlaceholder
+
+	q, args, err := qm.BuildParameterisedQueryFromID("ARTIST_UPSERT", rows)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "INSERT INTO artist (id, name, genre) VALUES\n    ($1, $2, $3)\n"+expected[PostgreSQLDialect][0])
+	test.ExpectInt(t, len(args), 3)
+
+	qm.Dialect = SQLServerDialect
+
+	_, err = qm.BuildQueryFromID("ARTIST_INSERT_IGNORE", row)
+	test.ExpectNotNil(t, err)
+
+	test.ExpectBool(t, qm.IsUpsert("ARTIST_UPSERT"), true)
+	test.ExpectBool(t, qm.IsUpsert("ARTIST_SEARCH"), false)
+	test.ExpectBool(t, qm.IsUpsert("MISSING"), false)
+}
+
+func TestTemplateInspection(t *testing.T) {
+
+	qm := sectionsQueryManager()
+
+	test.ExpectBool(t, qm.UsesParameter("ARTIST_SEARCH", "genres"), true)
+	test.ExpectBool(t, qm.UsesParameter("ARTIST_INSERT", "artists"), true)
+	test.ExpectBool(t, qm.UsesParameter("ARTIST_SEARCH", "artists"), false)
+	test.ExpectBool(t, qm.UsesParameter("MISSING", "name"), false)
+
+	qm.placeholder = DollarPlaceholder
+	test.ExpectBool(t, qm.Placeholders() == DollarPlaceholder, true)
+}
+
+func sectionsQueryManager() *TemplatedQueryManager {
+
+	f := filepath.Join("querymanager", "sections", "sections")
+	queryFiles := []string{test.FilePath(f)}
+
+	qm := buildQueryManager()
+	qm.DirectivePrefix = "--#"
+	qm.ValueProcessor = &SQLProcessor{BoolTrue: "1", BoolFalse: "0"}
+	qm.tokenisedTemplates = qm.parseQueryFiles(queryFiles)
+
+	return qm
+}
+
+func TestWiderParameterTypes(t *testing.T) {
+
+	qm := buildQueryManager()
+	qm.ValueProcessor = &SQLProcessor{BoolTrue: "TRUE", BoolFalse: "FALSE"}
+
+	qm.tokenisedTemplates = qm.parseQueryFiles([]string{test.FilePath(filepath.Join("querymanager", "bind", "bind-params"))})
+
+	var nilTime *time.Time
+
+	values := []struct {
+		v        interface{}
+		expected string
+	}{
+		{int8(-8), "-8"},
+		{uint16(16), "16"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{float32(1.5), "1.5"},
+		{2.25, "2.25"},
+		{true, "TRUE"},
+		{types.NewNilableBool(false), "FALSE"},
+		{types.NewNilableFloat64(0.1), "0.1"},
+		{[]byte{1}, "X'01'"},
+		{time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), "'2019-01-02 03:04:05+00:00'"},
+		{nilTime, "null"},
+	}
+
+	for _, v := range values {
+
+		q, err := qm.BuildQueryFromID("BIND_PARAMS", map[string]interface{}{"name": "x", "genre": v.v})
+
+		test.ExpectNil(t, err)
+		test.ExpectBool(t, strings.Contains(q, "genre = "+v.expected+" OR"), true)
+	}
+
+	q, args, err := qm.BuildParameterisedQueryFromID("BIND_PARAMS", map[string]interface{}{"name": uint8(1), "genre": nilTime})
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(q, "?"), true)
+	test.ExpectInt(t, len(args), 3)
+	test.ExpectNil(t, args[1])
+
+	qm.ValueProcessor = new(ConfigurableProcessor)
+
+	_, err = qm.BuildQueryFromID("BIND_PARAMS", map[string]interface{}{"name": "x", "genre": struct{}{}})
+	test.ExpectNotNil(t, err)
+}
+
+func writeTemplates(t *testing.T, dir string, files map[string]string) {
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStrictStartup(t *testing.T) {
+
+	invalid := []map[string]string{
+		{"a": "ID:ONE\nSELECT 1", "b": "ID:ONE\nSELECT 2"},
+		{"a": "SELECT 1\nID:ONE\nSELECT 1"},
+		{"a": "ID:ONE\nSELECT ${}"},
+		{"a": "ID:ONE\nSELECT ${ name }"},
+		{"a": "ID:ONE\nSELECT ${name"},
+		{"a": "ID:ONE\nSELECT ${a} ${b"},
+		{"a": "ID:ONE\nSELECT 1\n--#unknown"},
+		{"a": "ID:ONE\nINSERT INTO a VALUES (1)\n--#upsert"},
+		{"a": "ID:ONE\nINSERT INTO a VALUES (1)\n--#upsert ,"},
+		{"a": "ID:ONE\nINSERT INTO a VALUES (1)\n--#upsert , name"},
+	}
+
+	for _, files := range invalid {
+
+		dir := t.TempDir()
+		writeTemplates(t, dir, files)
+
+		qm := buildQueryManager()
+		qm.DirectivePrefix = "--#"
+		qm.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+		qm.TemplateLocation = dir
+
+		// Problems are logged unless strict startup is enabled
+		test.ExpectNil(t, qm.StartComponent())
+
+		qm = buildQueryManager()
+		qm.DirectivePrefix = "--#"
+		qm.TemplateLocation = dir
+		qm.StrictStartup = true
+
+		if err := qm.StartComponent(); err == nil {
+			t.Errorf("Expected strict startup to fail with templates %v", files)
+		}
+	}
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT ${!a}, ${1}, ${b.c}", "b": "ID:TWO\nSELECT 2"})
+
+	qm := buildQueryManager()
+	qm.TemplateLocation = dir
+	qm.StrictStartup = true
+
+	test.ExpectNil(t, qm.StartComponent())
+}
+
+func TestQueryDescriptions(t *testing.T) {
+
+	qm := sectionsQueryManager()
+
+	qd := qm.Queries()
+
+	if len(qd) == 0 {
+		t.Fatalf("Expected queries to be described")
+	}
+
+	for i := 1; i < len(qd); i++ {
+		if qd[i-1].ID >= qd[i].ID {
+			t.Errorf("Expected descriptions to be sorted by ID")
+		}
+	}
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT ${!a}, ${b}\n--#if c\nAND ${a} ${2}\n--#end"})
+
+	qm = buildQueryManager()
+	qm.DirectivePrefix = "--#"
+	qm.TemplateLocation = dir
+
+	test.ExpectNil(t, qm.StartComponent())
+
+	qd = qm.Queries()
+
+	test.ExpectInt(t, len(qd), 1)
+	test.ExpectString(t, qd[0].ID, "ONE")
+	test.ExpectString(t, strings.Join(qd[0].Parameters, ","), "!a,b,c,a,2")
+	test.ExpectString(t, qd[0].Source, filepath.Join(dir, "a"))
+}
+
+func TestTemplatesFromFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"resource/queries/a":     {Data: []byte("ID:ONE\nSELECT ${a}")},
+		"resource/queries/sub/b": {Data: []byte("ID:TWO\nSELECT 2")},
+		"resource/other/c":       {Data: []byte("ID:THREE\nSELECT 3")},
+	}
+
+	qm := buildQueryManager()
+	qm.TemplateFS = fsys
+	qm.TemplateLocation = "resource/queries"
+	qm.StrictStartup = true
+
+	test.ExpectNil(t, qm.StartComponent())
+
+	q, err := qm.BuildQueryFromID("ONE", map[string]interface{}{"a": 1})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, strings.TrimSpace(q), "SELECT 1")
+
+	_, err = qm.FragmentFromID("THREE")
+	test.ExpectNotNil(t, err)
+
+	qd := qm.Queries()
+
+	test.ExpectInt(t, len(qd), 2)
+	test.ExpectString(t, qd[1].Source, "resource/queries/sub/b")
+
+	// Templates are reloaded from the same filesystem
+	fsys["resource/queries/a"] = &fstest.MapFile{Data: []byte("ID:ONE\nSELECT ${a}, 2")}
+
+	test.ExpectNil(t, qm.Reload())
+
+	q, _ = qm.BuildQueryFromID("ONE", map[string]interface{}{"a": 1})
+	test.ExpectString(t, strings.TrimSpace(q), "SELECT 1, 2")
+
+	qm = buildQueryManager()
+	qm.TemplateFS = fsys
+	qm.TemplateLocation = "resource/missing"
+
+	test.ExpectNotNil(t, qm.StartComponent())
+}
@@ -0,0 +1,304 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Directives that can be used in a query template to mark the start and end of a section.
+const (
+	// IfDirective includes the content of the section only if the named parameter is set
+	IfDirective = "if"
+	// WhereDirective prefixes the content of the section with WHERE (after removing any leading AND or OR) if the content
+	// is not empty after any nested sections have been processed.
+	WhereDirective = "where"
+	// RangeDirective repeats the content of the section for each element of the named slice parameter
+	RangeDirective = "range"
+	// EndDirective marks the end of the most recently started section
+	EndDirective = "end"
+	// UpsertDirective is replaced with the clause that makes an INSERT update (or ignore) rows that conflict with the
+	// named unique key columns, using the syntax of the TemplatedQueryManager's Dialect. It does not start a section.
+	UpsertDirective = "upsert"
+)
+
+const defaultRangeSeparator = ","
+
+// varWriter writes the value of a variable to the query being built. topLevel is true if the value was found in the
+// parameters supplied when the query was built, rather than in an element of a range.
+type varWriter func(b *bytes.Buffer, key string, required bool, value interface{}, topLevel bool) error
+
+func newTemplateRenderer(qid string, dialect string, params map[string]interface{}, write varWriter) *templateRenderer {
+	tr := new(templateRenderer)
+	tr.qid = qid
+	tr.dialect = dialect
+	tr.scopes = []map[string]interface{}{params}
+	tr.write = write
+
+	return tr
+}
+
+// templateRenderer walks the tokens of a query template, evaluating any sections and passing each variable it
+// encounters to a varWriter.
+type templateRenderer struct {
+	qid     string
+	dialect string
+	scopes  []map[string]interface{}
+	write   varWriter
+}
+
+func (tr *templateRenderer) render(b *bytes.Buffer, tokens []*queryTemplateToken) error {
+
+	for i := 0; i < len(tokens); i++ {
+
+		t := tokens[i]
+
+		switch t.Type {
+		case fragmentToken:
+			b.WriteString(t.Content)
+
+		case varNameToken, varIndexToken:
+			key, required := splitRequired(t.Content)
+			v, topLevel := tr.lookup(key)
+
+			if err := tr.write(b, key, required, v, topLevel); err != nil {
+				return err
+			}
+
+		case upsertToken:
+			if err := tr.upsert(b, t); err != nil {
+				return err
+			}
+
+		case sectionToken:
+			inner := tokens[i+1 : i+1+t.length]
+
+			if err := tr.section(b, t, inner); err != nil {
+				return err
+			}
+
+			// Skip the section's content and its end token
+			i += t.length + 1
+		}
+	}
+
+	return nil
+}
+
+func (tr *templateRenderer) section(b *bytes.Buffer, t *queryTemplateToken, inner []*queryTemplateToken) error {
+
+	switch t.Directive {
+
+	case IfDirective:
+		key, _ := splitRequired(t.Content)
+
+		if v, _ := tr.lookup(key); paramSet(v) {
+			return tr.render(b, inner)
+		}
+
+	case WhereDirective:
+		var clause bytes.Buffer
+
+		if err := tr.render(&clause, inner); err != nil {
+			return err
+		}
+
+		writeWhere(b, clause.String())
+
+	case RangeDirective:
+		return tr.repeat(b, t, inner)
+	}
+
+	return nil
+}
+
+func (tr *templateRenderer) repeat(b *bytes.Buffer, t *queryTemplateToken, inner []*queryTemplateToken) error {
+
+	key, required := splitRequired(t.Content)
+	v, _ := tr.lookup(key)
+
+	if !paramSet(v) {
+
+		if required {
+			return fmt.Errorf("parameter %s is required for query %s but has not been set", key, tr.qid)
+		}
+
+		return nil
+	}
+
+	if !isExpandable(v) {
+		return fmt.Errorf("parameter %s for query %s must be a slice to be used in a %s section (type is %T)", key, tr.qid, RangeDirective, v)
+	}
+
+	elements := expand(v)
+
+	for i, e := range elements {
+
+		scope, found := e.(map[string]interface{})
+
+		if !found {
+			return fmt.Errorf("element %d of parameter %s for query %s is not a map[string]interface{} (type is %T)", i, key, tr.qid, e)
+		}
+
+		var eb bytes.Buffer
+
+		tr.scopes = append(tr.scopes, scope)
+		err := tr.render(&eb, inner)
+		tr.scopes = tr.scopes[:len(tr.scopes)-1]
+
+		if err != nil {
+			return err
+		}
+
+		b.WriteString(strings.TrimRight(eb.String(), "\n"))
+
+		if i < len(elements)-1 {
+			b.WriteString(t.Separator)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return nil
+}
+
+// upsert writes the clause for an upsert directive. MySQL does not allow the conflicting key to be specified, so
+// the key columns are only used to 'update' a row to itself if no columns are to be updated.
+func (tr *templateRenderer) upsert(b *bytes.Buffer, t *queryTemplateToken) error {
+
+	var clauses []string
+
+	switch strings.ToLower(tr.dialect) {
+
+	case MySQLDialect:
+		b.WriteString("ON DUPLICATE KEY UPDATE ")
+
+		for _, c := range t.Updates {
+			clauses = append(clauses, fmt.Sprintf("%s = VALUES(%s)", c, c))
+		}
+
+		if len(clauses) == 0 {
+			clauses = append(clauses, fmt.Sprintf("%s = %s", t.Keys[0], t.Keys[0]))
+		}
+
+	case PostgreSQLDialect, SQLiteDialect:
+		b.WriteString("ON CONFLICT (")
+		b.WriteString(strings.Join(t.Keys, ", "))
+		b.WriteString(") ")
+
+		if len(t.Updates) == 0 {
+			b.WriteString("DO NOTHING\n")
+			return nil
+		}
+
+		b.WriteString("DO UPDATE SET ")
+
+		for _, c := range t.Updates {
+			clauses = append(clauses, fmt.Sprintf("%s = excluded.%s", c, c))
+		}
+
+	default:
+		return fmt.Errorf("the %s directive in query %s is only supported if Dialect is %s, %s or %s", UpsertDirective, tr.qid,
+			MySQLDialect, PostgreSQLDialect, SQLiteDialect)
+	}
+
+	b.WriteString(strings.Join(clauses, ", "))
+	b.WriteString("\n")
+
+	return nil
+}
+
+// lookup finds the value of the named parameter, searching the element of the innermost range first.
+func (tr *templateRenderer) lookup(key string) (interface{}, bool) {
+
+	for i := len(tr.scopes) - 1; i >= 0; i-- {
+		if v, found := tr.scopes[i][key]; found {
+			return v, i == 0
+		}
+	}
+
+	return nil, true
+}
+
+func writeWhere(b *bytes.Buffer, clause string) {
+
+	c := strings.TrimSpace(clause)
+
+	for _, op := range []string{"AND", "OR"} {
+
+		if len(c) > len(op) && strings.EqualFold(c[:len(op)], op) {
+
+			next := rune(c[len(op)])
+
+			if unicode.IsSpace(next) || next == '(' {
+				c = strings.TrimSpace(c[len(op):])
+				break
+			}
+		}
+	}
+
+	if c == "" {
+		return
+	}
+
+	b.WriteString("WHERE ")
+	b.WriteString(c)
+	b.WriteString("\n")
+}
+
+func splitRequired(key string) (string, bool) {
+
+	if strings.HasPrefix(key, requiredPrefix) {
+		return strings.Replace(key, requiredPrefix, "", 1), true
+	}
+
+	return key, false
+}
+
+// paramSet returns false if the supplied value is nil, an unset nilable type or an empty slice.
+func paramSet(v interface{}) bool {
+
+	if _, set := bindValue(v); !set {
+		return false
+	}
+
+	if isExpandable(v) {
+		return reflect.ValueOf(v).Len() > 0
+	}
+
+	return true
+}
+
+// isExpandable returns true if the supplied value is a slice or array that should be expanded into a comma separated
+// list when used as the value of a variable. Byte slices are treated as a single value.
+func isExpandable(v interface{}) bool {
+
+	if v == nil {
+		return false
+	}
+
+	if _, found := v.([]byte); found {
+		return false
+	}
+
+	k := reflect.TypeOf(v).Kind()
+
+	return k == reflect.Slice || k == reflect.Array
+}
+
+func expand(v interface{}) []interface{} {
+
+	rv := reflect.ValueOf(v)
+	elements := make([]interface{}, rv.Len())
+
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+
+	return elements
+}
@@ -4,8 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/types"
+	"time"
 )
 
+// DefaultTimeFormat is the layout used to convert time.Time parameters to strings if a ParamValueProcessor's TimeFormat is not set.
+const DefaultTimeFormat = "2006-01-02 15:04:05.999999-07:00"
+
 // ParamValueProcessor is implemented by components able to escape the value of a parameter to a query and handle unset parameters
 type ParamValueProcessor interface {
 	EscapeParamValue(v *paramValueContext)
@@ -40,6 +44,9 @@ type ConfigurableProcessor struct {
 
 	// A string that will be used as a prefix and suffix to a string parameter if WrapStrings is true.
 	StringWrapWith string
+
+	// The layout (see time.Time.Format) used to convert time.Time parameters to strings. If not set, DefaultTimeFormat is used.
+	TimeFormat string
 }
 
 // EscapeParamValue implements ParamValueProcessor.EscapeParamValue
@@ -52,6 +59,12 @@ func (cp *ConfigurableProcessor) EscapeParamValue(v *paramValueContext) {
 		cp.wrapString(v, t.String())
 	case *types.NilableString:
 		cp.wrapString(v, t.String())
+	case []byte:
+		cp.wrapString(v, string(t))
+	case time.Time:
+		cp.wrapString(v, formatTime(t, cp.TimeFormat))
+	case *time.Time:
+		cp.wrapString(v, formatTime(*t, cp.TimeFormat))
 	}
 
 }
@@ -78,11 +91,14 @@ func (cp *ConfigurableProcessor) SubstituteUnset(v *paramValueContext) error {
 	return nil
 }
 
-// SQLProcessor replaces missing values with the word null, wraps strings with single quotes and
-// replaces bool values with the value the BoolTrue and BoolFalse members
+// SQLProcessor replaces missing values with the word null, wraps strings and times with single quotes,
+// replaces bool values with the value the BoolTrue and BoolFalse members and converts byte slices to hexadecimal literals
 type SQLProcessor struct {
 	BoolTrue  string
 	BoolFalse string
+
+	// The layout (see time.Time.Format) used to convert time.Time parameters to strings. If not set, DefaultTimeFormat is used.
+	TimeFormat string
 }
 
 // EscapeParamValue modifies the value in the supplied parameter + value so that is beocomes valid SQL
@@ -101,6 +117,12 @@ func (sp *SQLProcessor) EscapeParamValue(v *paramValueContext) {
 		sp.replaceBool(v, t.Bool())
 	case *types.NilableBool:
 		sp.replaceBool(v, t.Bool())
+	case time.Time:
+		sp.escapeString(v, formatTime(t, sp.TimeFormat))
+	case *time.Time:
+		sp.escapeString(v, formatTime(*t, sp.TimeFormat))
+	case []byte:
+		v.Value = fmt.Sprintf("X'%X'", t)
 	}
 }
 
@@ -129,3 +151,12 @@ func (sp *SQLProcessor) SubstituteUnset(v *paramValueContext) error {
 
 	return nil
 }
+
+func formatTime(t time.Time, layout string) string {
+
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+
+	return t.Format(layout)
+}
@@ -1,7 +1,9 @@
 package dsquery
 
 import (
+	"github.com/graniticio/granitic/v2/test"
 	"testing"
+	"time"
 )
 
 func TestSQLProcessor(t *testing.T) {
@@ -32,3 +34,31 @@ func TestSQLProcessor(t *testing.T) {
 	}
 
 }
+
+func TestTimeAndByteEscaping(t *testing.T) {
+
+	tm := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	sp := new(SQLProcessor)
+
+	pvc := paramValueContext{Value: tm}
+	sp.EscapeParamValue(&pvc)
+	test.ExpectString(t, pvc.Value.(string), "'2019-03-04 05:06:07+00:00'")
+
+	pvc = paramValueContext{Value: []byte{0x0a, 0xff}}
+	sp.EscapeParamValue(&pvc)
+	test.ExpectString(t, pvc.Value.(string), "X'0AFF'")
+
+	cp := new(ConfigurableProcessor)
+	cp.WrapStrings = true
+	cp.StringWrapWith = "\""
+	cp.TimeFormat = "2006-01-02"
+
+	pvc = paramValueContext{Value: &tm}
+	cp.EscapeParamValue(&pvc)
+	test.ExpectString(t, pvc.Value.(string), "\"2019-03-04\"")
+
+	pvc = paramValueContext{Value: []byte("abc")}
+	cp.EscapeParamValue(&pvc)
+	test.ExpectString(t, pvc.Value.(string), "\"abc\"")
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package dsquery
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is used if TemplatedQueryManager.WatchIntervalMS is not set.
+const DefaultWatchInterval = time.Second
+
+// templateWatcher periodically checks the files in a TemplatedQueryManager's TemplateLocation and reloads all
+// templates when a file is added, changed or removed.
+type templateWatcher struct {
+	qm       *TemplatedQueryManager
+	snapshot map[string]string
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+func (qm *TemplatedQueryManager) watch() {
+
+	interval := DefaultWatchInterval
+
+	if qm.WatchIntervalMS > 0 {
+		interval = time.Duration(qm.WatchIntervalMS) * time.Millisecond
+	}
+
+	tw := new(templateWatcher)
+	tw.qm = qm
+	tw.snapshot, _ = qm.snapshotTemplates()
+	tw.stop = make(chan struct{})
+
+	qm.watcher = tw
+
+	qm.FrameworkLogger.LogInfof("Watching %s for changes to query templates", qm.TemplateLocation)
+
+	tw.stopped.Add(1)
+
+	go func() {
+		defer tw.stopped.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-tw.stop:
+				return
+			case <-t.C:
+				tw.check()
+			}
+		}
+	}()
+}
+
+// check reloads the templates if the files in TemplateLocation have changed since the last successful reload.
+func (tw *templateWatcher) check() {
+
+	current, err := tw.qm.snapshotTemplates()
+
+	if err != nil {
+		tw.qm.FrameworkLogger.LogErrorf("Unable to check query templates for changes: %s", err.Error())
+		return
+	}
+
+	if sameSnapshot(tw.snapshot, current) {
+		return
+	}
+
+	if err := tw.qm.Reload(); err != nil {
+		// The snapshot is not updated so that the reload is retried at the next check
+		tw.qm.FrameworkLogger.LogErrorf("%s", err.Error())
+		return
+	}
+
+	tw.snapshot = current
+}
+
+func (tw *templateWatcher) stopWatching() {
+	close(tw.stop)
+	tw.stopped.Wait()
+}
+
+// Reload re-reads all of the templates in TemplateLocation, replacing the templates currently in use. If StrictStartup
+// is true and any of the templates have problems, an error is returned and the templates currently in use are kept.
+// Otherwise any problems are logged.
+func (qm *TemplatedQueryManager) Reload() error {
+
+	files, err := qm.templateFiles()
+
+	if err != nil {
+		return fmt.Errorf("Unable to reload query templates: %s", err.Error())
+	}
+
+	templates, problems := qm.loadTemplates(files)
+
+	if qm.StrictStartup && len(problems) > 0 {
+		return fmt.Errorf("Query templates not reloaded due to problems with query templates: %s", joinErrors(problems))
+	}
+
+	qm.logProblems(problems)
+
+	qm.mu.Lock()
+	qm.tokenisedTemplates = templates
+	qm.fragments = make(map[string]string)
+	listeners := qm.reloadListeners
+	qm.mu.Unlock()
+
+	qm.FrameworkLogger.LogInfof("Reloaded %d query templates", len(templates))
+
+	for _, f := range listeners {
+		f()
+	}
+
+	return nil
+}
+
+// OnReload implements ReloadNotifier, registering a function to be called each time Reload replaces the templates in use.
+func (qm *TemplatedQueryManager) OnReload(f func()) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	qm.reloadListeners = append(qm.reloadListeners, f)
+}
+
+// snapshotTemplates records the modification time and size of each file in TemplateLocation.
+func (qm *TemplatedQueryManager) snapshotTemplates() (map[string]string, error) {
+
+	files, err := qm.templateFiles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]string)
+
+	for _, f := range files {
+
+		info, err := qm.statTemplate(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot[f] = fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	return snapshot, nil
+}
+
+func sameSnapshot(a, b map[string]string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for f, s := range a {
+		if b[f] != s {
+			return false
+		}
+	}
+
+	return true
+}
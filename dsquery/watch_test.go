@@ -0,0 +1,135 @@
+package dsquery
+
+import (
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTemplatesReloadedWhenChanged(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT 1"})
+
+	qm := buildQueryManager()
+	qm.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	qm.TemplateLocation = dir
+	qm.WatchTemplates = true
+	qm.WatchIntervalMS = 10
+
+	test.ExpectNil(t, qm.StartComponent())
+	defer qm.Stop()
+
+	q, err := qm.BuildQueryFromID("ONE", nil)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "SELECT 1\n")
+
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT 11", "b": "ID:TWO\nSELECT 2"})
+
+	waitFor(t, func() bool {
+		_, err := qm.BuildQueryFromID("TWO", nil)
+		return err == nil
+	})
+
+	q, _ = qm.BuildQueryFromID("ONE", nil)
+	test.ExpectString(t, q, "SELECT 11\n")
+
+	os.Remove(filepath.Join(dir, "b"))
+
+	waitFor(t, func() bool {
+		_, err := qm.BuildQueryFromID("TWO", nil)
+		return err != nil
+	})
+}
+
+func TestStrictReloadKeepsExistingTemplates(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT 1"})
+
+	qm := buildQueryManager()
+	qm.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	qm.TemplateLocation = dir
+	qm.StrictStartup = true
+
+	test.ExpectNil(t, qm.StartComponent())
+
+	reloads := 0
+	qm.OnReload(func() { reloads++ })
+
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT ${one", "b": "ID:TWO\nSELECT 2"})
+
+	test.ExpectNotNil(t, qm.Reload())
+	test.ExpectInt(t, reloads, 0)
+
+	_, err := qm.BuildQueryFromID("TWO", nil)
+	test.ExpectNotNil(t, err)
+
+	q, _ := qm.BuildQueryFromID("ONE", nil)
+	test.ExpectString(t, q, "SELECT 1\n")
+
+	qm.StrictStartup = false
+
+	test.ExpectNil(t, qm.Reload())
+	test.ExpectInt(t, reloads, 1)
+
+	_, err = qm.BuildQueryFromID("TWO", nil)
+	test.ExpectNil(t, err)
+}
+
+func TestFailedReloadRetried(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT 1"})
+
+	qm := buildQueryManager()
+	qm.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	qm.TemplateLocation = dir
+	qm.StrictStartup = true
+
+	test.ExpectNil(t, qm.StartComponent())
+
+	tw := new(templateWatcher)
+	tw.qm = qm
+	tw.snapshot, _ = qm.snapshotTemplates()
+
+	writeTemplates(t, dir, map[string]string{"a": "ID:ONE\nSELECT ${one", "b": "ID:TWO\nSELECT 2"})
+
+	tw.check()
+
+	_, err := qm.BuildQueryFromID("TWO", nil)
+	test.ExpectNotNil(t, err)
+
+	// The files have not changed since the failed reload, but the reload is attempted again
+	qm.StrictStartup = false
+
+	tw.check()
+
+	_, err = qm.BuildQueryFromID("TWO", nil)
+	test.ExpectNil(t, err)
+}
+
+func TestSnapshotComparison(t *testing.T) {
+
+	test.ExpectBool(t, sameSnapshot(map[string]string{"a": "1"}, map[string]string{"a": "1"}), true)
+	test.ExpectBool(t, sameSnapshot(map[string]string{"a": "1"}, map[string]string{"a": "2"}), false)
+	test.ExpectBool(t, sameSnapshot(map[string]string{"a": "1"}, map[string]string{"b": "1"}), false)
+	test.ExpectBool(t, sameSnapshot(map[string]string{"a": "1"}, map[string]string{"a": "1", "b": "1"}), false)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !condition() {
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for templates to be reloaded")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -28,6 +28,17 @@ const instrumentationDecoratorName = instance.FrameworkPrefix + "RequestInstrume
 // (see https://granitic.io/ref/component-definition-files )
 const HTTPServerAbnormalStatusFieldName = "AbnormalStatusWriter"
 const accessLogWriterName = instance.FrameworkPrefix + "AccessLogWriter"
+const securityHeadersComponentName = instance.FrameworkPrefix + "SecurityHeaders"
+const requestStatsCommandName = instance.FrameworkPrefix + "CommandRequestStats"
+const tracerComponentName = instance.FrameworkPrefix + "Tracer"
+const spanExporterComponentName = instance.FrameworkPrefix + "JSONSpanExporter"
+
+// HTTPServerFacilityName is the name of the facility
+const HTTPServerFacilityName = "HTTPServer"
+
+// LatencyRecorderComponentName is the name of the instrument.LatencyRecorder component created when
+// HTTPServer.LatencyRecording.Enabled is set to true.
+const LatencyRecorderComponentName = instance.FrameworkPrefix + "LatencyRecorder"
 
 // FacilityBuilder creates the components that make up the HTTPServer facility (the server and an access log writer).
 type FacilityBuilder struct {
@@ -52,10 +63,22 @@ func (hsfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 		cn.WrapAndAddProto(accessLogWriterName, accessLogWriter)
 	}
 
+	if err := configureSecurityHeaders(ca, cn, httpServer); err != nil {
+		return err
+	}
+
 	idbd := new(contextBuilderDecorator)
 	idbd.Server = httpServer
 	cn.WrapAndAddProto(contextIDDecoratorName, idbd)
 
+	if err := configureLatencyRecording(ca, cn, httpServer); err != nil {
+		return err
+	}
+
+	if err := configureTracing(lm, ca, cn, httpServer); err != nil {
+		return err
+	}
+
 	if !httpServer.DisableInstrumentationAutoWire {
 
 		log.LogDebugf("Will attempt to auto-wire an implementation of instrument.RequestInstrumentationManager")
@@ -119,9 +142,137 @@ func configureRequestIDGeneration(ca *config.Accessor, log logging.Logger, s *HT
 
 }
 
+func configureSecurityHeaders(ca *config.Accessor, cn *ioc.ComponentContainer, s *HTTPServer) error {
+
+	basePath := "HTTPServer.SecurityHeaders"
+
+	if !ca.PathExists(basePath) {
+		return nil
+	}
+
+	cfg := new(securityHeadersConfig)
+
+	if err := ca.Populate(basePath, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for security headers %s", err.Error())
+	} else if !cfg.Enabled {
+		return nil
+	}
+
+	sh := new(SecurityHeaders)
+	sh.Headers = cfg.Headers
+	sh.HandlerOverrides = cfg.HandlerOverrides
+
+	s.SecurityHeaders = sh
+
+	cn.WrapAndAddProto(securityHeadersComponentName, sh)
+
+	return nil
+}
+
+func configureLatencyRecording(ca *config.Accessor, cn *ioc.ComponentContainer, s *HTTPServer) error {
+
+	basePath := "HTTPServer.LatencyRecording"
+
+	if !ca.PathExists(basePath) {
+		return nil
+	}
+
+	enabled, err := ca.BoolVal(basePath + ".Enabled")
+
+	if err != nil {
+		return fmt.Errorf("Unable to read configuration for latency recording %s", err.Error())
+	} else if !enabled {
+		return nil
+	}
+
+	lr := new(instrument.LatencyRecorder)
+
+	if err := ca.Populate(basePath, lr); err != nil {
+		return fmt.Errorf("Unable to read configuration for latency recording %s", err.Error())
+	}
+
+	cn.WrapAndAddProto(LatencyRecorderComponentName, lr)
+
+	if s.DisableInstrumentationAutoWire {
+		s.InstrumentationManager = instrument.CombineManagers(s.InstrumentationManager, lr)
+	}
+
+	if runtimeCtlEnabled(ca) {
+		rc := new(requestStatsCommand)
+		rc.Recorder = lr
+
+		cn.WrapAndAddProto(requestStatsCommandName, rc)
+	}
+
+	return nil
+}
+
+func configureTracing(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer, s *HTTPServer) error {
+
+	basePath := "HTTPServer.Tracing"
+
+	if !ca.PathExists(basePath) {
+		return nil
+	}
+
+	cfg := new(tracingConfig)
+
+	if err := ca.Populate(basePath, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for tracing %s", err.Error())
+	} else if !cfg.Enabled {
+		return nil
+	}
+
+	t := new(instrument.Tracer)
+	t.ServiceName = cfg.ServiceName
+	t.FrameworkLogger = lm.CreateLogger(tracerComponentName)
+
+	exporters := cn.ProtoComponentsByType(func(i interface{}) bool {
+		_, found := i.(instrument.SpanExporter)
+		return found
+	})
+
+	switch len(exporters) {
+	case 0:
+		je := new(instrument.JSONSpanExporter)
+		je.Path = cfg.JSONExporter.Path
+
+		t.Exporter = je
+
+		cn.WrapAndAddProto(spanExporterComponentName, je)
+	case 1:
+		t.Exporter = exporters[0].Component.Instance.(instrument.SpanExporter)
+	default:
+		return fmt.Errorf("Tracing is enabled but more than one component implements instrument.SpanExporter")
+	}
+
+	cn.WrapAndAddProto(tracerComponentName, t)
+
+	if s.DisableInstrumentationAutoWire {
+		s.InstrumentationManager = instrument.CombineManagers(s.InstrumentationManager, t)
+	}
+
+	return nil
+}
+
+// runtimeCtlEnabled checks to see if the RuntimeCtl facility is enabled in configuration (the runtimectl
+// package cannot be imported from this package)
+func runtimeCtlEnabled(ca *config.Accessor) bool {
+
+	p := "Facilities.RuntimeCtl"
+
+	if !ca.PathExists(p) {
+		return false
+	}
+
+	b, _ := ca.BoolVal(p)
+
+	return b
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (hsfb *FacilityBuilder) FacilityName() string {
-	return "HTTPServer"
+	return HTTPServerFacilityName
 }
 
 // DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
@@ -147,19 +298,19 @@ func (id *instrumentationDecorator) OfInterest(subject *ioc.Component) bool {
 	return result
 }
 
-// DecorateComponent injects the instrument.RequestInstrumentationManager into the HTTP server
+// DecorateComponent injects the instrument.RequestInstrumentationManager into the HTTP server. If more than one
+// component implements instrument.RequestInstrumentationManager, requests are passed to all of them.
 func (id *instrumentationDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
 
 	im := subject.Instance.(instrument.RequestInstrumentationManager)
 
 	if id.Server.InstrumentationManager != nil {
-		id.Log.LogWarnf("Multiple components implementing instrument.RequestInstrumentationManager found. Using %s", subject.Name)
-
+		id.Log.LogDebugf("Multiple components implementing instrument.RequestInstrumentationManager found. Adding %s", subject.Name)
 	}
 
 	id.Log.LogDebugf("HTTP server using %s for instrumentation", subject.Name)
 
-	id.Server.InstrumentationManager = im
+	id.Server.InstrumentationManager = instrument.CombineManagers(id.Server.InstrumentationManager, im)
 }
 
 type requestIDConfig struct {
@@ -170,6 +321,20 @@ type requestIDConfig struct {
 	}
 }
 
+type tracingConfig struct {
+	Enabled      bool
+	ServiceName  string
+	JSONExporter struct {
+		Path string
+	}
+}
+
+type securityHeadersConfig struct {
+	Enabled          bool
+	Headers          map[string]string
+	HandlerOverrides map[string]map[string]string
+}
+
 type requestContextBuilder struct {
 	idGen   uuid.Generate16Byte
 	encoder uuid.EncodeFrom16Byte
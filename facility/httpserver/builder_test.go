@@ -1,6 +1,14 @@
 package httpserver
 
-import "testing"
+import (
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"testing"
+)
 
 func TestFacilityNaming(t *testing.T) {
 
@@ -11,3 +19,72 @@ func TestFacilityNaming(t *testing.T) {
 	}
 
 }
+
+func TestTracingConfiguration(t *testing.T) {
+
+	var jd map[string]interface{}
+
+	json.Unmarshal([]byte(`{"HTTPServer": {"Tracing": {"Enabled": true, "ServiceName": "svc", "JSONExporter": {"Path": ""}}}}`), &jd)
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+	ca := &config.Accessor{JSONData: jd, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+	cn := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	s := new(HTTPServer)
+	s.DisableInstrumentationAutoWire = true
+
+	if err := configureTracing(lm, ca, cn, s); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	tr, found := s.InstrumentationManager.(*instrument.Tracer)
+
+	if !found {
+		t.Fatalf("Expected Tracer to be injected")
+	}
+
+	if tr.ServiceName != "svc" || cn.ProtoComponents()[spanExporterComponentName] == nil {
+		t.Errorf("Tracer not configured")
+	}
+
+	// Latency recording and tracing can both be enabled
+	lr := new(instrument.LatencyRecorder)
+
+	s = new(HTTPServer)
+	s.DisableInstrumentationAutoWire = true
+	s.InstrumentationManager = lr
+
+	if err := configureTracing(lm, ca, cn, s); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	mm, found := s.InstrumentationManager.(*instrument.MultiRequestInstrumentationManager)
+
+	if !found || len(mm.Managers) != 2 || mm.Managers[0] != lr {
+		t.Errorf("Expected requests to be passed to both the LatencyRecorder and the Tracer")
+	}
+}
+
+func TestMultipleInstrumentationManagersDecorated(t *testing.T) {
+
+	s := new(HTTPServer)
+
+	id := new(instrumentationDecorator)
+	id.Server = s
+	id.Log = new(logging.ConsoleErrorLogger)
+
+	lr := new(instrument.LatencyRecorder)
+	other := new(instrument.LatencyRecorder)
+
+	for _, c := range []*ioc.Component{ioc.NewComponent("lr", lr), ioc.NewComponent("other", other), ioc.NewComponent("other", other)} {
+		if id.OfInterest(c) {
+			id.DecorateComponent(c, nil)
+		}
+	}
+
+	mm, found := s.InstrumentationManager.(*instrument.MultiRequestInstrumentationManager)
+
+	if !found || len(mm.Managers) != 2 {
+		t.Errorf("Expected requests to be passed to both instrumentation managers")
+	}
+}
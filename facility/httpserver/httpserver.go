@@ -91,6 +91,10 @@ type HTTPServer struct {
 	// A component able to use data in an HTTP request's headers to populate a context
 	IDContextBuilder IdentifiedRequestContextBuilder
 
+	// A component that adds security related headers to every response. Automatically added by this facility's builder if
+	// HTTPServer.SecurityHeaders.Enabled is set to true in configuration.
+	SecurityHeaders *SecurityHeaders
+
 	state  ioc.ComponentState
 	server *http.Server
 }
@@ -203,6 +207,11 @@ func (h *HTTPServer) Resume() error {
 	return nil
 }
 
+// Suspended returns true if the server has been suspended and is rejecting new requests.
+func (h *HTTPServer) Suspended() bool {
+	return h.state == ioc.SuspendedState
+}
+
 // AllowAccess starts the server listening on the configured address and port. Returns an error if the port is already in use.
 func (h *HTTPServer) AllowAccess() error {
 
@@ -263,13 +272,20 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 	ctx, cancelFunc := context.WithCancel(req.Context())
 	defer cancelFunc()
 
-	if h.AllowEarlyInstrumentation {
-		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, res, req)
-		defer endInstrumentation()
+	var shw *securityHeaderWriter
+
+	if h.SecurityHeaders != nil {
+		shw = h.SecurityHeaders.wrap(res)
+		res = shw
 	}
 
 	wrw := httpendpoint.NewHTTPResponseWriter(res)
 
+	if h.AllowEarlyInstrumentation {
+		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, res, req)
+		defer h.endInstrumentation(instrumentor, wrw, endInstrumentation)
+	}
+
 	if h.state != ioc.RunningState {
 		// The HTTP server is suspended - reject the request
 		h.writeAbnormal(ctx, h.TooBusyStatus, wrw)
@@ -287,7 +303,7 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 
 	if instrumentor == nil {
 		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, res, req)
-		defer endInstrumentation()
+		defer h.endInstrumentation(instrumentor, wrw, endInstrumentation)
 	}
 
 	var requestID string
@@ -334,6 +350,11 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		if pattern.MatchString(path) && h.versionMatch(instrumentor, req, handlerPattern.Provider) {
 			h.FrameworkLogger.LogTracef("Matches %s", pattern.String())
 			matched = true
+
+			if shw != nil {
+				shw.servingHandler(handlerPattern.Provider)
+			}
+
 			ctx = handlerPattern.Provider.ServeHTTP(ctx, wrw, req)
 		}
 	}
@@ -353,6 +374,22 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 
 }
 
+// endInstrumentation passes the HTTP status code of the response to the instrumentor before instrumentation of the request
+// is ended.
+func (h *HTTPServer) endInstrumentation(ri instrument.Instrumentor, wrw *httpendpoint.HTTPResponseWriter, end func()) {
+
+	status := wrw.Status
+
+	if status == 0 {
+		// No status explicitly written - the underlying http.ResponseWriter will have sent a 200
+		status = http.StatusOK
+	}
+
+	ri.Amend(instrument.ResponseStatus, status)
+
+	end()
+}
+
 func (h *HTTPServer) versionMatch(ri instrument.Instrumentor, r *http.Request, p httpendpoint.Provider) bool {
 
 	if h.VersionExtractor == nil || !p.VersionAware() {
@@ -0,0 +1,161 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ioc"
+	"net"
+	"net/http"
+)
+
+// SecurityHeaders is a component that adds a configurable set of security-related headers (Strict-Transport-Security,
+// Content-Security-Policy, X-Content-Type-Options, X-Frame-Options etc) to every response sent by the HTTPServer, including
+// responses written by an AbnormalStatusWriter (404s, 'too busy' responses etc).
+//
+// Headers are only added to a response if the header has not already been set by a handler or response writer, so
+// application code always has the final say over the value of a header.
+type SecurityHeaders struct {
+	// The headers (and their values) that should be added to every response.
+	Headers map[string]string
+
+	// Per-handler modifications to Headers. The outer map is keyed on the component name of the handler; the inner
+	// map contains headers that should replace the defaults. A header with an empty value will not be sent
+	// for requests served by that handler.
+	HandlerOverrides map[string]map[string]string
+
+	defaults  http.Header
+	overrides map[string]http.Header
+}
+
+// StartComponent canonicalises the names of the configured headers and pre-computes the headers to be used for
+// each handler that has overrides.
+func (sh *SecurityHeaders) StartComponent() error {
+
+	sh.defaults = make(http.Header)
+
+	for k, v := range sh.Headers {
+
+		if v == "" {
+			return fmt.Errorf("security header %s has an empty value", k)
+		}
+
+		sh.defaults.Set(k, v)
+	}
+
+	sh.overrides = make(map[string]http.Header)
+
+	for handler, headers := range sh.HandlerOverrides {
+
+		merged := sh.defaults.Clone()
+
+		for k, v := range headers {
+
+			if v == "" {
+				merged.Del(k)
+			} else {
+				merged.Set(k, v)
+			}
+		}
+
+		sh.overrides[handler] = merged
+	}
+
+	return nil
+}
+
+// HeadersFor returns the headers that should be added to a response served by the named handler. If handlerName
+// is an empty string (no handler matched the request) or no overrides are defined for the handler, the default headers are returned.
+func (sh *SecurityHeaders) HeadersFor(handlerName string) http.Header {
+
+	if h, found := sh.overrides[handlerName]; found {
+		return h
+	}
+
+	return sh.defaults
+}
+
+// wrap creates an http.ResponseWriter that will add security headers to the response immediately before the status
+// line is written.
+func (sh *SecurityHeaders) wrap(rw http.ResponseWriter) *securityHeaderWriter {
+	sw := new(securityHeaderWriter)
+	sw.ResponseWriter = rw
+	sw.headers = sh
+
+	return sw
+}
+
+// securityHeaderWriter delays the addition of security headers until the response is about to be committed, so that
+// any headers already set by the handler take precedence and per-handler overrides can be applied once a handler has
+// been matched to the request.
+type securityHeaderWriter struct {
+	http.ResponseWriter
+	headers *SecurityHeaders
+	handler string
+	applied bool
+}
+
+// WriteHeader adds security headers and then calls through to the underlying http.ResponseWriter
+func (sw *securityHeaderWriter) WriteHeader(status int) {
+	sw.apply()
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// Write adds security headers (if they have not already been added) and then calls through to the underlying http.ResponseWriter
+func (sw *securityHeaderWriter) Write(b []byte) (int, error) {
+	sw.apply()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush adds security headers (if they have not already been added) and then flushes the underlying
+// http.ResponseWriter, if it supports flushing.
+func (sw *securityHeaderWriter) Flush() {
+	sw.apply()
+
+	if f, found := sw.ResponseWriter.(http.Flusher); found {
+		f.Flush()
+	}
+}
+
+// Hijack calls through to the underlying http.ResponseWriter, if it supports hijacking. Security headers are not
+// added to data written to a hijacked connection.
+func (sw *securityHeaderWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+
+	if h, found := sw.ResponseWriter.(http.Hijacker); found {
+		return h.Hijack()
+	}
+
+	return nil, nil, fmt.Errorf("%T does not support hijacking", sw.ResponseWriter)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, allowing http.ResponseController to reach it.
+func (sw *securityHeaderWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+func (sw *securityHeaderWriter) apply() {
+
+	if sw.applied {
+		return
+	}
+
+	sw.applied = true
+
+	out := sw.ResponseWriter.Header()
+
+	for k, v := range sw.headers.HeadersFor(sw.handler) {
+		if _, set := out[k]; !set {
+			out[k] = append([]string(nil), v...)
+		}
+	}
+}
+
+// servingHandler records the name of the handler (if it has one) that is going to process the request.
+func (sw *securityHeaderWriter) servingHandler(p interface{}) {
+
+	if n, found := p.(ioc.ComponentNamer); found {
+		sw.handler = n.ComponentName()
+	}
+}
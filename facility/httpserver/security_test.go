@@ -0,0 +1,187 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeadersAppliedToAbnormalResponses(t *testing.T) {
+
+	s := serverWithSecurityHeaders(t)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/missing", nil)
+
+	s.handleAll(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", rec.Code)
+	}
+
+	if rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("Expected X-Frame-Options header to be set")
+	}
+
+	if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected X-Content-Type-Options header to be set")
+	}
+}
+
+func TestSecurityHeadersHandlerOverrides(t *testing.T) {
+
+	s := serverWithSecurityHeaders(t)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/framed", nil)
+
+	s.handleAll(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	if rec.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+		t.Errorf("Expected X-Frame-Options to be overridden, got %s", rec.Header().Get("X-Frame-Options"))
+	}
+
+	if _, found := rec.Header()["X-Content-Type-Options"]; found {
+		t.Errorf("Expected X-Content-Type-Options to be suppressed")
+	}
+
+	if rec.Header().Get("Content-Security-Policy") != "default-src 'self'" {
+		t.Errorf("Expected header set by handler to take precedence, got %s", rec.Header().Get("Content-Security-Policy"))
+	}
+}
+
+func TestSecurityHeadersRejectEmptyValues(t *testing.T) {
+
+	sh := new(SecurityHeaders)
+	sh.Headers = map[string]string{"X-Frame-Options": ""}
+
+	if err := sh.StartComponent(); err == nil {
+		t.Errorf("Expected an error for an empty header value")
+	}
+}
+
+func TestSecurityHeaderWriterForwardsOptionalInterfaces(t *testing.T) {
+
+	sh := new(SecurityHeaders)
+	sh.Headers = map[string]string{"X-Frame-Options": "DENY"}
+	sh.StartComponent()
+
+	rec := httptest.NewRecorder()
+	sw := sh.wrap(rec)
+
+	var w http.ResponseWriter = sw
+
+	f, found := w.(http.Flusher)
+
+	if !found {
+		t.Fatalf("Expected writer to implement http.Flusher")
+	}
+
+	f.Flush()
+
+	if !rec.Flushed || rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("Expected security headers to be added before flushing")
+	}
+
+	if _, _, err := sw.Hijack(); err == nil {
+		t.Errorf("Expected an error hijacking a writer that does not support it")
+	}
+
+	if sw.Unwrap() != rec {
+		t.Errorf("Expected Unwrap to return the underlying writer")
+	}
+}
+
+func serverWithSecurityHeaders(t *testing.T) *HTTPServer {
+
+	sh := new(SecurityHeaders)
+	sh.Headers = map[string]string{
+		"x-frame-options":         "DENY",
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'",
+	}
+	sh.HandlerOverrides = map[string]map[string]string{
+		"framedHandler": {
+			"X-Frame-Options":        "SAMEORIGIN",
+			"X-Content-Type-Options": "",
+		},
+	}
+
+	if err := sh.StartComponent(); err != nil {
+		t.Fatalf("Unexpected error starting security headers: %s", err.Error())
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(statusAsw)
+	s.SecurityHeaders = sh
+
+	p := new(namedProvider)
+	p.name = "framedHandler"
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"framedHandler": p})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatalf("Unexpected error starting server: %s", err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	return s
+}
+
+type statusAsw struct {
+}
+
+func (a *statusAsw) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
+	state.HTTPResponseWriter.WriteHeader(state.Status)
+	return nil
+}
+
+type namedProvider struct {
+	name string
+}
+
+func (np *namedProvider) SupportedHTTPMethods() []string {
+	return []string{"GET"}
+}
+
+func (np *namedProvider) RegexPattern() string {
+	return "^/framed$"
+}
+
+func (np *namedProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func (np *namedProvider) VersionAware() bool {
+	return false
+}
+
+func (np *namedProvider) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+func (np *namedProvider) AutoWireable() bool {
+	return true
+}
+
+func (np *namedProvider) ComponentName() string {
+	return np.name
+}
+
+func (np *namedProvider) SetComponentName(name string) {
+	np.name = name
+}
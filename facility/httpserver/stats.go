@@ -0,0 +1,154 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ws"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	rsCommandName = "request-stats"
+	rsSummary     = "Shows latency, status code and in-flight statistics for web service requests."
+	rsUsage       = "request-stats [events] [-reset true]"
+	rsHelp        = "With no qualifier, shows the number of requests, latency percentiles, HTTP status codes returned and in-flight requests for each handler."
+	rsHelpTwo     = "If the 'events' qualifier is supplied, latency statistics for each instrumentation event ID are shown instead."
+	rsHelpThree   = "If the '-reset true' argument is supplied, all statistics will be discarded after they are displayed."
+	rsEvents      = "events"
+	rsResetArg    = "reset"
+)
+
+type requestStatsCommand struct {
+	Recorder *instrument.LatencyRecorder
+}
+
+func (c *requestStatsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	reset := false
+
+	if v := args[rsResetArg]; v != "" {
+		var err error
+
+		if reset, err = strconv.ParseBool(v); err != nil {
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError("value of reset argument cannot be interpreted as a bool")}
+		}
+	}
+
+	events := false
+
+	if len(qualifiers) > 0 {
+
+		if qualifiers[0] != rsEvents {
+			m := fmt.Sprintf("Unknown qualifier %s. Only %s is supported.", qualifiers[0], rsEvents)
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+		}
+
+		events = true
+	}
+
+	s := c.Recorder.Snapshot()
+
+	if reset {
+		c.Recorder.Reset()
+	}
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Columns
+
+	if events {
+		co.OutputBody = eventRows(s)
+	} else {
+		co.OutputHeader = fmt.Sprintf("%d request(s) in flight", s.InFlight)
+		co.OutputBody = handlerRows(s)
+	}
+
+	return co, nil
+}
+
+func handlerRows(s *instrument.LatencySnapshot) [][]string {
+
+	names := make([]string, 0, len(s.Handlers))
+
+	for n := range s.Handlers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+
+	for _, n := range names {
+		hs := s.Handlers[n]
+
+		codes := make([]int, 0, len(hs.Statuses))
+
+		for code := range hs.Statuses {
+			codes = append(codes, code)
+		}
+
+		sort.Ints(codes)
+
+		statuses := make([]string, len(codes))
+
+		for i, code := range codes {
+			statuses[i] = fmt.Sprintf("%d:%d", code, hs.Statuses[code])
+		}
+
+		desc := fmt.Sprintf("%s in-flight=%d status=[%s]", summarise(hs.Latency), hs.InFlight, strings.Join(statuses, " "))
+
+		rows = append(rows, []string{n, desc})
+	}
+
+	return rows
+}
+
+func eventRows(s *instrument.LatencySnapshot) [][]string {
+
+	ids := make([]string, 0, len(s.Events))
+
+	for id := range s.Events {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	rows := make([][]string, 0, len(ids))
+
+	for _, id := range ids {
+		rows = append(rows, []string{id, summarise(s.Events[id])})
+	}
+
+	return rows
+}
+
+func summarise(h instrument.HistogramSnapshot) string {
+	return fmt.Sprintf("count=%d mean=%s p50=%s p90=%s p99=%s max=%s", h.Count, roundDuration(h.Mean()),
+		roundDuration(h.Percentile(50)), roundDuration(h.Percentile(90)), roundDuration(h.Percentile(99)), roundDuration(h.Max))
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
+
+func (c *requestStatsCommand) Name() string {
+	return rsCommandName
+}
+
+func (c *requestStatsCommand) Summmary() string {
+	return rsSummary
+}
+
+func (c *requestStatsCommand) Usage() string {
+	return rsUsage
+}
+
+func (c *requestStatsCommand) Help() []string {
+	return []string{rsHelp, rsHelpTwo, rsHelpThree}
+}
@@ -0,0 +1,45 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/instrument"
+	"strings"
+	"testing"
+)
+
+func TestRequestStatsCommand(t *testing.T) {
+
+	lr := instrument.NewLatencyRecorder(nil)
+
+	ctx, ri, end := lr.Begin(context.Background(), nil, nil)
+	ri.Amend(instrument.Handler, &namedProvider{name: "artistHandler"})
+	instrument.Event(ctx, "unmarshall")()
+	end()
+
+	c := new(requestStatsCommand)
+	c.Recorder = lr
+
+	out, errs := c.ExecuteCommand([]string{}, map[string]string{})
+
+	if len(errs) > 0 || len(out.OutputBody) != 1 {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	if !strings.Contains(out.OutputBody[0][1], "status=[200:1]") {
+		t.Errorf("Unexpected handler summary %s", out.OutputBody[0][1])
+	}
+
+	out, errs = c.ExecuteCommand([]string{"events"}, map[string]string{"reset": "true"})
+
+	if len(errs) > 0 || len(out.OutputBody) != 1 || out.OutputBody[0][0] != "unmarshall" {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	if len(lr.Snapshot().Events) != 0 {
+		t.Errorf("Expected statistics to be reset")
+	}
+
+	if _, errs = c.ExecuteCommand([]string{"unknown"}, map[string]string{}); len(errs) == 0 {
+		t.Errorf("Expected an error for an unknown qualifier")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/facility/httpserver"
 	"github.com/graniticio/granitic/v2/facility/logger"
+	"github.com/graniticio/granitic/v2/facility/metrics"
 	"github.com/graniticio/granitic/v2/facility/querymanager"
 	"github.com/graniticio/granitic/v2/facility/rdbms"
 	"github.com/graniticio/granitic/v2/facility/runtimectl"
@@ -135,6 +136,7 @@ func (fi *FacilitiesInitialisor) Initialise(ca *config.Accessor) error {
 	fi.addFacility(new(rdbms.FacilityBuilder))
 	fi.addFacility(new(runtimectl.FacilityBuilder))
 	fi.addFacility(new(taskscheduler.FacilityBuilder))
+	fi.addFacility(new(metrics.FacilityBuilder))
 
 	if fc["ApplicationLogging"].(bool) || fc["HTTPServer"].(bool) {
 		//Facilties are required that might need a logging.ContextFilter
@@ -0,0 +1,237 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package metrics provides the Metrics facility which exposes metrics about a Granitic application in the Prometheus text
+exposition format.
+
+This facility is described in detail at https://granitic.io/ref/metrics
+
+The Metrics facility depends on the HTTPServer facility, which must also be enabled. Enabling the Metrics facility
+creates an HTTP server, separate to the server created by the HTTPServer facility, that responds to GET requests on a
+configurable path (by default /metrics on port 9098):
+
+	{
+	  "Metrics": {
+		"Path": "/metrics",
+		"Server":{
+		  "Port": 9098,
+		  "Address": ""
+		}
+	  }
+	}
+
+Built-in metrics
+
+Depending on which other facilities are enabled, the following metrics are exposed. Each group can be disabled with
+configuration (e.g. setting Metrics.HTTP to false).
+
+	HTTP         granitic_http_requests_total, granitic_http_request_duration_seconds (labelled by handler and status)
+	             and granitic_http_requests_in_flight
+	Scheduler    granitic_task_runs_total, granitic_task_failures_total and granitic_task_duration_seconds (labelled by task)
+	Rdbms        granitic_rdbms_connections_* and granitic_rdbms_wait_* from sql.DB.Stats (labelled by database)
+	Logging      granitic_log_messages_total (labelled by level)
+	Runtime      go_* statistics about goroutines, memory and garbage collection
+
+HTTP metrics are derived from an instrument.LatencyRecorder. If HTTPServer.LatencyRecording.Enabled is false, the facility
+creates a LatencyRecorder itself. Requests are passed to the LatencyRecorder as well as to any
+instrument.RequestInstrumentationManager your application has defined.
+
+Application metrics
+
+The facility's metrics.Registry is injected into any of your components that have a field of type *metrics.Registry
+with a name listed in Metrics.InjectFieldNames (by default MetricsRegistry). Your components can then register their own
+counters, gauges and histograms in their StartComponent method.
+*/
+package metrics
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/rdbms"
+)
+
+const (
+	// RegistryComponentName is the name of the metrics.Registry component created by this facility
+	RegistryComponentName = instance.FrameworkPrefix + "MetricsRegistry"
+	// Server is the component name that will be used for the metrics server
+	Server                = instance.FrameworkPrefix + "MetricsServer"
+	endpointComponentName = instance.FrameworkPrefix + "MetricsEndpoint"
+	taskObserverName      = instance.FrameworkPrefix + "MetricsTaskObserver"
+	registryDecoratorName = instance.FrameworkPrefix + "MetricsRegistryDecorator"
+	facilityName          = "Metrics"
+)
+
+// FacilityBuilder creates and configures the Metrics facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	log := lm.CreateLogger(instance.FrameworkPrefix + "FacilityBuilder")
+
+	cfg := new(metricsConfig)
+
+	if err := ca.Populate(facilityName, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for the %s facility: %s", facilityName, err.Error())
+	}
+
+	r := metrics.NewRegistry()
+	cn.WrapAndAddProto(RegistryComponentName, r)
+
+	sv := new(httpserver.HTTPServer)
+
+	if err := ca.Populate(facilityName+".Server", sv); err != nil {
+		return fmt.Errorf("Unable to read configuration for the %s facility's server: %s", facilityName, err.Error())
+	}
+
+	sv.AbnormalStatusWriter = new(plainTextStatusWriter)
+
+	cn.WrapAndAddProto(Server, sv)
+
+	ep := new(endpoint)
+	ep.Registry = r
+	ep.Path = cfg.Path
+
+	sv.SetProvidersManually(map[string]httpendpoint.Provider{endpointComponentName: ep})
+
+	if cfg.Runtime {
+		r.AddCollector(new(metrics.RuntimeCollector))
+	}
+
+	if cfg.HTTP {
+		r.AddCollector(&httpCollector{recorder: findOrCreateRecorder(ca, cn, log)})
+	}
+
+	if cfg.Scheduler && facilityEnabled(ca, "TaskScheduler") {
+		to, err := newTaskObserver(r)
+
+		if err != nil {
+			return err
+		}
+
+		cn.WrapAndAddProto(taskObserverName, to)
+	}
+
+	if cfg.Rdbms && facilityEnabled(ca, "RdbmsAccess") {
+		r.AddCollector(newPoolCollector(findClientManagers(cn)))
+	}
+
+	if cfg.Logging {
+		mc := logging.NewMessageCounter()
+		lm.SetMessageCounter(mc)
+
+		for _, pc := range cn.ProtoComponentsByType(isLoggerManager) {
+			pc.Component.Instance.(*logging.ComponentLoggerManager).SetMessageCounter(mc)
+		}
+
+		r.AddCollector(&logCollector{counter: mc})
+	}
+
+	if len(cfg.InjectFieldNames) > 0 {
+		rd := new(registryDecorator)
+		rd.fieldNames = cfg.InjectFieldNames
+		rd.registry = r
+		rd.log = lm.CreateLogger(registryDecoratorName)
+
+		cn.WrapAndAddProto(registryDecoratorName, rd)
+	}
+
+	return nil
+}
+
+// findOrCreateRecorder returns the LatencyRecorder that the HTTP server will use for instrumentation, creating one if
+// none has been defined. Requests are passed to the created LatencyRecorder as well as to any other
+// RequestInstrumentationManager the application has defined.
+func findOrCreateRecorder(ca *config.Accessor, cn *ioc.ComponentContainer, log logging.Logger) *instrument.LatencyRecorder {
+
+	if lr := cn.ProtoComponentsByType(isLatencyRecorder); len(lr) > 0 {
+
+		if len(lr) > 1 {
+			log.LogWarnf("Multiple instrument.LatencyRecorder components found. HTTP metrics will use %s", lr[0].Component.Name)
+		}
+
+		return lr[0].Component.Instance.(*instrument.LatencyRecorder)
+	}
+
+	lr := new(instrument.LatencyRecorder)
+	ca.Populate("HTTPServer.LatencyRecording", lr)
+
+	cn.WrapAndAddProto(httpserver.LatencyRecorderComponentName, lr)
+
+	if p := cn.ProtoComponents()[httpserver.HTTPServerComponentName]; p != nil {
+
+		if s := p.Component.Instance.(*httpserver.HTTPServer); s.DisableInstrumentationAutoWire {
+			s.InstrumentationManager = instrument.CombineManagers(s.InstrumentationManager, lr)
+		}
+	}
+
+	return lr
+}
+
+func findClientManagers(cn *ioc.ComponentContainer) map[string]*rdbms.GraniticRdbmsClientManager {
+
+	cm := make(map[string]*rdbms.GraniticRdbmsClientManager)
+
+	for _, pc := range cn.ProtoComponentsByType(isClientManager) {
+		cm[pc.Component.Name] = pc.Component.Instance.(*rdbms.GraniticRdbmsClientManager)
+	}
+
+	return cm
+}
+
+func facilityEnabled(ca *config.Accessor, name string) bool {
+
+	p := "Facilities." + name
+
+	if !ca.PathExists(p) {
+		return false
+	}
+
+	b, _ := ca.BoolVal(p)
+
+	return b
+}
+
+func isLatencyRecorder(i interface{}) bool {
+	_, found := i.(*instrument.LatencyRecorder)
+	return found
+}
+
+func isClientManager(i interface{}) bool {
+	_, found := i.(*rdbms.GraniticRdbmsClientManager)
+	return found
+}
+
+func isLoggerManager(i interface{}) bool {
+	_, found := i.(*logging.ComponentLoggerManager)
+	return found
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return facilityName
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{httpserver.HTTPServerFacilityName}
+}
+
+type metricsConfig struct {
+	Path             string
+	InjectFieldNames []string
+	HTTP             bool
+	Scheduler        bool
+	Rdbms            bool
+	Logging          bool
+	Runtime          bool
+}
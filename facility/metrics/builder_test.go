@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"testing"
+)
+
+const testConfig = `{
+  "Facilities": {"HTTPServer": true, "TaskScheduler": true, "RdbmsAccess": false},
+  "Metrics": {
+    "Path": "/metrics",
+    "InjectFieldNames": ["MetricsRegistry"],
+    "HTTP": true,
+    "Scheduler": true,
+    "Rdbms": true,
+    "Logging": true,
+    "Runtime": true,
+    "Server": {"Port": 9098}
+  }
+}`
+
+func TestFacilityNaming(t *testing.T) {
+
+	fb := new(FacilityBuilder)
+
+	if fb.FacilityName() != "Metrics" {
+		t.Errorf("Unexpected facility name %s", fb.FacilityName())
+	}
+
+}
+
+func TestDependsOnHTTPServer(t *testing.T) {
+
+	deps := new(FacilityBuilder).DependsOnFacilities()
+
+	if len(deps) != 1 || deps[0] != httpserver.HTTPServerFacilityName {
+		t.Errorf("Expected facility to depend on the HTTPServer facility, got %v", deps)
+	}
+}
+
+func TestMissingServerConfiguration(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t)
+
+	delete(ca.JSONData["Metrics"].(map[string]interface{}), "Server")
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err == nil {
+		t.Errorf("Expected an error for missing server configuration")
+	}
+}
+
+func TestBuildAndRegister(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t)
+
+	hs := new(httpserver.HTTPServer)
+	hs.DisableInstrumentationAutoWire = true
+	cn.WrapAndAddProto(httpserver.HTTPServerComponentName, hs)
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	p := cn.ProtoComponents()
+
+	for _, n := range []string{RegistryComponentName, Server, taskObserverName, registryDecoratorName, httpserver.LatencyRecorderComponentName} {
+		if p[n] == nil {
+			t.Errorf("Expected component %s to be registered", n)
+		}
+	}
+
+	if _, found := hs.InstrumentationManager.(*instrument.LatencyRecorder); !found {
+		t.Errorf("Expected LatencyRecorder to be injected into HTTP server")
+	}
+
+	lm.CreateLogger("counted").LogErrorf("Counted")
+
+	r := p[RegistryComponentName].Component.Instance.(*metrics.Registry)
+
+	found := false
+
+	for _, f := range r.Gather() {
+		if f.Name == "granitic_log_messages_total" {
+			found = len(f.Samples) == 1 && f.Samples[0].Value == 1
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected log message to be counted")
+	}
+}
+
+func TestExistingInstrumentationKept(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t)
+
+	cn.WrapAndAddProto("customInstrumentation", new(customInstrumentation))
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	if cn.ProtoComponents()[httpserver.LatencyRecorderComponentName] == nil {
+		t.Errorf("LatencyRecorder should be created alongside other RequestInstrumentationManagers")
+	}
+}
+
+func TestRecorderCombinedWithExistingManager(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t)
+
+	ci := new(customInstrumentation)
+
+	hs := new(httpserver.HTTPServer)
+	hs.DisableInstrumentationAutoWire = true
+	hs.InstrumentationManager = ci
+	cn.WrapAndAddProto(httpserver.HTTPServerComponentName, hs)
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	mm, found := hs.InstrumentationManager.(*instrument.MultiRequestInstrumentationManager)
+
+	if !found || len(mm.Managers) != 2 || mm.Managers[0] != ci {
+		t.Errorf("Expected requests to be passed to both the existing manager and the LatencyRecorder")
+	}
+}
+
+func buildContainer(t *testing.T) (*logging.ComponentLoggerManager, *config.Accessor, *ioc.ComponentContainer) {
+
+	var jd map[string]interface{}
+
+	if err := json.Unmarshal([]byte(testConfig), &jd); err != nil {
+		t.Fatal(err)
+	}
+
+	lm := logging.CreateComponentLoggerManager(logging.Error, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+	ca := &config.Accessor{JSONData: jd, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+
+	return lm, ca, ioc.NewComponentContainer(lm, ca, new(instance.System))
+}
+
+type customInstrumentation struct {
+	instrument.RequestInstrumentationManager
+}
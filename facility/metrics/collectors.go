@@ -0,0 +1,223 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"database/sql"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/schedule"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// httpCollector exposes the statistics held by an instrument.LatencyRecorder
+type httpCollector struct {
+	recorder *instrument.LatencyRecorder
+}
+
+func (hc *httpCollector) Collect() []*metrics.Family {
+
+	s := hc.recorder.Snapshot()
+
+	requests := &metrics.Family{Name: "granitic_http_requests_total", Help: "HTTP requests processed, by handler and status code.", Type: metrics.CounterType}
+	durations := &metrics.Family{Name: "granitic_http_request_duration_seconds", Help: "Time taken to process HTTP requests, by handler and status code.", Type: metrics.HistogramType}
+
+	names := make([]string, 0, len(s.Handlers))
+
+	for n := range s.Handlers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		hs := s.Handlers[n]
+
+		codes := make([]int, 0, len(hs.Statuses))
+
+		for code := range hs.Statuses {
+			codes = append(codes, code)
+		}
+
+		sort.Ints(codes)
+
+		for _, code := range codes {
+			labels := []metrics.Label{{Name: "handler", Value: n}, {Name: "status", Value: strconv.Itoa(code)}}
+
+			requests.Samples = append(requests.Samples, metrics.Sample{Name: requests.Name, Labels: labels, Value: float64(hs.Statuses[code])})
+
+			if h, found := hs.StatusLatency[code]; found {
+				durations.Samples = append(durations.Samples, histogramSamples(durations.Name, labels, h)...)
+			}
+		}
+	}
+
+	inFlight := &metrics.Family{Name: "granitic_http_requests_in_flight", Help: "HTTP requests currently being processed.", Type: metrics.GaugeType,
+		Samples: []metrics.Sample{{Name: "granitic_http_requests_in_flight", Value: float64(s.InFlight)}}}
+
+	return []*metrics.Family{requests, durations, inFlight}
+}
+
+func histogramSamples(name string, labels []metrics.Label, h instrument.HistogramSnapshot) []metrics.Sample {
+
+	bounds := make([]float64, len(h.Bounds))
+
+	for i, b := range h.Bounds {
+		bounds[i] = b.Seconds()
+	}
+
+	return metrics.HistogramSamples(name, labels, bounds, h.Counts, h.Sum.Seconds())
+}
+
+func newTaskObserver(r *metrics.Registry) (*taskObserver, error) {
+
+	var err error
+
+	to := new(taskObserver)
+
+	if to.runs, err = r.Counter("granitic_task_runs_total", "Scheduled task invocations completed.", "task"); err != nil {
+		return nil, err
+	}
+
+	if to.failures, err = r.Counter("granitic_task_failures_total", "Scheduled task invocations that returned an error or panicked.", "task"); err != nil {
+		return nil, err
+	}
+
+	if to.duration, err = r.Histogram("granitic_task_duration_seconds", "Time taken by scheduled task invocations.", nil, "task"); err != nil {
+		return nil, err
+	}
+
+	return to, nil
+}
+
+// taskObserver records metrics about each task invocation. It is automatically found by the TaskScheduler
+type taskObserver struct {
+	runs     *metrics.Counter
+	failures *metrics.Counter
+	duration *metrics.Histogram
+}
+
+// TaskCompleted implements schedule.TaskObserver.TaskCompleted
+func (to *taskObserver) TaskCompleted(summary schedule.TaskInvocationSummary, elapsed time.Duration, err error) {
+
+	name := summary.TaskName
+
+	if name == "" {
+		name = summary.TaskID
+	}
+
+	to.runs.Inc(name)
+	to.duration.Observe(elapsed.Seconds(), name)
+
+	if err != nil {
+		to.failures.Inc(name)
+	}
+}
+
+func newPoolCollector(managers map[string]*rdbms.GraniticRdbmsClientManager) *poolCollector {
+
+	pc := new(poolCollector)
+	pc.managers = managers
+
+	for n := range managers {
+		pc.names = append(pc.names, n)
+	}
+
+	sort.Strings(pc.names)
+
+	return pc
+}
+
+// poolCollector exposes the connection pool statistics of each RDBMS ClientManager
+type poolCollector struct {
+	managers map[string]*rdbms.GraniticRdbmsClientManager
+	names    []string
+}
+
+func (pc *poolCollector) Collect() []*metrics.Family {
+
+	type poolMetric struct {
+		name  string
+		help  string
+		t     metrics.Type
+		value func(s *sql.DBStats) float64
+	}
+
+	pm := []poolMetric{
+		{"granitic_rdbms_connections_open", "Established connections, both in use and idle.", metrics.GaugeType, func(s *sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"granitic_rdbms_connections_in_use", "Connections currently in use.", metrics.GaugeType, func(s *sql.DBStats) float64 { return float64(s.InUse) }},
+		{"granitic_rdbms_connections_idle", "Idle connections.", metrics.GaugeType, func(s *sql.DBStats) float64 { return float64(s.Idle) }},
+		{"granitic_rdbms_connections_max_open", "Maximum number of open connections (zero means unlimited).", metrics.GaugeType, func(s *sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"granitic_rdbms_wait_count_total", "Number of times a caller waited for a connection.", metrics.CounterType, func(s *sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"granitic_rdbms_wait_duration_seconds_total", "Total time spent waiting for a connection.", metrics.CounterType, func(s *sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"granitic_rdbms_max_idle_closed_total", "Connections closed due to the maximum number of idle connections.", metrics.CounterType, func(s *sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"granitic_rdbms_max_lifetime_closed_total", "Connections closed due to the maximum connection lifetime.", metrics.CounterType, func(s *sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+
+	families := make([]*metrics.Family, len(pm))
+
+	for i, m := range pm {
+		families[i] = &metrics.Family{Name: m.name, Help: m.help, Type: m.t}
+	}
+
+	for _, n := range pc.names {
+		cm := pc.managers[n]
+
+		stats, err := cm.PoolStats()
+
+		if err != nil {
+			// Database is not currently available - omit its statistics
+			continue
+		}
+
+		labels := []metrics.Label{{Name: "database", Value: databaseLabel(n, cm)}}
+
+		for i, m := range pm {
+			families[i].Samples = append(families[i].Samples, metrics.Sample{Name: m.name, Labels: labels, Value: m.value(&stats)})
+		}
+	}
+
+	return families
+}
+
+func databaseLabel(componentName string, cm *rdbms.GraniticRdbmsClientManager) string {
+
+	if cm.Configuration != nil && cm.Configuration.ClientName != "" {
+		return cm.Configuration.ClientName
+	}
+
+	return componentName
+}
+
+// logCollector exposes the number of messages logged at each level
+type logCollector struct {
+	counter *logging.MessageCounter
+}
+
+func (lc *logCollector) Collect() []*metrics.Family {
+
+	name := "granitic_log_messages_total"
+
+	f := &metrics.Family{Name: name, Help: "Log messages written, by level.", Type: metrics.CounterType}
+
+	counts := lc.counter.Counts()
+
+	levels := make([]string, 0, len(counts))
+
+	for l := range counts {
+		levels = append(levels, l)
+	}
+
+	sort.Strings(levels)
+
+	for _, l := range levels {
+		f.Samples = append(f.Samples, metrics.Sample{Name: name, Labels: []metrics.Label{{Name: "level", Value: l}}, Value: float64(counts[l])})
+	}
+
+	return []*metrics.Family{f}
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/schedule"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPCollector(t *testing.T) {
+
+	lr := instrument.NewLatencyRecorder([]time.Duration{time.Second})
+
+	_, ri, end := lr.Begin(context.Background(), nil, nil)
+	ri.Amend(instrument.Handler, namedHandler("artistHandler"))
+	ri.Amend(instrument.ResponseStatus, http.StatusNotFound)
+	end()
+
+	f := (&httpCollector{recorder: lr}).Collect()
+
+	test.ExpectInt(t, len(f), 3)
+
+	requests := f[0].Samples
+	test.ExpectInt(t, len(requests), 1)
+	test.ExpectString(t, requests[0].Labels[0].Value, "artistHandler")
+	test.ExpectString(t, requests[0].Labels[1].Value, "404")
+	test.ExpectFloat(t, requests[0].Value, 1)
+
+	durations := f[1].Samples
+	test.ExpectInt(t, len(durations), 4)
+	test.ExpectString(t, durations[0].Name, "granitic_http_request_duration_seconds_bucket")
+	test.ExpectString(t, durations[0].Labels[2].Value, "1")
+
+	test.ExpectFloat(t, f[2].Samples[0].Value, 0)
+}
+
+func TestTaskObserver(t *testing.T) {
+
+	r := metrics.NewRegistry()
+
+	to, err := newTaskObserver(r)
+	test.ExpectNil(t, err)
+
+	var _ schedule.TaskObserver = to
+
+	to.TaskCompleted(schedule.TaskInvocationSummary{TaskName: "cleanup"}, time.Second, nil)
+	to.TaskCompleted(schedule.TaskInvocationSummary{TaskName: "cleanup"}, time.Second, errors.New("failed"))
+
+	values := make(map[string]float64)
+
+	for _, f := range r.Gather() {
+		for _, s := range f.Samples {
+			if s.Name != "granitic_task_duration_seconds_bucket" {
+				values[s.Name] = s.Value
+			}
+		}
+	}
+
+	test.ExpectFloat(t, values["granitic_task_runs_total"], 2)
+	test.ExpectFloat(t, values["granitic_task_failures_total"], 1)
+	test.ExpectFloat(t, values["granitic_task_duration_seconds_sum"], 2)
+}
+
+func TestLogCollector(t *testing.T) {
+
+	mc := logging.NewMessageCounter()
+	mc.Increment(logging.WarnLabel)
+	mc.Increment(logging.ErrorLabel)
+	mc.Increment(logging.ErrorLabel)
+
+	s := (&logCollector{counter: mc}).Collect()[0].Samples
+
+	test.ExpectInt(t, len(s), 2)
+	test.ExpectString(t, s[0].Labels[0].Value, logging.ErrorLabel)
+	test.ExpectFloat(t, s[0].Value, 2)
+}
+
+type namedHandler string
+
+func (nh namedHandler) ComponentName() string {
+	return string(nh)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/reflecttools"
+	"reflect"
+)
+
+// registryDecorator injects the metrics.Registry into any nil field of type *metrics.Registry whose name is in fieldNames
+type registryDecorator struct {
+	fieldNames []string
+	registry   *metrics.Registry
+	log        logging.Logger
+}
+
+func (rd *registryDecorator) OfInterest(component *ioc.Component) bool {
+
+	for _, field := range rd.fieldNames {
+
+		if rd.needsRegistry(component.Instance, field) {
+			rd.log.LogTracef("%s.%s needs a metrics registry", component.Name, field)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rd *registryDecorator) DecorateComponent(component *ioc.Component, container *ioc.ComponentContainer) {
+
+	i := component.Instance
+
+	for _, field := range rd.fieldNames {
+
+		if rd.needsRegistry(i, field) {
+			reflect.ValueOf(i).Elem().FieldByName(field).Set(reflect.ValueOf(rd.registry))
+		}
+	}
+}
+
+func (rd *registryDecorator) needsRegistry(i interface{}, field string) bool {
+
+	if !reflecttools.HasFieldOfName(i, field) {
+		return false
+	}
+
+	if reflecttools.TypeOfField(i, field) != reflect.TypeOf(rd.registry) {
+		return false
+	}
+
+	return reflect.ValueOf(i).Elem().FieldByName(field).IsNil()
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"testing"
+)
+
+func TestRegistryDecorator(t *testing.T) {
+
+	rd := new(registryDecorator)
+	rd.fieldNames = []string{"MetricsRegistry"}
+	rd.registry = metrics.NewRegistry()
+	rd.log = new(logging.ConsoleErrorLogger)
+
+	tar := new(registryTarget)
+	c := ioc.NewComponent("target", tar)
+
+	if !rd.OfInterest(c) {
+		t.FailNow()
+	}
+
+	rd.DecorateComponent(c, nil)
+
+	if tar.MetricsRegistry != rd.registry {
+		t.Errorf("Registry not injected")
+	}
+
+	if rd.OfInterest(c) || rd.OfInterest(ioc.NewComponent("other", new(wrongTypeTarget))) {
+		t.Errorf("Components with populated or incompatible fields should not be of interest")
+	}
+}
+
+type registryTarget struct {
+	MetricsRegistry *metrics.Registry
+}
+
+type wrongTypeTarget struct {
+	MetricsRegistry string
+}
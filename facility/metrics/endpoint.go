@@ -0,0 +1,61 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"regexp"
+)
+
+// endpoint responds to GET requests with the contents of the Registry in Prometheus text format
+type endpoint struct {
+	Registry *metrics.Registry
+	Path     string
+}
+
+func (e *endpoint) SupportedHTTPMethods() []string {
+	return []string{"GET"}
+}
+
+func (e *endpoint) RegexPattern() string {
+	return "^" + regexp.QuoteMeta(e.Path) + "$"
+}
+
+func (e *endpoint) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	e.Registry.ServeHTTP(w, req)
+
+	return ctx
+}
+
+func (e *endpoint) VersionAware() bool {
+	return false
+}
+
+func (e *endpoint) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+func (e *endpoint) AutoWireable() bool {
+	return false
+}
+
+// plainTextStatusWriter responds to requests for anything other than the metrics path with a plain text description of the status
+type plainTextStatusWriter struct {
+}
+
+func (pw *plainTextStatusWriter) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
+
+	w := state.HTTPResponseWriter
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(state.Status)
+
+	_, err := w.Write([]byte(http.StatusText(state.Status) + "\n"))
+
+	return err
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+
+	r := metrics.NewRegistry()
+	c, _ := r.Counter("orders_total", "Orders")
+	c.Inc()
+
+	ep := &endpoint{Registry: r, Path: "/metrics"}
+
+	re := regexp.MustCompile(ep.RegexPattern())
+
+	test.ExpectBool(t, re.MatchString("/metrics"), true)
+	test.ExpectBool(t, re.MatchString("/metricsx"), false)
+
+	rec := httptest.NewRecorder()
+	ep.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), httptest.NewRequest("GET", "/metrics", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), metrics.TextContentType)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "orders_total 1"), true)
+}
+
+func TestPlainTextStatusWriter(t *testing.T) {
+
+	rec := httptest.NewRecorder()
+
+	state := ws.NewAbnormalState(http.StatusNotFound, httpendpoint.NewHTTPResponseWriter(rec))
+
+	test.ExpectNil(t, new(plainTextStatusWriter).WriteAbnormalStatus(context.Background(), state))
+
+	test.ExpectInt(t, rec.Code, http.StatusNotFound)
+	test.ExpectString(t, rec.Body.String(), "Not Found\n")
+}
@@ -71,6 +71,46 @@ Required parameters
 If you put a ! character before a parameter name in your template (e.g. ${!artistID}), an error will be returned if that parameter is
 not available when a query is built.
 
+Conditional and repeating sections
+
+Lines in a template starting with --# (the prefix can be changed with QueryManager.DirectivePrefix) start or end a section
+of a query. Sections may be nested and are ended with --#end
+
+	ID:ARTIST_SEARCH
+
+	SELECT id FROM artist
+	--#where
+		--#if name
+		AND name = ${name}
+		--#end
+		--#if genres
+		AND genre IN (${genres})
+		--#end
+	--#end
+
+	ID:ARTIST_INSERT
+
+	INSERT INTO artist (name, genre) VALUES
+	--#range artists ,
+		(${name}, ${genre})
+	--#end
+
+An if section is only included if the named parameter is set (not nil, not an unset nilable type and not an empty slice).
+A where section is prefixed with WHERE if it is not empty once its nested sections have been processed, with any leading
+AND or OR removed. A range section is repeated for each element of the named parameter, which must be a slice of
+map[string]interface{} (rdbms.ManagedClient converts slices of structs automatically). Variables inside a range are
+found in the current element first. The optional second argument to range is the separator placed between repetitions
+(a comma if not specified).
+
+Any parameter whose value is a slice (other than a []byte) is expanded into a comma separated list of its elements,
+which allows a slice to be used in an IN (...) clause.
+
+The upsert directive does not start a section. It is replaced with the clause that makes an INSERT update rows that
+conflict with a unique key, using the syntax of QueryManager.Dialect (mysql, postgresql or sqlite). Its first argument
+lists the key columns and its optional second argument lists the columns to update:
+
+	--#upsert id name,genre
+
 Parameter Values
 
 Parameter values are injected into the query using a component called a ParamValueProcessor. Granitic includes two
@@ -80,6 +120,60 @@ values and b) perform any escaping/substitution/conversion of values before they
 To enable one of the default processors, set QueryManager.ProcessorName to Configurable or SQL (the default is Configurable). If
 you want to implement your own processor, set QueryManager.CreateDefaultValueProcessor to false and define a component that
 implements ParamValueProcessor
+
+If QueryManager.Dialect is set to mysql, postgresql or sqlite, the processor for that dialect (MySQLProcessor,
+PostgreSQLProcessor or SQLiteProcessor) is used instead of the processor named in ProcessorName. These processors follow
+the database's rules for escaping strings, use its literals for bools and byte slices and quote dsquery.Identifier values
+as identifiers. They are configured under QueryManager.ValueProcessors.MySQL, PostgreSQL or SQLite.
+
+Parameters may be strings, bools, any int, uint or float type, []byte, time.Time or any of Granitic's nilable types. Times
+are converted to strings by the ParamValueProcessor using its TimeFormat setting (DefaultTimeFormat if not set).
+SQLProcessor also replaces bools with its BoolTrue and BoolFalse values and converts []byte values to hexadecimal
+literals (X'0AFF').
+
+Bind parameters
+
+As an alternative to escaping values and inserting them into the text of the query, the QueryManager can replace each
+variable with a placeholder and return the values separately, so that they are passed to the database driver as
+arguments. This removes any reliance on escaping to prevent SQL injection and allows the database to cache statements.
+Enable this mode with:
+
+	{
+	  "QueryManager":{
+		"BindParameters": true,
+		"Dialect": "postgresql"
+	  }
+	}
+
+The Dialect setting determines the style of placeholder used: ? for mysql and sqlite (the default), $1, $2 for
+postgresql and @p1, @p2 for sqlserver. When writing templates for this mode, variables must not be wrapped in quotes
+(e.g. use name = ${artistName} rather than name = '${artistName}'). Unset parameters that are not marked as required
+are passed to the ParamValueProcessor's SubstituteUnset method, as they are when parameters are not bound. A substituted
+null is bound as NULL, a substituted value the processor has marked as escaped is written into the query and any other
+substituted value is bound. ParamValueProcessors are not used to escape values in this mode.
+
+rdbms.ManagedClient automatically detects this mode and prepares a statement for each query, which is reused by all
+ManagedClients created by the same ClientManager.
+
+Validating and reloading templates
+
+By default, problems found when templates are loaded (files that cannot be read, templates with the same ID, invalid
+directives, malformed variables such as ${} or ${name and content before the first query ID) are logged. Setting
+QueryManager.StrictStartup to true causes the application to fail to start instead.
+
+During development, setting QueryManager.WatchTemplates to true causes TemplateLocation to be checked for changes every
+QueryManager.WatchIntervalMS milliseconds (1000 by default). All templates are reloaded when a file is added, changed
+or removed, so queries can be edited without restarting the application. If StrictStartup is also true, templates with
+problems are not loaded and the previous templates remain in use.
+
+If the RuntimeCtl facility is enabled, the queries command lists the ID of each template, the parameters it uses and
+the file it was loaded from.
+
+Embedded templates
+
+If your application was started with resources embedded in its executable (see ioc.ProtoComponents.WithResources and
+the -e argument of grnc-bind), templates are loaded from TemplateLocation within those resources. Set
+QueryManager.EmbeddedTemplates to false to load templates from the working directory instead.
 */
 package querymanager
 
@@ -88,9 +182,11 @@ import (
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/facility/runtimectl"
 	"github.com/graniticio/granitic/v2/instance"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
+	"strings"
 )
 
 // QueryManagerComponentName is the name of the query manager in the IoC container.
@@ -101,9 +197,18 @@ const QueryManagerFacilityName = "QueryManager"
 
 const processorDecorator = instance.FrameworkPrefix + "ParamValueProcessorDecorator"
 
+const queriesCommandName = instance.FrameworkPrefix + "CommandQueries"
+
 const confValueProcess = "Configurable"
 const sqlValueProcess = "SQL"
 
+// Configuration paths (under QueryManager.ValueProcessors) for the dialect-specific processors
+var dialectProcessorConfig = map[string]string{
+	dsquery.MySQLDialect:      "MySQL",
+	dsquery.PostgreSQLDialect: "PostgreSQL",
+	dsquery.SQLiteDialect:     "SQLite",
+}
+
 // FacilityBuilder creates an instance of dsquery.QueryManager and stores it in the IoC container.
 type FacilityBuilder struct {
 }
@@ -114,8 +219,19 @@ func (qmfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 	queryManager := new(dsquery.TemplatedQueryManager)
 	ca.Populate("QueryManager", queryManager)
 
+	if embedded, _ := ca.BoolVal("QueryManager.EmbeddedTemplates"); embedded {
+		queryManager.TemplateFS = cn.Resources()
+	}
+
 	cn.WrapAndAddProto(QueryManagerComponentName, queryManager)
 
+	if runtimectl.Enabled(ca) {
+		qc := new(queriesCommand)
+		qc.queryManager = queryManager
+
+		cn.WrapAndAddProto(queriesCommandName, qc)
+	}
+
 	if build, _ := ca.BoolVal("QueryManager.CreateDefaultValueProcessor"); build == false {
 		//Construction of stock value processor has been disabled
 
@@ -126,6 +242,20 @@ func (qmfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 		return nil
 	}
 
+	if vp := dsquery.ProcessorForDialect(queryManager.Dialect); vp != nil {
+		//Use the stock processor for the configured SQL dialect
+
+		vpConfig := "QueryManager.ValueProcessors." + dialectProcessorConfig[strings.ToLower(queryManager.Dialect)]
+
+		if ca.PathExists(vpConfig) {
+			ca.Populate(vpConfig, vp)
+		}
+
+		queryManager.ValueProcessor = vp
+
+		return nil
+	}
+
 	vpName, err := ca.StringVal("QueryManager.ProcessorName")
 
 	if err != nil || (vpName != confValueProcess && vpName != sqlValueProcess) {
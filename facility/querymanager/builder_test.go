@@ -1,6 +1,16 @@
 package querymanager
 
-import "testing"
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
 
 func TestFacilityNaming(t *testing.T) {
 
@@ -11,3 +21,125 @@ func TestFacilityNaming(t *testing.T) {
 	}
 
 }
+
+func TestQueriesCommand(t *testing.T) {
+
+	dir := t.TempDir()
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "q"), []byte("ID:ONE\nSELECT ${a}\nID:TWO\nSELECT ${!b}, ${c}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	qm := new(dsquery.TemplatedQueryManager)
+	qm.QueryIDPrefix = "ID:"
+	qm.VarMatchRegEx = "\\$\\{([^\\}]*)\\}"
+	qm.TemplateLocation = dir
+	qm.ValueProcessor = new(dsquery.SQLProcessor)
+	qm.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	if err := qm.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &queriesCommand{queryManager: qm}
+
+	out, errs := c.ExecuteCommand([]string{}, map[string]string{})
+
+	if len(errs) > 0 || len(out.OutputBody) != 2 {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	out, errs = c.ExecuteCommand([]string{"TWO"}, map[string]string{})
+
+	if len(errs) > 0 || len(out.OutputBody) != 1 {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	if row := out.OutputBody[0]; row[0] != "TWO" || row[1] != "!b,c" || row[2] != filepath.Join(dir, "q") {
+		t.Errorf("Unexpected output %v", row)
+	}
+
+	if _, errs = c.ExecuteCommand([]string{"UNKNOWN"}, map[string]string{}); len(errs) == 0 {
+		t.Errorf("Expected an error for an unknown query")
+	}
+}
+
+func TestEmbeddedTemplates(t *testing.T) {
+
+	resources := fstest.MapFS{"resource/queries/q": {Data: []byte("ID:ONE\nSELECT 1")}}
+
+	for _, embedded := range []bool{true, false} {
+
+		jd := map[string]interface{}{
+			"QueryManager": map[string]interface{}{
+				"TemplateLocation":            "resource/queries",
+				"EmbeddedTemplates":           embedded,
+				"CreateDefaultValueProcessor": false,
+			},
+		}
+
+		lm := logging.CreateComponentLoggerManager(logging.Error, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+		ca := &config.Accessor{JSONData: jd, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+		cn := ioc.NewComponentContainer(lm, ca, new(instance.System))
+		cn.AddResources(resources)
+
+		if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+			t.Fatal(err)
+		}
+
+		qm := cn.ProtoComponents()[QueryManagerComponentName].Component.Instance.(*dsquery.TemplatedQueryManager)
+
+		if (qm.TemplateFS != nil) != embedded {
+			t.Errorf("Expected embedded templates to be used: %v", embedded)
+		}
+	}
+}
+
+func TestDialectValueProcessor(t *testing.T) {
+
+	build := func(dialect string) dsquery.ParamValueProcessor {
+
+		jd := map[string]interface{}{
+			"QueryManager": map[string]interface{}{
+				"Dialect":                     dialect,
+				"CreateDefaultValueProcessor": true,
+				"ProcessorName":               "SQL",
+				"ValueProcessors": map[string]interface{}{
+					"SQL":   map[string]interface{}{},
+					"MySQL": map[string]interface{}{"NoBackslashEscapes": true},
+				},
+			},
+		}
+
+		lm := logging.CreateComponentLoggerManager(logging.Error, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+		ca := &config.Accessor{JSONData: jd, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+		cn := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+		if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+			t.Fatal(err)
+		}
+
+		return cn.ProtoComponents()[QueryManagerComponentName].Component.Instance.(*dsquery.TemplatedQueryManager).ValueProcessor
+	}
+
+	if mp, found := build("MySQL").(*dsquery.MySQLProcessor); !found || !mp.NoBackslashEscapes {
+		t.Errorf("Expected a configured MySQLProcessor")
+	}
+
+	if _, found := build("postgresql").(*dsquery.PostgreSQLProcessor); !found {
+		t.Errorf("Expected a PostgreSQLProcessor")
+	}
+
+	if _, found := build("sqlite").(*dsquery.SQLiteProcessor); !found {
+		t.Errorf("Expected a SQLiteProcessor")
+	}
+
+	// Dialects without a specific processor use ProcessorName
+	if _, found := build("sqlserver").(*dsquery.SQLProcessor); !found {
+		t.Errorf("Expected a SQLProcessor")
+	}
+
+	if _, found := build("").(*dsquery.SQLProcessor); !found {
+		t.Errorf("Expected a SQLProcessor")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package querymanager
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/ws"
+	"strings"
+)
+
+const (
+	qCommandName = "queries"
+	qSummary     = "Lists the query templates loaded by the QueryManager."
+	qUsage       = "queries [query-id]"
+	qHelp        = "With no qualifier, lists the ID of each query template together with the parameters it uses and the file it was loaded from."
+	qHelpTwo     = "If a query ID is supplied as a qualifier, only that query is shown. Required parameters are prefixed with !"
+)
+
+type queriesCommand struct {
+	queryManager *dsquery.TemplatedQueryManager
+}
+
+func (c *queriesCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Columns
+
+	for _, qd := range c.queryManager.Queries() {
+
+		if len(qualifiers) > 0 && qd.ID != qualifiers[0] {
+			continue
+		}
+
+		co.OutputBody = append(co.OutputBody, []string{qd.ID, strings.Join(qd.Parameters, ","), qd.Source})
+	}
+
+	if len(qualifiers) > 0 && len(co.OutputBody) == 0 {
+		m := fmt.Sprintf("Unknown query %s", qualifiers[0])
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+	}
+
+	return co, nil
+}
+
+func (c *queriesCommand) Name() string {
+	return qCommandName
+}
+
+func (c *queriesCommand) Summmary() string {
+	return qSummary
+}
+
+func (c *queriesCommand) Usage() string {
+	return qUsage
+}
+
+func (c *queriesCommand) Help() []string {
+	return []string{qHelp, qHelpTwo}
+}
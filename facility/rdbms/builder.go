@@ -10,6 +10,107 @@ interface that applications will use for executing SQL is described in the rdbms
 The purpose of this facility is to create an rdbms.ClientManager that will be injected into your application
 components. In turn, the rdbms.ClientManager will be used by your application to create instances of rdbms.RDBMSClient
 which provide the interface for executing SQL queries and managing transactions.
+
+Multiple databases
+
+If your application needs to access more than one database, each database can be declared in configuration under
+RdbmsAccess.Databases:
+
+	{
+	  "RdbmsAccess":{
+	    "Databases": {
+	      "orders": {
+	        "ProviderName": "ordersDBProvider",
+	        "InjectFieldNames": ["OrdersClientManager"]
+	      },
+	      "catalogue": {
+	        "ProviderName": "catalogueDBProvider",
+	        "QueryManagerName": "catalogueQueryManager",
+	        "InjectFieldNames": ["CatalogueClientManager"]
+	      }
+	    }
+	  }
+	}
+
+Each entry supports the same settings as RdbmsAccess.Default (see rdbms.ClientManagerConfig). Any setting that is not
+specified for a database, other than the names of components and fields, is taken from RdbmsAccess.Default. Each entry
+results in a separate rdbms.ClientManager being injected into any component with a field named in InjectFieldNames.
+ProviderName is the name of the component implementing rdbms.DatabaseProvider for that database and is required.
+QueryManagerName is the name of a component implementing dsquery.QueryManager, allowing each database to have its own
+query templates. If it is not set, the QueryManager created by the QueryManager facility is used.
+
+If any databases are declared (or your component definition files contain rdbms.ClientManagerConfig components), no
+default ClientManager is created. Include DbClientManager in the InjectFieldNames of one of your databases if your
+existing code expects it.
+
+Read replicas
+
+Setting ReplicaProviderNames to the names of one or more DatabaseProvider components causes queries executed with the
+SelectXXX methods of rdbms.ManagedClient to be sent to a read replica, unless a transaction is open. Inserts, updates,
+deletes, transactions and the Exec, Query and QueryRow pass-through methods always use the primary database.
+
+Replicas are used in turn. The health of each replica is checked by pinging it every ReplicaCheckIntervalMS
+milliseconds (10000 by default). A replica that fails a check is not used until it passes a later check. If no
+replica is healthy, queries are sent to the primary database.
+
+Connection pools and health checks
+
+The MaxOpenConnections, MaxIdleConnections, ConnMaxLifetimeMS and ConnMaxIdleTimeMS settings are applied to the
+sql.DB returned by each DatabaseProvider (including read replicas).
+
+If HealthCheckIntervalMS is set (health checks are off by default), the database is pinged every HealthCheckIntervalMS
+milliseconds. An error is logged when the database becomes unreachable and again when it recovers. If
+SuspendHTTPServerWhenUnavailable is true and the HTTPServer facility is enabled, the HTTP server is suspended (new
+requests receive a 'too busy' response) while any such database is unreachable. A server that was already suspended is
+not resumed when the database recovers.
+
+If the RuntimeCtl facility is enabled, the pool-stats command shows the connection pool statistics, the result of the
+most recent health check and the number of healthy read replicas for each database.
+
+Query statistics and slow queries
+
+The number of executions, number of errors and latency of each query ID are recorded unless DisableQueryStats is
+true. If SlowQueryThresholdMS is set, queries taking at least that many milliseconds are logged at WARN. Parameters
+are included in the message if LogSlowQueryParameters is true, with the values of any parameters named in
+RedactParameters hidden:
+
+	{
+	  "RdbmsAccess":{
+	    "Default": {
+	      "SlowQueryThresholdMS": 500,
+	      "LogSlowQueryParameters": true,
+	      "RedactParameters": ["password", "email"]
+	    }
+	  }
+	}
+
+If the RuntimeCtl facility is enabled, the query-stats command shows the statistics for each database ('query-stats -reset
+true' discards them after they are shown).
+
+Schema migrations
+
+Setting RdbmsAccess.Migrations.Enabled to true creates an rdbms.Migrator that applies the versioned SQL files in
+RdbmsAccess.Migrations.Directory to a database:
+
+	{
+	  "RdbmsAccess":{
+	    "Migrations": {
+	      "Enabled": true,
+	      "Directory": "resource/migrations"
+	    }
+	  }
+	}
+
+If more than one database is configured, RdbmsAccess.Migrations.Database must be set to the ClientName of the database to
+migrate. Unless RunAtStartup is set to false, pending migrations are applied before the application becomes accessible
+(so the HTTP server does not accept requests until migrations are complete) and a failed migration prevents the
+application from starting. If DryRun is true, pending migrations are logged rather than applied.
+
+Only one instance of an application applies migrations at a time; other instances wait up to
+RdbmsAccess.Migrations.LockWaitMS for the instance applying migrations to finish. The Dialect used to record applied
+migrations defaults to QueryManager.Dialect.
+
+If the RuntimeCtl facility is enabled, the migrate command shows ('migrate status') or applies ('migrate up') migrations.
 */
 package rdbms
 
@@ -17,6 +118,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
 	"github.com/graniticio/granitic/v2/facility/querymanager"
 	"github.com/graniticio/granitic/v2/instance"
 	"github.com/graniticio/granitic/v2/ioc"
@@ -29,6 +131,20 @@ const rdbmsClientManagerConfigName = instance.FrameworkPrefix + "ClientManagerCo
 
 const managerDecorator = instance.FrameworkPrefix + "DbClientManagerDecorator"
 
+const databasesConfigPath = "RdbmsAccess.Databases"
+
+const poolStatsCommandName = instance.FrameworkPrefix + "CommandPoolStats"
+
+const queryStatsCommandName = instance.FrameworkPrefix + "CommandQueryStats"
+
+const serverSuspenderName = instance.FrameworkPrefix + "RdbmsServerSuspender"
+
+const migrationsConfigPath = "RdbmsAccess.Migrations"
+
+const migratorName = instance.FrameworkPrefix + "Migrator"
+
+const migrateCommandName = instance.FrameworkPrefix + "CommandMigrate"
+
 // FacilityBuilder creates an instance of rdbms.RDBMSClientManager that can be injected into your application components.
 type FacilityBuilder struct {
 	Log logging.Logger
@@ -54,31 +170,44 @@ func (rafb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 	//See if client manager configs have been explicitly defined
 	rafb.findConfigurations(cn, managerConfigs)
 
+	//Add any databases declared in configuration
+	if err := rafb.configuredDatabases(ca, cn, managerConfigs); err != nil {
+		return err
+	}
+
 	if len(managerConfigs) == 0 {
 
 		log.LogTracef("Provider found but no explicit rdbms.ClientManagerConfig components. Creating default configuration")
 
-		//We have a provider
-		providerName := pn[0]
-
 		// Create config for a default ClientManager
 		mc := new(rdbms.ClientManagerConfig)
 		ca.Populate("RdbmsAccess.Default", mc)
 
+		if mc.ProviderName == "" {
+			//Use the provider we found
+			mc.ProviderName = pn[0]
+		}
+
 		proto := ioc.CreateProtoComponent(mc, rdbmsClientManagerConfigName)
 
-		proto.AddDependency("Provider", providerName)
+		proto.AddDependency("Provider", mc.ProviderName)
 		cn.AddProto(proto)
 
 		managerConfigs[rdbmsClientManagerConfigName] = mc
 
 	}
 
-	return rafb.createManagers(cn, managerConfigs, lm)
+	for _, mc := range managerConfigs {
+		if err := rafb.findReplicas(cn, mc); err != nil {
+			return err
+		}
+	}
+
+	return rafb.createManagers(ca, cn, managerConfigs, lm)
 
 }
 
-func (rafb *FacilityBuilder) createManagers(cn *ioc.ComponentContainer, conf map[string]*rdbms.ClientManagerConfig, lm *logging.ComponentLoggerManager) error {
+func (rafb *FacilityBuilder) createManagers(ca *config.Accessor, cn *ioc.ComponentContainer, conf map[string]*rdbms.ClientManagerConfig, lm *logging.ComponentLoggerManager) error {
 
 	mn := types.NewEmptyUnorderedStringSet()
 
@@ -94,6 +223,10 @@ func (rafb *FacilityBuilder) createManagers(cn *ioc.ComponentContainer, conf map
 	}
 
 	fieldsToManager := make(map[string]rdbms.ClientManager)
+	managers := make(map[string]*rdbms.GraniticRdbmsClientManager)
+	managerNames := make(map[string]string)
+
+	var suspender *serverSuspender
 
 	for k, managerConf := range conf {
 		manager := new(rdbms.GraniticRdbmsClientManager)
@@ -105,7 +238,13 @@ func (rafb *FacilityBuilder) createManagers(cn *ioc.ComponentContainer, conf map
 
 		proto := ioc.CreateProtoComponent(manager, managerConf.ManagerName)
 
-		proto.AddDependency("QueryManager", querymanager.QueryManagerComponentName)
+		qm := managerConf.QueryManagerName
+
+		if qm == "" {
+			qm = querymanager.QueryManagerComponentName
+		}
+
+		proto.AddDependency("QueryManager", qm)
 		proto.AddDependency("Configuration", k)
 		cn.AddProto(proto)
 
@@ -113,6 +252,48 @@ func (rafb *FacilityBuilder) createManagers(cn *ioc.ComponentContainer, conf map
 			fieldsToManager[methodToInject] = manager
 		}
 
+		managers[managerConf.ClientName] = manager
+		managerNames[managerConf.ClientName] = managerConf.ManagerName
+
+		if !managerConf.SuspendHTTPServerWhenUnavailable {
+			continue
+		}
+
+		if managerConf.HealthCheckIntervalMS <= 0 {
+			return fmt.Errorf("%s is configured to suspend the HTTP server when its database is unavailable but HealthCheckIntervalMS is not set", managerConf.ClientName)
+		}
+
+		if !facilityEnabled(ca, "HTTPServer") {
+			rafb.Log.LogWarnf("%s is configured to suspend the HTTP server when its database is unavailable but the HTTPServer facility is not enabled", managerConf.ClientName)
+			continue
+		}
+
+		if suspender == nil {
+			suspender = new(serverSuspender)
+			suspender.log = lm.CreateLogger(serverSuspenderName)
+
+			proto := ioc.CreateProtoComponent(suspender, serverSuspenderName)
+			proto.AddDependency("Server", httpserver.HTTPServerComponentName)
+			cn.AddProto(proto)
+		}
+
+		managerConf.ConnectivityListeners = append(managerConf.ConnectivityListeners, suspender)
+	}
+
+	if facilityEnabled(ca, "RuntimeCtl") {
+		pc := new(poolStatsCommand)
+		pc.managers = managers
+
+		cn.WrapAndAddProto(poolStatsCommandName, pc)
+
+		qc := new(queryStatsCommand)
+		qc.managers = managers
+
+		cn.WrapAndAddProto(queryStatsCommandName, qc)
+	}
+
+	if err := rafb.createMigrator(ca, cn, managerNames, lm); err != nil {
+		return err
 	}
 
 	md := new(clientManagerDecorator)
@@ -151,12 +332,169 @@ func (rafb *FacilityBuilder) findConfigurations(cn *ioc.ComponentContainer, c ma
 
 		rafb.Log.LogTracef("ClientManager name will be: %s", config.ManagerName)
 
+		if config.ProviderName != "" {
+			comp.AddDependency("Provider", config.ProviderName)
+		}
+
 		c[name] = config
 
 	}
 
 }
 
+// configuredDatabases creates an rdbms.ClientManagerConfig component for each database declared in RdbmsAccess.Databases
+func (rafb *FacilityBuilder) configuredDatabases(ca *config.Accessor, cn *ioc.ComponentContainer, c map[string]*rdbms.ClientManagerConfig) error {
+
+	if !ca.PathExists(databasesConfigPath) {
+		return nil
+	}
+
+	databases, err := ca.ObjectVal(databasesConfigPath)
+
+	if err != nil {
+		return err
+	}
+
+	for name := range databases {
+
+		mc := new(rdbms.ClientManagerConfig)
+
+		// Settings (other than names) not specified for the database are taken from the default configuration
+		ca.Populate("RdbmsAccess.Default", mc)
+
+		mc.InjectFieldNames = nil
+		mc.ClientName = ""
+		mc.ManagerName = ""
+		mc.ProviderName = ""
+		mc.QueryManagerName = ""
+		mc.ReplicaProviderNames = nil
+
+		if err := ca.Populate(databasesConfigPath+config.JSONPathSeparator+name, mc); err != nil {
+			return err
+		}
+
+		if mc.ProviderName == "" {
+			return fmt.Errorf("database %s in %s must have a ProviderName", name, databasesConfigPath)
+		}
+
+		if mc.ClientName == "" {
+			mc.ClientName = name + "Client"
+		}
+
+		rafb.Log.LogTracef("Database %s will use provider %s and ManagedClient name %s", name, mc.ProviderName, mc.ClientName)
+
+		componentName := rdbmsClientManagerConfigName + "_" + name
+
+		proto := ioc.CreateProtoComponent(mc, componentName)
+		proto.AddDependency("Provider", mc.ProviderName)
+		cn.AddProto(proto)
+
+		c[componentName] = mc
+	}
+
+	return nil
+}
+
+// createMigrator creates an rdbms.Migrator if migrations are enabled in RdbmsAccess.Migrations. managerNames maps
+// the ClientName of each database to the name of its ClientManager component.
+func (rafb *FacilityBuilder) createMigrator(ca *config.Accessor, cn *ioc.ComponentContainer, managerNames map[string]string, lm *logging.ComponentLoggerManager) error {
+
+	enabledPath := migrationsConfigPath + config.JSONPathSeparator + "Enabled"
+
+	if !ca.PathExists(enabledPath) {
+		return nil
+	}
+
+	if enabled, _ := ca.BoolVal(enabledPath); !enabled {
+		return nil
+	}
+
+	m := new(rdbms.Migrator)
+
+	if err := ca.Populate(migrationsConfigPath, m); err != nil {
+		return err
+	}
+
+	if m.Directory == "" {
+		return fmt.Errorf("%s.Directory must be set if migrations are enabled", migrationsConfigPath)
+	}
+
+	if m.Dialect == "" {
+		// Use the same placeholders as queries built by the QueryManager
+		m.Dialect, _ = ca.StringVal("QueryManager.Dialect")
+	}
+
+	if m.Database == "" {
+
+		if len(managerNames) != 1 {
+			return fmt.Errorf("%s.Database must be set to the ClientName of the database to migrate when more than one database is configured", migrationsConfigPath)
+		}
+
+		for name := range managerNames {
+			m.Database = name
+		}
+	}
+
+	managerName := managerNames[m.Database]
+
+	if managerName == "" {
+		return fmt.Errorf("%s.Database is set to %s but no database has that ClientName", migrationsConfigPath, m.Database)
+	}
+
+	m.FrameworkLogger = lm.CreateLogger(migratorName)
+
+	proto := ioc.CreateProtoComponent(m, migratorName)
+	proto.AddDependency("ClientManager", managerName)
+	cn.AddProto(proto)
+
+	if facilityEnabled(ca, "RuntimeCtl") {
+		mc := new(migrateCommand)
+		mc.migrator = m
+
+		cn.WrapAndAddProto(migrateCommandName, mc)
+	}
+
+	return nil
+}
+
+// findReplicas sets the ReplicaProviders of the supplied configuration to the components named in its ReplicaProviderNames
+func (rafb *FacilityBuilder) findReplicas(cn *ioc.ComponentContainer, mc *rdbms.ClientManagerConfig) error {
+
+	protos := cn.ProtoComponents()
+
+	for _, name := range mc.ReplicaProviderNames {
+
+		pc := protos[name]
+
+		if pc == nil {
+			return fmt.Errorf("no component named %s is available to use as a read replica for %s", name, mc.ClientName)
+		}
+
+		p, found := pc.Component.Instance.(rdbms.DatabaseProvider)
+
+		if !found {
+			return fmt.Errorf("component %s cannot be used as a read replica as it does not implement rdbms.DatabaseProvider", name)
+		}
+
+		mc.ReplicaProviders = append(mc.ReplicaProviders, p)
+	}
+
+	return nil
+}
+
+func facilityEnabled(ca *config.Accessor, name string) bool {
+
+	p := "Facilities." + name
+
+	if !ca.PathExists(p) {
+		return false
+	}
+
+	b, _ := ca.BoolVal(p)
+
+	return b
+}
+
 func (rafb *FacilityBuilder) findProviders(cn *ioc.ComponentContainer) []string {
 
 	p := make([]string, 0)
@@ -1,6 +1,16 @@
 package rdbms
 
-import "testing"
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"testing"
+)
 
 func TestFacilityNaming(t *testing.T) {
 
@@ -11,3 +21,263 @@ func TestFacilityNaming(t *testing.T) {
 	}
 
 }
+
+func TestDefaultDatabase(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t, `{}`)
+
+	cn.WrapAndAddProto("dbProvider", new(mockProvider))
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	p := cn.ProtoComponents()
+
+	if p["grncRdbmsClientManager"] == nil {
+		t.Fatalf("Expected default ClientManager to be registered")
+	}
+
+	mc := p[rdbmsClientManagerConfigName]
+
+	if mc == nil || mc.Dependencies["Provider"] != "dbProvider" {
+		t.Errorf("Expected default configuration to use the only provider")
+	}
+}
+
+func TestNamedDatabasesAndReplicas(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t, `{
+      "Databases": {
+        "orders": {
+          "ProviderName": "ordersProvider",
+          "ReplicaProviderNames": ["ordersReplica"],
+          "InjectFieldNames": ["OrdersClientManager"]
+        },
+        "catalogue": {
+          "ProviderName": "catalogueProvider",
+          "QueryManagerName": "catalogueQueryManager",
+          "InjectFieldNames": ["CatalogueClientManager"]
+        }
+      }
+    }`)
+
+	replica := new(mockProvider)
+
+	cn.WrapAndAddProto("ordersProvider", new(mockProvider))
+	cn.WrapAndAddProto("ordersReplica", replica)
+	cn.WrapAndAddProto("catalogueProvider", new(mockProvider))
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	p := cn.ProtoComponents()
+
+	if p["grncRdbmsClientManager"] != nil {
+		t.Errorf("Did not expect a default ClientManager to be registered")
+	}
+
+	orders := p["ordersClientManager"]
+	catalogue := p["catalogueClientManager"]
+
+	if orders == nil || catalogue == nil {
+		t.Fatalf("Expected a ClientManager for each database")
+	}
+
+	if catalogue.Dependencies["QueryManager"] != "catalogueQueryManager" {
+		t.Errorf("Unexpected QueryManager %s", catalogue.Dependencies["QueryManager"])
+	}
+
+	oc := p[orders.Dependencies["Configuration"]]
+
+	if oc.Dependencies["Provider"] != "ordersProvider" {
+		t.Errorf("Unexpected provider %s", oc.Dependencies["Provider"])
+	}
+
+	conf := oc.Component.Instance.(*rdbms.ClientManagerConfig)
+
+	if len(conf.ReplicaProviders) != 1 || conf.ReplicaProviders[0] != replica {
+		t.Errorf("Expected replica provider to be set")
+	}
+
+	d := p[managerDecorator].Component.Instance.(*clientManagerDecorator)
+
+	if d.fieldNameManager["OrdersClientManager"] != orders.Component.Instance {
+		t.Errorf("Expected orders ClientManager to be injected into OrdersClientManager fields")
+	}
+}
+
+func TestInvalidDatabases(t *testing.T) {
+
+	configs := []string{
+		`{"Databases": {"orders": {}}}`,
+		`{"Databases": {"orders": {"ProviderName": "ordersProvider", "ReplicaProviderNames": ["missing"]}}}`,
+		`{"Databases": {"orders": {"ProviderName": "ordersProvider", "ReplicaProviderNames": ["notProvider"]}}}`,
+		`{"Databases": {"orders": {"ProviderName": "ordersProvider", "SuspendHTTPServerWhenUnavailable": true}}}`,
+	}
+
+	for _, c := range configs {
+
+		lm, ca, cn := buildContainer(t, c)
+
+		cn.WrapAndAddProto("ordersProvider", new(mockProvider))
+		cn.WrapAndAddProto("notProvider", new(mockTarget))
+
+		if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err == nil {
+			t.Errorf("Expected an error with configuration %s", c)
+		}
+	}
+}
+
+func TestServerSuspensionAndPoolStats(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t, `{
+      "Databases": {
+        "orders": {
+          "ProviderName": "ordersProvider",
+          "HealthCheckIntervalMS": 1000,
+          "SuspendHTTPServerWhenUnavailable": true
+        }
+      }
+    }`)
+
+	ca.JSONData["Facilities"] = map[string]interface{}{"HTTPServer": true, "RuntimeCtl": true}
+
+	cn.WrapAndAddProto("ordersProvider", new(mockProvider))
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	p := cn.ProtoComponents()
+
+	if p[poolStatsCommandName] == nil {
+		t.Errorf("Expected pool-stats command to be registered")
+	}
+
+	ss := p[serverSuspenderName]
+
+	if ss == nil || ss.Dependencies["Server"] != "grncHTTPServer" {
+		t.Fatalf("Expected server suspender to be registered")
+	}
+
+	conf := p[rdbmsClientManagerConfigName+"_orders"].Component.Instance.(*rdbms.ClientManagerConfig)
+
+	if len(conf.ConnectivityListeners) != 1 {
+		t.Errorf("Expected server suspender to be listening to database")
+	}
+
+	// Settings not specified for the database are taken from the default configuration
+	if conf.ReplicaCheckIntervalMS != 5000 {
+		t.Errorf("Unexpected ReplicaCheckIntervalMS %d", conf.ReplicaCheckIntervalMS)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+
+	lm, ca, cn := buildContainer(t, `{"Migrations": {"Enabled": true, "Directory": "migrations", "RunAtStartup": true}}`)
+
+	ca.JSONData["Facilities"] = map[string]interface{}{"RuntimeCtl": true}
+	ca.JSONData["QueryManager"] = map[string]interface{}{"Dialect": "postgresql"}
+
+	cn.WrapAndAddProto("dbProvider", new(mockProvider))
+
+	if err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn); err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	p := cn.ProtoComponents()
+
+	mp := p[migratorName]
+
+	if mp == nil || mp.Dependencies["ClientManager"] != "grncRdbmsClientManager" {
+		t.Fatalf("Expected migrator to use the default ClientManager")
+	}
+
+	m := mp.Component.Instance.(*rdbms.Migrator)
+
+	if m.Directory != "migrations" || !m.RunAtStartup || m.Database != "grncRdbmsClient" || m.Dialect != "postgresql" {
+		t.Errorf("Unexpected migrator configuration %v", m)
+	}
+
+	if p[migrateCommandName] == nil {
+		t.Errorf("Expected migrate command to be registered")
+	}
+
+	// Migrations are not enabled by default
+	lm, ca, cn = buildContainer(t, `{"Migrations": {"Directory": "migrations"}}`)
+	cn.WrapAndAddProto("dbProvider", new(mockProvider))
+
+	new(FacilityBuilder).BuildAndRegister(lm, ca, cn)
+
+	if cn.ProtoComponents()[migratorName] != nil {
+		t.Errorf("Did not expect migrator to be registered")
+	}
+}
+
+func TestMigrationsWithMultipleDatabases(t *testing.T) {
+
+	databases := `"Databases": {
+        "orders": {"ProviderName": "ordersProvider"},
+        "catalogue": {"ProviderName": "catalogueProvider", "ClientName": "catalogue"}
+      }`
+
+	configs := map[string]bool{
+		`{"Migrations": {"Enabled": true, "Directory": "m"}, ` + databases + `}`:                             false,
+		`{"Migrations": {"Enabled": true, "Directory": "m", "Database": "unknown"}, ` + databases + `}`:      false,
+		`{"Migrations": {"Enabled": true, "Database": "catalogue"}, ` + databases + `}`:                      false,
+		`{"Migrations": {"Enabled": true, "Directory": "m", "Database": "catalogue"}, ` + databases + `}`:    true,
+		`{"Migrations": {"Enabled": true, "Directory": "m", "Database": "ordersClient"}, ` + databases + `}`: true,
+	}
+
+	for c, valid := range configs {
+
+		lm, ca, cn := buildContainer(t, c)
+
+		cn.WrapAndAddProto("ordersProvider", new(mockProvider))
+		cn.WrapAndAddProto("catalogueProvider", new(mockProvider))
+
+		err := new(FacilityBuilder).BuildAndRegister(lm, ca, cn)
+
+		if valid && err != nil {
+			t.Errorf("Unexpected error %s with configuration %s", err.Error(), c)
+		} else if !valid && err == nil {
+			t.Errorf("Expected an error with configuration %s", c)
+		}
+	}
+}
+
+func buildContainer(t *testing.T, rdbmsConfig string) (*logging.ComponentLoggerManager, *config.Accessor, *ioc.ComponentContainer) {
+
+	var rc map[string]interface{}
+
+	if err := json.Unmarshal([]byte(rdbmsConfig), &rc); err != nil {
+		t.Fatal(err)
+	}
+
+	rc["Default"] = map[string]interface{}{
+		"InjectFieldNames":       []interface{}{"DbClientManager"},
+		"ClientName":             "grncRdbmsClient",
+		"ReplicaCheckIntervalMS": 5000,
+	}
+
+	jd := map[string]interface{}{"RdbmsAccess": rc}
+
+	lm := logging.CreateComponentLoggerManager(logging.Error, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+	ca := &config.Accessor{JSONData: jd, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+
+	return lm, ca, ioc.NewComponentContainer(lm, ca, new(instance.System))
+}
+
+type mockProvider struct{}
+
+func (mp *mockProvider) Database() (*sql.DB, error) {
+	return nil, nil
+}
+
+type failingProvider struct{}
+
+func (fp *failingProvider) Database() (*sql.DB, error) {
+	return nil, errors.New("unreachable")
+}
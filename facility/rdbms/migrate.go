@@ -0,0 +1,108 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/ws"
+	"strconv"
+)
+
+const (
+	migCommandName = "migrate"
+	migSummary     = "Shows or applies database schema migrations."
+	migUsage       = "migrate status|up [-dry-run true]"
+	migHelp        = "'migrate status' shows each migration and whether or not it has been applied to the database."
+	migHelpTwo     = "'migrate up' applies all pending migrations in version order, stopping at the first migration that fails."
+	migHelpThree   = "If the '-dry-run true' argument is supplied, 'migrate up' shows the migrations that would be applied without applying them."
+	migDryRunArg   = "dry-run"
+)
+
+type migrateCommand struct {
+	migrator *rdbms.Migrator
+}
+
+func (c *migrateCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	if len(qualifiers) != 1 {
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError("Usage: " + migUsage)}
+	}
+
+	dryRun := false
+
+	if v := args[migDryRunArg]; v != "" {
+
+		var err error
+
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError("value of dry-run argument cannot be interpreted as a bool")}
+		}
+	}
+
+	var migrations []*rdbms.Migration
+	var err error
+
+	switch qualifiers[0] {
+	case "status":
+		migrations, err = c.migrator.Status()
+	case "up":
+		if dryRun {
+			migrations, err = c.migrator.Pending()
+		} else {
+			migrations, err = c.migrator.Up()
+		}
+	default:
+		m := fmt.Sprintf("Unknown migrate command %s (status, up)", qualifiers[0])
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+	}
+
+	if err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Columns
+
+	for _, mg := range migrations {
+		co.OutputBody = append(co.OutputBody, []string{strconv.FormatInt(mg.Version, 10), mg.Description, migrationState(mg)})
+	}
+
+	if len(migrations) == 0 && qualifiers[0] == "up" {
+		co.OutputHeader = "No pending migrations"
+	} else if len(migrations) == 0 {
+		co.OutputHeader = "No migrations"
+	}
+
+	return co, nil
+}
+
+func migrationState(mg *rdbms.Migration) string {
+
+	switch {
+	case mg.Applied && mg.File == "":
+		return "applied (file missing)"
+	case mg.Applied:
+		return "applied"
+	}
+
+	return "pending"
+}
+
+func (c *migrateCommand) Name() string {
+	return migCommandName
+}
+
+func (c *migrateCommand) Summmary() string {
+	return migSummary
+}
+
+func (c *migrateCommand) Usage() string {
+	return migUsage
+}
+
+func (c *migrateCommand) Help() []string {
+	return []string{migHelp, migHelpTwo, migHelpThree}
+}
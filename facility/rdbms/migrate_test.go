@@ -0,0 +1,39 @@
+package rdbms
+
+import (
+	"github.com/graniticio/granitic/v2/rdbms"
+	"testing"
+)
+
+func TestMigrateCommandValidation(t *testing.T) {
+
+	c := new(migrateCommand)
+	c.migrator = new(rdbms.Migrator)
+
+	invalid := [][]string{{}, {"down"}, {"status", "extra"}}
+
+	for _, q := range invalid {
+		if _, errs := c.ExecuteCommand(q, map[string]string{}); len(errs) == 0 {
+			t.Errorf("Expected an error with qualifiers %v", q)
+		}
+	}
+
+	if _, errs := c.ExecuteCommand([]string{"up"}, map[string]string{"dry-run": "maybe"}); len(errs) == 0 {
+		t.Errorf("Expected an error with an invalid dry-run argument")
+	}
+}
+
+func TestMigrationState(t *testing.T) {
+
+	states := map[string]*rdbms.Migration{
+		"pending":                {File: "1_a.sql"},
+		"applied":                {File: "1_a.sql", Applied: true},
+		"applied (file missing)": {Applied: true},
+	}
+
+	for expected, mg := range states {
+		if s := migrationState(mg); s != expected {
+			t.Errorf("Expected %s, got %s", expected, s)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/ws"
+	"sort"
+	"time"
+)
+
+const (
+	psCommandName = "pool-stats"
+	psSummary     = "Shows connection pool statistics and health for each database."
+	psUsage       = "pool-stats [database]"
+	psHelp        = "With no qualifier, shows the connection pool statistics, result of the most recent health check and number of healthy read replicas for each database."
+	psHelpTwo     = "If a database's client name is supplied as a qualifier, only that database is shown."
+)
+
+type poolStatsCommand struct {
+	// ClientManagers keyed by their ClientName
+	managers map[string]*rdbms.GraniticRdbmsClientManager
+}
+
+func (c *poolStatsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	names := make([]string, 0, len(c.managers))
+
+	if len(qualifiers) > 0 {
+
+		if c.managers[qualifiers[0]] == nil {
+			m := fmt.Sprintf("Unknown database %s", qualifiers[0])
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+		}
+
+		names = append(names, qualifiers[0])
+
+	} else {
+
+		for n := range c.managers {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+	}
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Columns
+
+	for _, n := range names {
+		co.OutputBody = append(co.OutputBody, []string{n, describePool(c.managers[n])})
+	}
+
+	return co, nil
+}
+
+func describePool(cm *rdbms.GraniticRdbmsClientManager) string {
+
+	desc := ""
+
+	if s, err := cm.PoolStats(); err != nil {
+		desc = fmt.Sprintf("unavailable (%s)", err.Error())
+	} else {
+		desc = fmt.Sprintf("open=%d in-use=%d idle=%d max-open=%d waits=%d wait-time=%s", s.OpenConnections, s.InUse,
+			s.Idle, s.MaxOpenConnections, s.WaitCount, s.WaitDuration.Round(time.Microsecond))
+	}
+
+	h := cm.Health()
+
+	if !h.Checked.IsZero() {
+
+		if h.Available {
+			desc += " health=ok"
+		} else {
+			desc += fmt.Sprintf(" health=failing failures=%d error=%q", h.ConsecutiveFailures, h.LastError.Error())
+		}
+	}
+
+	if healthy, configured := cm.HealthyReplicas(); configured > 0 {
+		desc += fmt.Sprintf(" replicas=%d/%d", healthy, configured)
+	}
+
+	return desc
+}
+
+func (c *poolStatsCommand) Name() string {
+	return psCommandName
+}
+
+func (c *poolStatsCommand) Summmary() string {
+	return psSummary
+}
+
+func (c *poolStatsCommand) Usage() string {
+	return psUsage
+}
+
+func (c *poolStatsCommand) Help() []string {
+	return []string{psHelp, psHelpTwo}
+}
@@ -0,0 +1,30 @@
+package rdbms
+
+import (
+	"github.com/graniticio/granitic/v2/rdbms"
+	"strings"
+	"testing"
+)
+
+func TestPoolStatsCommand(t *testing.T) {
+
+	cm := new(rdbms.GraniticRdbmsClientManager)
+	cm.Configuration = &rdbms.ClientManagerConfig{Provider: new(failingProvider)}
+
+	c := new(poolStatsCommand)
+	c.managers = map[string]*rdbms.GraniticRdbmsClientManager{"ordersClient": cm}
+
+	out, errs := c.ExecuteCommand([]string{}, map[string]string{})
+
+	if len(errs) > 0 || len(out.OutputBody) != 1 {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	if out.OutputBody[0][0] != "ordersClient" || !strings.HasPrefix(out.OutputBody[0][1], "unavailable") {
+		t.Errorf("Unexpected output %v", out.OutputBody[0])
+	}
+
+	if _, errs = c.ExecuteCommand([]string{"unknown"}, map[string]string{}); len(errs) == 0 {
+		t.Errorf("Expected an error for an unknown database")
+	}
+}
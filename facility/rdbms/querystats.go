@@ -0,0 +1,131 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/ws"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	qsCommandName = "query-stats"
+	qsSummary     = "Shows execution statistics for each query."
+	qsUsage       = "query-stats [database] [-reset true]"
+	qsHelp        = "With no qualifier, shows the number of executions, errors and latency percentiles of each query ID for each database."
+	qsHelpTwo     = "If a database's client name is supplied as a qualifier, only that database's queries are shown."
+	qsHelpThree   = "If the '-reset true' argument is supplied, the statistics shown will be discarded after they are displayed."
+	qsResetArg    = "reset"
+)
+
+type queryStatsCommand struct {
+	// ClientManagers keyed by their ClientName
+	managers map[string]*rdbms.GraniticRdbmsClientManager
+}
+
+func (c *queryStatsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	reset := false
+
+	if v := args[qsResetArg]; v != "" {
+		var err error
+
+		if reset, err = strconv.ParseBool(v); err != nil {
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError("value of reset argument cannot be interpreted as a bool")}
+		}
+	}
+
+	names := make([]string, 0, len(c.managers))
+
+	if len(qualifiers) > 0 {
+
+		if c.managers[qualifiers[0]] == nil {
+			m := fmt.Sprintf("Unknown database %s", qualifiers[0])
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+		}
+
+		names = append(names, qualifiers[0])
+
+	} else {
+
+		for n := range c.managers {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+	}
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Columns
+
+	for _, n := range names {
+
+		qs := c.managers[n].QueryStats()
+
+		if qs == nil {
+			continue
+		}
+
+		co.OutputBody = append(co.OutputBody, queryRows(n, qs.Snapshot())...)
+
+		if reset {
+			qs.Reset()
+		}
+	}
+
+	if len(co.OutputBody) == 0 {
+		co.OutputHeader = "No queries recorded"
+	}
+
+	return co, nil
+}
+
+func queryRows(database string, snap map[string]rdbms.QueryStatistics) [][]string {
+
+	ids := make([]string, 0, len(snap))
+
+	for id := range snap {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	rows := make([][]string, 0, len(ids))
+
+	for _, id := range ids {
+		s := snap[id]
+		h := s.Latency
+
+		desc := fmt.Sprintf("count=%d errors=%d mean=%s p50=%s p90=%s p99=%s max=%s", s.Count, s.Errors, roundDuration(h.Mean()),
+			roundDuration(h.Percentile(50)), roundDuration(h.Percentile(90)), roundDuration(h.Percentile(99)), roundDuration(h.Max))
+
+		rows = append(rows, []string{database, id, desc})
+	}
+
+	return rows
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
+
+func (c *queryStatsCommand) Name() string {
+	return qsCommandName
+}
+
+func (c *queryStatsCommand) Summmary() string {
+	return qsSummary
+}
+
+func (c *queryStatsCommand) Usage() string {
+	return qsUsage
+}
+
+func (c *queryStatsCommand) Help() []string {
+	return []string{qsHelp, qsHelpTwo, qsHelpThree}
+}
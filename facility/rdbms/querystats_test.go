@@ -0,0 +1,57 @@
+package rdbms
+
+import (
+	"github.com/graniticio/granitic/v2/rdbms"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryStatsCommand(t *testing.T) {
+
+	cm := new(rdbms.GraniticRdbmsClientManager)
+	cm.Configuration = &rdbms.ClientManagerConfig{Provider: new(failingProvider)}
+
+	if err := cm.StartComponent(); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	qs := cm.QueryStats()
+	qs.Record("B_QUERY", time.Millisecond, nil)
+	qs.Record("A_QUERY", 2*time.Millisecond, nil)
+
+	c := new(queryStatsCommand)
+	c.managers = map[string]*rdbms.GraniticRdbmsClientManager{"ordersClient": cm}
+
+	out, errs := c.ExecuteCommand([]string{}, map[string]string{"reset": "true"})
+
+	if len(errs) > 0 || len(out.OutputBody) != 2 {
+		t.Fatalf("Unexpected output %v %v", out, errs)
+	}
+
+	row := out.OutputBody[0]
+
+	if row[0] != "ordersClient" || row[1] != "A_QUERY" || !strings.HasPrefix(row[2], "count=1 errors=0") {
+		t.Errorf("Unexpected output %v", row)
+	}
+
+	if len(qs.Snapshot()) != 0 {
+		t.Errorf("Expected statistics to be reset")
+	}
+
+	out, _ = c.ExecuteCommand([]string{"ordersClient"}, map[string]string{})
+
+	if len(out.OutputBody) != 0 || out.OutputHeader == "" {
+		t.Errorf("Unexpected output %v", out)
+	}
+
+	if _, errs = c.ExecuteCommand([]string{"unknown"}, map[string]string{}); len(errs) == 0 {
+		t.Errorf("Expected an error for an unknown database")
+	}
+
+	if _, errs = c.ExecuteCommand([]string{}, map[string]string{"reset": "maybe"}); len(errs) == 0 {
+		t.Errorf("Expected an error for an invalid reset argument")
+	}
+
+	cm.Stop()
+}
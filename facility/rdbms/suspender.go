@@ -0,0 +1,76 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"sync"
+)
+
+// suspensionReporter is implemented by servers that can report whether they are currently suspended
+type suspensionReporter interface {
+	Suspended() bool
+}
+
+// serverSuspender suspends the HTTP server while any of the databases it is listening to are unreachable. A server
+// that was already suspended (e.g. via the RuntimeCtl facility) is left for whoever suspended it to resume.
+type serverSuspender struct {
+	Server      ioc.Suspendable
+	log         logging.Logger
+	mu          sync.Mutex
+	unavailable map[string]bool
+	suspended   bool
+}
+
+// DatabaseUnavailable implements rdbms.ConnectivityListener.DatabaseUnavailable
+func (ss *serverSuspender) DatabaseUnavailable(clientName string, err error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.unavailable == nil {
+		ss.unavailable = make(map[string]bool)
+	}
+
+	ss.unavailable[clientName] = true
+
+	if ss.suspended {
+		return
+	}
+
+	if sr, found := ss.Server.(suspensionReporter); found && sr.Suspended() {
+		ss.log.LogWarnf("The database used by %s is unreachable but the HTTP server is already suspended", clientName)
+		return
+	}
+
+	ss.log.LogWarnf("Suspending HTTP server while the database used by %s is unreachable", clientName)
+
+	if err := ss.Server.Suspend(); err != nil {
+		ss.log.LogErrorf("Unable to suspend HTTP server: %s", err.Error())
+		return
+	}
+
+	ss.suspended = true
+}
+
+// DatabaseAvailable implements rdbms.ConnectivityListener.DatabaseAvailable
+func (ss *serverSuspender) DatabaseAvailable(clientName string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	delete(ss.unavailable, clientName)
+
+	if !ss.suspended || len(ss.unavailable) > 0 {
+		return
+	}
+
+	ss.log.LogInfof("Resuming HTTP server as all databases are reachable")
+
+	if err := ss.Server.Resume(); err != nil {
+		ss.log.LogErrorf("Unable to resume HTTP server: %s", err.Error())
+		return
+	}
+
+	ss.suspended = false
+}
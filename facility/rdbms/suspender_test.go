@@ -0,0 +1,91 @@
+package rdbms
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/logging"
+	"testing"
+)
+
+func TestServerSuspendedWhileAnyDatabaseUnavailable(t *testing.T) {
+
+	s := new(mockServer)
+
+	ss := new(serverSuspender)
+	ss.Server = s
+	ss.log = new(logging.ConsoleErrorLogger)
+
+	ss.DatabaseUnavailable("orders", errors.New("unreachable"))
+	ss.DatabaseUnavailable("catalogue", errors.New("unreachable"))
+
+	if s.suspended != 1 {
+		t.Fatalf("Expected server to be suspended once, was suspended %d times", s.suspended)
+	}
+
+	ss.DatabaseAvailable("orders")
+
+	if s.resumed != 0 {
+		t.Errorf("Did not expect server to be resumed while a database is unavailable")
+	}
+
+	ss.DatabaseAvailable("catalogue")
+
+	if s.resumed != 1 {
+		t.Errorf("Expected server to be resumed")
+	}
+
+	// Only resume the server if it was suspended by the suspender
+	ss.DatabaseAvailable("orders")
+
+	if s.resumed != 1 {
+		t.Errorf("Did not expect server to be resumed again")
+	}
+}
+
+func TestServerSuspendedElsewhereNotResumed(t *testing.T) {
+
+	s := &reportingServer{alreadySuspended: true}
+
+	ss := new(serverSuspender)
+	ss.Server = s
+	ss.log = new(logging.ConsoleErrorLogger)
+
+	ss.DatabaseUnavailable("orders", errors.New("unreachable"))
+	ss.DatabaseAvailable("orders")
+
+	if s.suspended != 0 || s.resumed != 0 {
+		t.Errorf("Expected a server suspended elsewhere to be left alone, was suspended %d and resumed %d times", s.suspended, s.resumed)
+	}
+
+	s.alreadySuspended = false
+
+	ss.DatabaseUnavailable("orders", errors.New("unreachable"))
+	ss.DatabaseAvailable("orders")
+
+	if s.suspended != 1 || s.resumed != 1 {
+		t.Errorf("Expected server to be suspended and resumed once, was suspended %d and resumed %d times", s.suspended, s.resumed)
+	}
+}
+
+type reportingServer struct {
+	mockServer
+	alreadySuspended bool
+}
+
+func (rs *reportingServer) Suspended() bool {
+	return rs.alreadySuspended
+}
+
+type mockServer struct {
+	suspended int
+	resumed   int
+}
+
+func (ms *mockServer) Suspend() error {
+	ms.suspended++
+	return nil
+}
+
+func (ms *mockServer) Resume() error {
+	ms.resumed++
+	return nil
+}
@@ -38,7 +38,9 @@ camel-case mapping) is configurable. Refer to https://granitic.io/ref/json-web-s
 XML
 
 Once the XMLWs facility is enabled, requests to an endpoint will, by default, be parsed as XML and rendered using
-user defined templates. Refer to https://granitic.io/ref/xml-web-services for more details.
+user defined templates. Refer to https://granitic.io/ref/xml-web-services for more details. If your application was started
+with resources embedded in its executable (see ioc.ProtoComponents.WithResources), templates are loaded from those
+resources unless XMLWs.EmbeddedTemplates is set to false.
 
 Alternatively, the XMLWs facility can be configured to automatically render responses as XML using Go's built-in
 XML marshalling components by setting the following configuration in your application's configuration files:
@@ -64,6 +64,10 @@ func (fb *XMLFacilityBuilder) createTemplateComponents(ca *config.Accessor, cc *
 	rw.FrameworkErrors = wc.FrameworkErrors
 	rw.StatusDeterminer = wc.StatusDeterminer
 
+	if embedded, _ := ca.BoolVal("XMLWs.EmbeddedTemplates"); embedded {
+		rw.TemplateFS = cc.Resources()
+	}
+
 	return rw
 
 }
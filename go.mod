@@ -1 +1,3 @@
 module github.com/graniticio/granitic/v2
+
+go 1.19
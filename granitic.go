@@ -177,6 +177,11 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	cc.AddProtos(ac.Components)
 	cc.AddModifiers(ac.FrameworkDependencies)
 
+	//Make any resources embedded in the executable available to facilities and user components
+	if ac.Resources != nil {
+		cc.AddResources(ac.Resources)
+	}
+
 	//Instantiate those facilities required by user and register as components in container
 	fi := facility.NewFacilitiesInitialisor(cc, frameworkLoggingManager)
 
@@ -0,0 +1,109 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// NewJSONSpanExporter creates a JSONSpanExporter that writes to the supplied Writer.
+func NewJSONSpanExporter(w io.Writer) *JSONSpanExporter {
+	je := new(JSONSpanExporter)
+	je.w = w
+
+	return je
+}
+
+// JSONSpanExporter is a SpanExporter that writes each span as a single line of JSON to a file or to stdout. Its output
+// can be analysed offline or fed into a tracing system by a separate process.
+type JSONSpanExporter struct {
+	// The file that spans will be appended to. If empty or set to -, spans are written to stdout.
+	Path string
+
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+}
+
+// ExportSpans implements SpanExporter.ExportSpans
+func (je *JSONSpanExporter) ExportSpans(spans []*Span) error {
+
+	je.mu.Lock()
+	defer je.mu.Unlock()
+
+	if je.w == nil {
+		if err := je.open(); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(je.w)
+
+	for _, s := range spans {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartComponent opens the file at Path (if set)
+func (je *JSONSpanExporter) StartComponent() error {
+	je.mu.Lock()
+	defer je.mu.Unlock()
+
+	if je.w != nil {
+		return nil
+	}
+
+	return je.open()
+}
+
+func (je *JSONSpanExporter) open() error {
+
+	if je.Path == "" || je.Path == "-" {
+		je.w = os.Stdout
+		return nil
+	}
+
+	f, err := os.OpenFile(je.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	je.file = f
+	je.w = f
+
+	return nil
+}
+
+// PrepareToStop does nothing
+func (je *JSONSpanExporter) PrepareToStop() {
+}
+
+// ReadyToStop always returns true, nil
+func (je *JSONSpanExporter) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop closes the file spans are being written to
+func (je *JSONSpanExporter) Stop() error {
+	je.mu.Lock()
+	defer je.mu.Unlock()
+
+	if je.file == nil {
+		return nil
+	}
+
+	err := je.file.Close()
+	je.file = nil
+	je.w = nil
+
+	return err
+}
@@ -0,0 +1,50 @@
+package instrument
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONSpanExporter(t *testing.T) {
+
+	var b bytes.Buffer
+
+	je := NewJSONSpanExporter(&b)
+
+	s := newSpan(newTraceContext(), "", "process", InternalSpan, "svc")
+
+	test.ExpectNil(t, je.ExportSpans([]*Span{s, s}))
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	test.ExpectInt(t, len(lines), 2)
+
+	var m map[string]interface{}
+
+	test.ExpectNil(t, json.Unmarshal([]byte(lines[0]), &m))
+	test.ExpectString(t, m["traceId"].(string), s.TraceID)
+	test.ExpectString(t, m["name"].(string), "process")
+}
+
+func TestJSONSpanExporterFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "spans")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	je := new(JSONSpanExporter)
+	je.Path = filepath.Join(dir, "spans.json")
+
+	test.ExpectNil(t, je.StartComponent())
+	test.ExpectNil(t, je.ExportSpans([]*Span{newSpan(newTraceContext(), "", "write", InternalSpan, "")}))
+	test.ExpectNil(t, je.Stop())
+
+	c, err := ioutil.ReadFile(je.Path)
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, strings.Contains(string(c), "\"name\":\"write\""), true)
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the buckets used by a Histogram if no other bounds are specified.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// NewHistogram creates a Histogram using the supplied bucket upper bounds (which will be sorted into ascending order).
+// If no bounds are supplied, DefaultLatencyBuckets are used.
+func NewHistogram(bounds []time.Duration) *Histogram {
+
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+
+	b := make([]time.Duration, len(bounds))
+	copy(b, bounds)
+
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+
+	h := new(Histogram)
+	h.bounds = b
+	h.counts = make([]uint64, len(b)+1)
+
+	return h
+}
+
+// Histogram records the distribution of a series of durations in a fixed set of buckets. Each bucket counts the durations
+// that are less than or equal to its upper bound (and greater than the previous bucket's upper bound). An implicit
+// final bucket counts durations larger than the largest bound. Histogram is goroutine safe.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// Observe records a single duration.
+func (h *Histogram) Observe(d time.Duration) {
+
+	i := sort.Search(len(h.bounds), func(i int) bool { return d <= h.bounds[i] })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[i]++
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+
+	if d > h.max {
+		h.max = d
+	}
+
+	h.count++
+	h.sum += d
+}
+
+// Merge adds the observations recorded in the supplied snapshot to this Histogram. The snapshot must have
+// been taken from a Histogram with the same bucket bounds.
+func (h *Histogram) Merge(s HistogramSnapshot) {
+
+	if s.Count == 0 || len(s.Counts) != len(h.counts) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, c := range s.Counts {
+		h.counts[i] += c
+	}
+
+	if h.count == 0 || s.Min < h.min {
+		h.min = s.Min
+	}
+
+	if s.Max > h.max {
+		h.max = s.Max
+	}
+
+	h.count += s.Count
+	h.sum += s.Sum
+}
+
+// Reset discards all recorded observations.
+func (h *Histogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts = make([]uint64, len(h.bounds)+1)
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+}
+
+// Snapshot returns a copy of the current state of the Histogram.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := HistogramSnapshot{
+		Bounds: h.bounds,
+		Counts: make([]uint64, len(h.counts)),
+		Count:  h.count,
+		Sum:    h.sum,
+		Min:    h.min,
+		Max:    h.max,
+	}
+
+	copy(s.Counts, h.counts)
+
+	return s
+}
+
+// HistogramSnapshot is a point-in-time copy of the state of a Histogram.
+type HistogramSnapshot struct {
+	// The upper bounds of each bucket in ascending order.
+	Bounds []time.Duration
+
+	// The number of observations in each bucket (not cumulative). Has one more element than Bounds, the final
+	// element being the number of observations larger than the largest bound.
+	Counts []uint64
+
+	// The total number of observations.
+	Count uint64
+
+	// The sum of all observations.
+	Sum time.Duration
+
+	// The smallest observation.
+	Min time.Duration
+
+	// The largest observation.
+	Max time.Duration
+}
+
+// Mean returns the average of all observations or zero if there have been no observations.
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Percentile returns an estimate of the supplied percentile (0-100) of the recorded observations. The estimate is the
+// upper bound of the bucket containing the percentile (or the largest observation, if that is smaller).
+func (s HistogramSnapshot) Percentile(p float64) time.Duration {
+
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(float64(s.Count)*p/100 + 0.5)
+
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen uint64
+
+	for i, c := range s.Counts {
+		seen += c
+
+		if seen >= rank {
+
+			if i < len(s.Bounds) && s.Bounds[i] < s.Max {
+				return s.Bounds[i]
+			}
+
+			return s.Max
+		}
+	}
+
+	return s.Max
+}
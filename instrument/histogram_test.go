@@ -0,0 +1,57 @@
+package instrument
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+
+	h := NewHistogram([]time.Duration{10 * time.Millisecond, time.Millisecond, 100 * time.Millisecond})
+
+	h.Observe(500 * time.Microsecond)
+	h.Observe(time.Millisecond)
+	h.Observe(5 * time.Millisecond)
+	h.Observe(time.Second)
+
+	s := h.Snapshot()
+
+	test.ExpectInt(t, int(s.Count), 4)
+	test.ExpectInt(t, len(s.Counts), 4)
+	test.ExpectInt(t, int(s.Counts[0]), 2)
+	test.ExpectInt(t, int(s.Counts[1]), 1)
+	test.ExpectInt(t, int(s.Counts[2]), 0)
+	test.ExpectInt(t, int(s.Counts[3]), 1)
+
+	test.ExpectBool(t, s.Min == 500*time.Microsecond, true)
+	test.ExpectBool(t, s.Max == time.Second, true)
+
+	test.ExpectBool(t, s.Percentile(50) == time.Millisecond, true)
+	test.ExpectBool(t, s.Percentile(75) == 10*time.Millisecond, true)
+	test.ExpectBool(t, s.Percentile(100) == time.Second, true)
+
+	h.Reset()
+
+	test.ExpectInt(t, int(h.Snapshot().Count), 0)
+	test.ExpectBool(t, h.Snapshot().Percentile(50) == 0, true)
+}
+
+func TestHistogramMerge(t *testing.T) {
+
+	a := NewHistogram(nil)
+	b := NewHistogram(nil)
+
+	a.Observe(2 * time.Millisecond)
+	b.Observe(200 * time.Millisecond)
+	b.Observe(time.Microsecond)
+
+	a.Merge(b.Snapshot())
+
+	s := a.Snapshot()
+
+	test.ExpectInt(t, int(s.Count), 3)
+	test.ExpectBool(t, s.Min == time.Microsecond, true)
+	test.ExpectBool(t, s.Max == 200*time.Millisecond, true)
+	test.ExpectBool(t, s.Sum == 202*time.Millisecond+time.Microsecond, true)
+}
@@ -22,6 +22,8 @@ const (
 	UserIdentity
 	//Handler is he handler that is processing the request (*ws.Handler)
 	Handler
+	//ResponseStatus is the HTTP status code (int) that was sent to the caller
+	ResponseStatus
 )
 
 // Instrumentor is implemented by types that can add additional information to a request that is being instrumented in
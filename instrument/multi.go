@@ -0,0 +1,119 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"net/http"
+)
+
+// MultiRequestInstrumentationManager is an implementation of RequestInstrumentationManager that passes each request to
+// several other RequestInstrumentationManagers (for example a LatencyRecorder and a Tracer), so that a request can be
+// instrumented in more than one way. Managers are begun in the order they appear in Managers and ended in reverse order.
+type MultiRequestInstrumentationManager struct {
+	// The managers each request is passed to
+	Managers []RequestInstrumentationManager
+}
+
+// CombineManagers returns a RequestInstrumentationManager that instruments requests with both of the supplied managers.
+// If existing is nil, added is returned. If existing is a *MultiRequestInstrumentationManager, added is appended to it
+// (unless it is already present).
+func CombineManagers(existing, added RequestInstrumentationManager) RequestInstrumentationManager {
+
+	if existing == nil || existing == added {
+		return added
+	}
+
+	mm, found := existing.(*MultiRequestInstrumentationManager)
+
+	if !found {
+		return &MultiRequestInstrumentationManager{Managers: []RequestInstrumentationManager{existing, added}}
+	}
+
+	for _, m := range mm.Managers {
+		if m == added {
+			return mm
+		}
+	}
+
+	mm.Managers = append(mm.Managers, added)
+
+	return mm
+}
+
+// Begin implements RequestInstrumentationManager.Begin. Each manager is passed the context returned by the previous
+// manager, and the returned context contains an Instrumentor that passes events to the Instrumentor of every manager.
+func (mm *MultiRequestInstrumentationManager) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func()) {
+
+	mi := &multiInstrumentor{instrumentors: make([]Instrumentor, len(mm.Managers))}
+	ends := make([]func(), len(mm.Managers))
+
+	for i, m := range mm.Managers {
+		ctx, mi.instrumentors[i], ends[i] = m.Begin(ctx, res, req)
+	}
+
+	end := func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+
+	return AddInstrumentorToContext(ctx, mi), mi, end
+}
+
+// multiInstrumentor passes calls to the Instrumentor created by each manager of a MultiRequestInstrumentationManager
+type multiInstrumentor struct {
+	instrumentors []Instrumentor
+}
+
+// StartEvent implements Instrumentor.StartEvent
+func (mi *multiInstrumentor) StartEvent(id string, metadata ...interface{}) EndEvent {
+
+	ends := make([]EndEvent, len(mi.instrumentors))
+
+	for i, ri := range mi.instrumentors {
+		ends[i] = ri.StartEvent(id, metadata...)
+	}
+
+	return func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+}
+
+// Fork implements Instrumentor.Fork
+func (mi *multiInstrumentor) Fork(ctx context.Context) (context.Context, Instrumentor) {
+
+	child := &multiInstrumentor{instrumentors: make([]Instrumentor, len(mi.instrumentors))}
+
+	for i, ri := range mi.instrumentors {
+		ctx, child.instrumentors[i] = ri.Fork(ctx)
+	}
+
+	return AddInstrumentorToContext(ctx, child), child
+}
+
+// Integrate implements Instrumentor.Integrate. Each Instrumentor forked from this Instrumentor is integrated with the
+// Instrumentor it was forked from.
+func (mi *multiInstrumentor) Integrate(instrumentor Instrumentor) {
+
+	child, found := instrumentor.(*multiInstrumentor)
+
+	if !found || len(child.instrumentors) != len(mi.instrumentors) {
+		return
+	}
+
+	for i, ri := range mi.instrumentors {
+		ri.Integrate(child.instrumentors[i])
+	}
+}
+
+// Amend implements Instrumentor.Amend
+func (mi *multiInstrumentor) Amend(additional Additional, value interface{}) {
+
+	for _, ri := range mi.instrumentors {
+		ri.Amend(additional, value)
+	}
+}
@@ -0,0 +1,54 @@
+package instrument
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMultiRequestInstrumentationManager(t *testing.T) {
+
+	first := NewLatencyRecorder(nil)
+	second := NewLatencyRecorder(nil)
+
+	im := CombineManagers(CombineManagers(nil, first), second)
+	test.ExpectInt(t, len(im.(*MultiRequestInstrumentationManager).Managers), 2)
+	test.ExpectInt(t, len(CombineManagers(im, second).(*MultiRequestInstrumentationManager).Managers), 2)
+
+	ctx, ri, end := im.Begin(context.Background(), nil, httptest.NewRequest("GET", "/", nil))
+
+	ri.Amend(Handler, namedHandler("artistHandler"))
+	Event(ctx, "process")()
+
+	fctx, child := ri.Fork(ctx)
+	Event(fctx, "async")()
+	ri.Integrate(child)
+
+	ri.Amend(ResponseStatus, http.StatusOK)
+	end()
+
+	for _, lr := range []*LatencyRecorder{first, second} {
+		s := lr.Snapshot()
+		test.ExpectInt(t, int(s.Handlers["artistHandler"].Latency.Count), 1)
+		test.ExpectInt(t, int(s.Events["process"].Count), 1)
+		test.ExpectInt(t, int(s.Events["async"].Count), 1)
+	}
+}
+
+func TestTraceFromMultiRequestInstrumentationManager(t *testing.T) {
+
+	ex := new(collectingExporter)
+	im := CombineManagers(NewLatencyRecorder(nil), &Tracer{Exporter: ex})
+
+	ctx, _, end := im.Begin(context.Background(), nil, httptest.NewRequest("GET", "/", nil))
+
+	_, found := TraceFromContext(ctx)
+	test.ExpectBool(t, found, true)
+
+	Event(ctx, "process")()
+	end()
+
+	test.ExpectInt(t, len(ex.spans), 2)
+}
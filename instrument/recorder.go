@@ -0,0 +1,397 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UnmatchedHandler is the name under which statistics are recorded for requests that were not matched to a handler.
+const UnmatchedHandler = "-"
+
+// NewLatencyRecorder creates a LatencyRecorder whose histograms use the supplied bucket upper bounds. If no bounds
+// are supplied, DefaultLatencyBuckets are used.
+func NewLatencyRecorder(bounds []time.Duration) *LatencyRecorder {
+
+	lr := new(LatencyRecorder)
+	lr.init(bounds)
+
+	return lr
+}
+
+// LatencyRecorder is an implementation of RequestInstrumentationManager that records, for each handler, a histogram
+// of request latencies, a count of the HTTP status codes returned and the number of requests currently being processed.
+// It also records a histogram of durations for each distinct ID passed to Instrumentor.StartEvent.
+//
+// Timings from Instrumentors created with Fork are included as long as they are passed back to the parent
+// Instrumentor with Integrate before the request completes.
+type LatencyRecorder struct {
+	// The upper bounds, in milliseconds, of the buckets used for all histograms. If not set, DefaultLatencyBuckets will be used.
+	BucketsMS []float64
+
+	bounds   []time.Duration
+	mu       sync.RWMutex
+	handlers map[string]*handlerStats
+	events   map[string]*Histogram
+	inFlight int64
+}
+
+// StartComponent converts the configured bucket bounds into durations
+func (lr *LatencyRecorder) StartComponent() error {
+
+	if lr.handlers != nil {
+		return nil
+	}
+
+	var bounds []time.Duration
+
+	for _, ms := range lr.BucketsMS {
+		bounds = append(bounds, time.Duration(ms*float64(time.Millisecond)))
+	}
+
+	lr.init(bounds)
+
+	return nil
+}
+
+func (lr *LatencyRecorder) init(bounds []time.Duration) {
+	lr.bounds = NewHistogram(bounds).bounds
+	lr.handlers = make(map[string]*handlerStats)
+	lr.events = make(map[string]*Histogram)
+}
+
+// Begin implements RequestInstrumentationManager.Begin
+func (lr *LatencyRecorder) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func()) {
+
+	if lr.handlers == nil {
+		lr.init(nil)
+	}
+
+	atomic.AddInt64(&lr.inFlight, 1)
+
+	ri := newRecordingInstrumentor(lr)
+	started := time.Now()
+
+	end := func() {
+		atomic.AddInt64(&lr.inFlight, -1)
+		lr.complete(ri, time.Since(started))
+	}
+
+	return AddInstrumentorToContext(ctx, ri), ri, end
+}
+
+// InFlight returns the number of requests currently being processed.
+func (lr *LatencyRecorder) InFlight() int64 {
+	return atomic.LoadInt64(&lr.inFlight)
+}
+
+// Snapshot returns a copy of all of the statistics recorded so far.
+func (lr *LatencyRecorder) Snapshot() *LatencySnapshot {
+
+	s := new(LatencySnapshot)
+	s.InFlight = lr.InFlight()
+	s.Handlers = make(map[string]*HandlerSnapshot)
+	s.Events = make(map[string]HistogramSnapshot)
+
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	for name, hs := range lr.handlers {
+		s.Handlers[name] = hs.snapshot()
+	}
+
+	for id, h := range lr.events {
+		if hsn := h.Snapshot(); hsn.Count > 0 {
+			s.Events[id] = hsn
+		}
+	}
+
+	return s
+}
+
+// Reset discards all recorded statistics (other than the count of in-flight requests). Histograms are reset in place,
+// so requests completing during or after a reset are recorded.
+func (lr *LatencyRecorder) Reset() {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	for _, hs := range lr.handlers {
+		hs.reset()
+	}
+
+	for _, h := range lr.events {
+		h.Reset()
+	}
+}
+
+func (lr *LatencyRecorder) handler(name string) *handlerStats {
+
+	lr.mu.RLock()
+	hs := lr.handlers[name]
+	lr.mu.RUnlock()
+
+	if hs != nil {
+		return hs
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if hs = lr.handlers[name]; hs == nil {
+		hs = newHandlerStats(lr.bounds)
+		lr.handlers[name] = hs
+	}
+
+	return hs
+}
+
+func (lr *LatencyRecorder) event(id string) *Histogram {
+
+	lr.mu.RLock()
+	h := lr.events[id]
+	lr.mu.RUnlock()
+
+	if h != nil {
+		return h
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if h = lr.events[id]; h == nil {
+		h = NewHistogram(lr.bounds)
+		lr.events[id] = h
+	}
+
+	return h
+}
+
+func (lr *LatencyRecorder) complete(ri *recordingInstrumentor, elapsed time.Duration) {
+
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+
+	name := ri.handler
+
+	if name == "" {
+		name = UnmatchedHandler
+	} else {
+		atomic.AddInt64(&lr.handler(name).inFlight, -1)
+	}
+
+	lr.handler(name).record(elapsed, ri.status)
+
+	for _, e := range ri.events {
+		lr.event(e.id).Observe(e.elapsed)
+	}
+}
+
+// LatencySnapshot is a point-in-time copy of the statistics recorded by a LatencyRecorder.
+type LatencySnapshot struct {
+	// The total number of requests being processed when the snapshot was taken.
+	InFlight int64
+
+	// Statistics for each handler, keyed by the handler's component name.
+	Handlers map[string]*HandlerSnapshot
+
+	// Histograms for each event ID passed to Instrumentor.StartEvent
+	Events map[string]HistogramSnapshot
+}
+
+// HandlerSnapshot is a point-in-time copy of the statistics recorded for an individual handler.
+type HandlerSnapshot struct {
+	// The distribution of request latencies.
+	Latency HistogramSnapshot
+
+	// The number of responses sent with each HTTP status code.
+	Statuses map[int]uint64
+
+	// The distribution of request latencies for each HTTP status code.
+	StatusLatency map[int]HistogramSnapshot
+
+	// The number of requests being processed by the handler when the snapshot was taken.
+	InFlight int64
+}
+
+func newHandlerStats(bounds []time.Duration) *handlerStats {
+	hs := new(handlerStats)
+	hs.bounds = bounds
+	hs.latency = NewHistogram(bounds)
+	hs.statuses = make(map[int]uint64)
+	hs.statusLatency = make(map[int]*Histogram)
+
+	return hs
+}
+
+type handlerStats struct {
+	mu            sync.Mutex
+	bounds        []time.Duration
+	latency       *Histogram
+	statuses      map[int]uint64
+	statusLatency map[int]*Histogram
+	inFlight      int64
+}
+
+func (hs *handlerStats) record(elapsed time.Duration, status int) {
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.latency.Observe(elapsed)
+	hs.statuses[status]++
+
+	h := hs.statusLatency[status]
+
+	if h == nil {
+		h = NewHistogram(hs.bounds)
+		hs.statusLatency[status] = h
+	}
+
+	h.Observe(elapsed)
+}
+
+func (hs *handlerStats) snapshot() *HandlerSnapshot {
+
+	s := new(HandlerSnapshot)
+	s.InFlight = atomic.LoadInt64(&hs.inFlight)
+	s.Statuses = make(map[int]uint64)
+	s.StatusLatency = make(map[int]HistogramSnapshot)
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	s.Latency = hs.latency.Snapshot()
+
+	for k, v := range hs.statuses {
+		if v > 0 {
+			s.Statuses[k] = v
+		}
+	}
+
+	for k, h := range hs.statusLatency {
+		if hsn := h.Snapshot(); hsn.Count > 0 {
+			s.StatusLatency[k] = hsn
+		}
+	}
+
+	return s
+}
+
+// reset zeroes the statistics in place, so that a request holding one of the handler's histograms still records into it
+func (hs *handlerStats) reset() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.latency.Reset()
+
+	for k := range hs.statuses {
+		hs.statuses[k] = 0
+	}
+
+	for _, h := range hs.statusLatency {
+		h.Reset()
+	}
+}
+
+type recordedEvent struct {
+	id      string
+	elapsed time.Duration
+}
+
+func newRecordingInstrumentor(lr *LatencyRecorder) *recordingInstrumentor {
+	ri := new(recordingInstrumentor)
+	ri.recorder = lr
+
+	return ri
+}
+
+// recordingInstrumentor collects the timings of events during a request so they can be added to the
+// LatencyRecorder's histograms when the request completes.
+type recordingInstrumentor struct {
+	mu       sync.Mutex
+	recorder *LatencyRecorder
+	handler  string
+	status   int
+	events   []recordedEvent
+}
+
+// StartEvent implements Instrumentor.StartEvent
+func (ri *recordingInstrumentor) StartEvent(id string, metadata ...interface{}) EndEvent {
+
+	started := time.Now()
+
+	return func() {
+		elapsed := time.Since(started)
+
+		ri.mu.Lock()
+		ri.events = append(ri.events, recordedEvent{id, elapsed})
+		ri.mu.Unlock()
+	}
+}
+
+// Fork implements Instrumentor.Fork
+func (ri *recordingInstrumentor) Fork(ctx context.Context) (context.Context, Instrumentor) {
+
+	child := newRecordingInstrumentor(ri.recorder)
+
+	return AddInstrumentorToContext(ctx, child), child
+}
+
+// Integrate implements Instrumentor.Integrate
+func (ri *recordingInstrumentor) Integrate(instrumentor Instrumentor) {
+
+	child, found := instrumentor.(*recordingInstrumentor)
+
+	if !found || child == ri {
+		return
+	}
+
+	child.mu.Lock()
+	events := make([]recordedEvent, len(child.events))
+	copy(events, child.events)
+	child.events = nil
+	child.mu.Unlock()
+
+	ri.mu.Lock()
+	ri.events = append(ri.events, events...)
+	ri.mu.Unlock()
+}
+
+// Amend implements Instrumentor.Amend. Records the name of the handler processing the request and the HTTP status code returned.
+func (ri *recordingInstrumentor) Amend(additional Additional, value interface{}) {
+
+	switch additional {
+	case Handler:
+		n, found := value.(interface{ ComponentName() string })
+
+		if !found {
+			return
+		}
+
+		name := n.ComponentName()
+
+		ri.mu.Lock()
+		defer ri.mu.Unlock()
+
+		if ri.handler == "" && name != "" {
+			ri.handler = name
+			atomic.AddInt64(&ri.recorder.handler(name).inFlight, 1)
+		}
+
+	case ResponseStatus:
+		if s, found := value.(int); found {
+			ri.mu.Lock()
+			ri.status = s
+			ri.mu.Unlock()
+		}
+	}
+}
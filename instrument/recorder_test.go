@@ -0,0 +1,114 @@
+package instrument
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorderHandlerStats(t *testing.T) {
+
+	lr := NewLatencyRecorder(nil)
+
+	ctx, ri, end := lr.Begin(context.Background(), nil, nil)
+
+	test.ExpectInt(t, int(lr.InFlight()), 1)
+	test.ExpectNotNil(t, InstrumentorFromContext(ctx))
+
+	ri.Amend(Handler, namedHandler("artistHandler"))
+
+	s := lr.Snapshot()
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].InFlight), 1)
+
+	Event(ctx, "db")()
+
+	ri.Amend(ResponseStatus, http.StatusNotFound)
+	end()
+
+	_, _, unmatchedEnd := lr.Begin(context.Background(), nil, nil)
+	unmatchedEnd()
+
+	s = lr.Snapshot()
+
+	test.ExpectInt(t, int(s.InFlight), 0)
+
+	hs := s.Handlers["artistHandler"]
+
+	test.ExpectInt(t, int(hs.InFlight), 0)
+	test.ExpectInt(t, int(hs.Latency.Count), 1)
+	test.ExpectInt(t, int(hs.Statuses[http.StatusNotFound]), 1)
+	test.ExpectInt(t, int(hs.StatusLatency[http.StatusNotFound].Count), 1)
+
+	test.ExpectInt(t, int(s.Handlers[UnmatchedHandler].Statuses[http.StatusOK]), 1)
+	test.ExpectInt(t, int(s.Events["db"].Count), 1)
+
+	lr.Reset()
+
+	s = lr.Snapshot()
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].Latency.Count), 0)
+	test.ExpectInt(t, len(s.Handlers["artistHandler"].StatusLatency), 0)
+	test.ExpectInt(t, len(s.Events), 0)
+}
+
+func TestLatencyRecorderResetDuringRequest(t *testing.T) {
+
+	lr := NewLatencyRecorder(nil)
+
+	ctx, ri, end := lr.Begin(context.Background(), nil, nil)
+	ri.Amend(Handler, namedHandler("artistHandler"))
+	Event(ctx, "db")()
+
+	// Statistics held by a request that is completing when Reset is called are not discarded
+	hs := lr.handler("artistHandler")
+	h := lr.event("db")
+
+	lr.Reset()
+
+	hs.record(time.Millisecond, http.StatusOK)
+	h.Observe(time.Millisecond)
+
+	s := lr.Snapshot()
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].Latency.Count), 1)
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].Statuses[http.StatusOK]), 1)
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].StatusLatency[http.StatusOK].Count), 1)
+	test.ExpectInt(t, int(s.Events["db"].Count), 1)
+
+	end()
+
+	s = lr.Snapshot()
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].Latency.Count), 2)
+	test.ExpectInt(t, int(s.Handlers["artistHandler"].InFlight), 0)
+	test.ExpectInt(t, int(s.Events["db"].Count), 2)
+}
+
+func TestLatencyRecorderForkAndIntegrate(t *testing.T) {
+
+	lr := NewLatencyRecorder(nil)
+
+	ctx, ri, end := lr.Begin(context.Background(), nil, nil)
+
+	fctx, child := ri.Fork(ctx)
+
+	test.ExpectBool(t, InstrumentorFromContext(fctx) == child, true)
+
+	Event(fctx, "child")()
+	Event(fctx, "child")()
+	Event(ctx, "parent")()
+
+	ri.Integrate(child)
+
+	end()
+
+	s := lr.Snapshot()
+
+	test.ExpectInt(t, int(s.Events["child"].Count), 2)
+	test.ExpectInt(t, int(s.Events["parent"].Count), 1)
+}
+
+type namedHandler string
+
+func (nh namedHandler) ComponentName() string {
+	return string(nh)
+}
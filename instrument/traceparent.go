@@ -0,0 +1,185 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceParentHeader is the name of the HTTP header used to propagate trace context (see https://www.w3.org/TR/trace-context/ )
+	TraceParentHeader = "traceparent"
+	// TraceStateHeader is the name of the HTTP header used to propagate vendor-specific trace state
+	TraceStateHeader = "tracestate"
+
+	sampledFlag     = 0x01
+	traceParentLen  = 55
+	supportedFormat = "00"
+)
+
+// TraceContext identifies a span within a distributed trace, as described by the W3C Trace Context recommendation.
+type TraceContext struct {
+	// A globally unique ID shared by every span in the trace
+	TraceID [16]byte
+
+	// The ID of the span this context identifies
+	SpanID [8]byte
+
+	// Trace flags. Only the sampled flag (0x01) is currently defined.
+	Flags byte
+
+	// The unparsed contents of any tracestate header received with the trace context.
+	State string
+}
+
+// Sampled returns true if the sampled flag is set, indicating that the caller may have recorded its part of the trace.
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&sampledFlag != 0
+}
+
+// TraceIDString returns the trace ID as a lower case hex string.
+func (tc TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+// SpanIDString returns the span ID as a lower case hex string.
+func (tc TraceContext) SpanIDString() string {
+	return hex.EncodeToString(tc.SpanID[:])
+}
+
+// TraceParent formats the context as the value of a traceparent header.
+func (tc TraceContext) TraceParent() string {
+	return supportedFormat + "-" + tc.TraceIDString() + "-" + tc.SpanIDString() + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+// ParseTraceParent parses the value of a traceparent header. Headers with a version newer than 00 are accepted as long as
+// their first four fields are valid, as the recommendation requires.
+func ParseTraceParent(header string) (TraceContext, error) {
+
+	var tc TraceContext
+
+	h := strings.TrimSpace(header)
+
+	if len(h) < traceParentLen || (len(h) > traceParentLen && h[traceParentLen] != '-') {
+		return tc, errors.New("traceparent header is not in the expected format")
+	}
+
+	parts := strings.Split(h[:traceParentLen], "-")
+
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || !isLowerHex(h[:traceParentLen], '-') {
+		return tc, errors.New("traceparent header is not in the expected format")
+	}
+
+	version := parts[0]
+
+	if version == "ff" || (version == supportedFormat && len(h) != traceParentLen) {
+		return tc, errors.New("traceparent header has an invalid version")
+	}
+
+	hex.Decode(tc.TraceID[:], []byte(parts[1]))
+	hex.Decode(tc.SpanID[:], []byte(parts[2]))
+
+	f := make([]byte, 1)
+	hex.Decode(f, []byte(parts[3]))
+	tc.Flags = f[0]
+
+	if tc.TraceID == [16]byte{} || tc.SpanID == [8]byte{} {
+		return tc, errors.New("traceparent header contains an all-zero ID")
+	}
+
+	return tc, nil
+}
+
+// TraceFromContext returns the trace context of the span currently in progress in the supplied context, if the request
+// is being traced by a Tracer.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+
+	ti := findTraceInstrumentor(InstrumentorFromContext(ctx))
+
+	if ti == nil {
+		return TraceContext{}, false
+	}
+
+	return ti.current().tc, true
+}
+
+// findTraceInstrumentor returns the supplied Instrumentor if it was created by a Tracer, or the Tracer's Instrumentor
+// if the supplied Instrumentor was created by a MultiRequestInstrumentationManager.
+func findTraceInstrumentor(i Instrumentor) *traceInstrumentor {
+
+	switch ri := i.(type) {
+	case *traceInstrumentor:
+		return ri
+	case *multiInstrumentor:
+		for _, c := range ri.instrumentors {
+			if ti := findTraceInstrumentor(c); ti != nil {
+				return ti
+			}
+		}
+	}
+
+	return nil
+}
+
+// InjectTraceHeaders sets the traceparent and tracestate headers on the supplied header (normally that of an outbound
+// HTTP request) so the receiving service can continue the trace. Returns false if the context does not contain a Tracer's Instrumentor.
+func InjectTraceHeaders(ctx context.Context, h http.Header) bool {
+
+	tc, found := TraceFromContext(ctx)
+
+	if !found {
+		return false
+	}
+
+	h.Set(TraceParentHeader, tc.TraceParent())
+
+	if tc.State != "" {
+		h.Set(TraceStateHeader, tc.State)
+	}
+
+	return true
+}
+
+func newTraceContext() TraceContext {
+
+	var tc TraceContext
+
+	for tc.TraceID == [16]byte{} {
+		rand.Read(tc.TraceID[:])
+	}
+
+	tc.SpanID = newSpanID()
+	tc.Flags = sampledFlag
+
+	return tc
+}
+
+func newSpanID() [8]byte {
+
+	var id [8]byte
+
+	for id == [8]byte{} {
+		rand.Read(id[:])
+	}
+
+	return id
+}
+
+func isLowerHex(s string, allowed byte) bool {
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && c != allowed {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package instrument
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const validTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+
+	tc, err := ParseTraceParent(validTraceParent)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, tc.TraceIDString(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	test.ExpectString(t, tc.SpanIDString(), "00f067aa0ba902b7")
+	test.ExpectBool(t, tc.Sampled(), true)
+	test.ExpectString(t, tc.TraceParent(), validTraceParent)
+
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	test.ExpectNil(t, err)
+
+	invalid := []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-600f067aa0ba902b7-01",
+	}
+
+	for _, h := range invalid {
+		if _, err := ParseTraceParent(h); err == nil {
+			t.Errorf("Expected %q to be rejected", h)
+		}
+	}
+}
+
+func TestInjectTraceHeaders(t *testing.T) {
+
+	tr := new(Tracer)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, validTraceParent)
+	req.Header.Set(TraceStateHeader, "vendor=value")
+
+	ctx, _, end := tr.Begin(context.Background(), nil, req)
+	defer end()
+
+	h := make(http.Header)
+
+	test.ExpectBool(t, InjectTraceHeaders(ctx, h), true)
+
+	tc, err := ParseTraceParent(h.Get(TraceParentHeader))
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, tc.TraceIDString(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	test.ExpectBool(t, tc.SpanIDString() != "00f067aa0ba902b7", true)
+	test.ExpectString(t, h.Get(TraceStateHeader), "vendor=value")
+
+	test.ExpectBool(t, InjectTraceHeaders(context.Background(), h), false)
+}
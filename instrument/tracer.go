@@ -0,0 +1,265 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship between a span and the service that recorded it.
+type SpanKind string
+
+const (
+	// ServerSpan covers the handling of a request received from a remote caller
+	ServerSpan SpanKind = "server"
+	// InternalSpan covers an operation (such as an instrumented event) within the service
+	InternalSpan SpanKind = "internal"
+)
+
+// Span is a timed operation that forms part of a distributed trace.
+type Span struct {
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         SpanKind               `json:"kind"`
+	Service      string                 `json:"service,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+
+	tc TraceContext
+}
+
+// SpanExporter is implemented by components that can send completed spans to a tracing system (or store them for later analysis).
+type SpanExporter interface {
+	// ExportSpans is called with every span recorded during a request once the request has completed. Implementations
+	// must be goroutine safe.
+	ExportSpans(spans []*Span) error
+}
+
+// Tracer is an implementation of RequestInstrumentationManager that records each request as a distributed trace. The
+// trace context is read from the W3C traceparent and tracestate headers of the incoming request (a new trace is started
+// if they are missing or invalid) and can be propagated to outbound requests with InjectTraceHeaders.
+//
+// The request itself is recorded as a server span, named after the handler that processed it, and every call to
+// Instrumentor.StartEvent creates a child span named after the event ID. Spans created by Instrumentors returned by Fork are
+// included as long as they end before the request completes. Completed spans for sampled traces are passed to the Exporter.
+//
+// The trace context of the server span is written to the traceparent header of the response, so callers can find the
+// request in the trace, and is available to handlers by passing the request's context to TraceFromContext.
+type Tracer struct {
+	// The name of this service, recorded on each span
+	ServiceName string
+
+	// The component that spans will be sent to once a request has completed
+	Exporter SpanExporter
+
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+}
+
+// Begin implements RequestInstrumentationManager.Begin
+func (t *Tracer) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func()) {
+
+	var parent *TraceContext
+
+	if tp := req.Header.Get(TraceParentHeader); tp != "" {
+
+		if tc, err := ParseTraceParent(tp); err == nil {
+			tc.State = req.Header.Get(TraceStateHeader)
+			parent = &tc
+		} else if t.FrameworkLogger != nil {
+			t.FrameworkLogger.LogDebugf("Ignoring invalid %s header %q: %s", TraceParentHeader, tp, err.Error())
+		}
+	}
+
+	tr := new(trace)
+	root := t.newRootSpan(parent, req)
+	tr.root = root
+
+	if res != nil {
+		res.Header().Set(TraceParentHeader, root.tc.TraceParent())
+	}
+
+	ti := &traceInstrumentor{tracer: t, trace: tr, base: root}
+
+	end := func() {
+		root.End = time.Now()
+		tr.add(root)
+		t.export(tr)
+	}
+
+	return AddInstrumentorToContext(ctx, ti), ti, end
+}
+
+func (t *Tracer) newRootSpan(parent *TraceContext, req *http.Request) *Span {
+
+	var tc TraceContext
+	var parentID string
+
+	if parent == nil {
+		tc = newTraceContext()
+	} else {
+		tc = *parent
+		tc.SpanID = newSpanID()
+		parentID = parent.SpanIDString()
+	}
+
+	s := newSpan(tc, parentID, req.Method+" "+req.URL.Path, ServerSpan, t.ServiceName)
+	s.Attributes["http.method"] = req.Method
+	s.Attributes["http.target"] = req.URL.RequestURI()
+
+	return s
+}
+
+func (t *Tracer) export(tr *trace) {
+
+	if t.Exporter == nil || !tr.root.tc.Sampled() {
+		return
+	}
+
+	if err := t.Exporter.ExportSpans(tr.completed()); err != nil && t.FrameworkLogger != nil {
+		t.FrameworkLogger.LogErrorf("Unable to export spans for trace %s: %s", tr.root.TraceID, err.Error())
+	}
+}
+
+func newSpan(tc TraceContext, parentID, name string, kind SpanKind, service string) *Span {
+
+	s := new(Span)
+	s.tc = tc
+	s.TraceID = tc.TraceIDString()
+	s.SpanID = tc.SpanIDString()
+	s.ParentSpanID = parentID
+	s.Name = name
+	s.Kind = kind
+	s.Service = service
+	s.Start = time.Now()
+	s.Attributes = make(map[string]interface{})
+
+	return s
+}
+
+// trace holds the completed spans of a single request, shared between an Instrumentor and any Instrumentors forked from it
+type trace struct {
+	mu    sync.Mutex
+	root  *Span
+	spans []*Span
+}
+
+func (tr *trace) add(s *Span) {
+	tr.mu.Lock()
+	tr.spans = append(tr.spans, s)
+	tr.mu.Unlock()
+}
+
+func (tr *trace) completed() []*Span {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	s := make([]*Span, len(tr.spans))
+	copy(s, tr.spans)
+
+	return s
+}
+
+// traceInstrumentor creates spans for events. Events started while another event is in progress are recorded as children of that event.
+type traceInstrumentor struct {
+	mu     sync.Mutex
+	tracer *Tracer
+	trace  *trace
+	base   *Span
+	open   []*Span
+}
+
+// current returns the innermost span in progress. Must not be called while holding ti.mu
+func (ti *traceInstrumentor) current() *Span {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	if n := len(ti.open); n > 0 {
+		return ti.open[n-1]
+	}
+
+	return ti.base
+}
+
+// StartEvent implements Instrumentor.StartEvent. Any metadata is recorded as a span attribute.
+func (ti *traceInstrumentor) StartEvent(id string, metadata ...interface{}) EndEvent {
+
+	parent := ti.current()
+
+	tc := parent.tc
+	tc.SpanID = newSpanID()
+
+	s := newSpan(tc, parent.SpanID, id, InternalSpan, ti.tracer.ServiceName)
+
+	if len(metadata) > 0 {
+		md := make([]string, len(metadata))
+
+		for i, m := range metadata {
+			md[i] = fmt.Sprint(m)
+		}
+
+		s.Attributes["metadata"] = md
+	}
+
+	ti.mu.Lock()
+	ti.open = append(ti.open, s)
+	ti.mu.Unlock()
+
+	return func() {
+		s.End = time.Now()
+
+		ti.mu.Lock()
+
+		for i := len(ti.open) - 1; i >= 0; i-- {
+			if ti.open[i] == s {
+				ti.open = append(ti.open[:i], ti.open[i+1:]...)
+				break
+			}
+		}
+
+		ti.mu.Unlock()
+
+		ti.trace.add(s)
+	}
+}
+
+// Fork implements Instrumentor.Fork. Events started with the new Instrumentor are children of the span in progress when Fork was called.
+func (ti *traceInstrumentor) Fork(ctx context.Context) (context.Context, Instrumentor) {
+
+	child := &traceInstrumentor{tracer: ti.tracer, trace: ti.trace, base: ti.current()}
+
+	return AddInstrumentorToContext(ctx, child), child
+}
+
+// Integrate implements Instrumentor.Integrate. Spans from forked Instrumentors are shared with the parent as soon as they end, so this method does nothing.
+func (ti *traceInstrumentor) Integrate(instrumentor Instrumentor) {
+}
+
+// Amend implements Instrumentor.Amend. Information about the request is recorded as attributes of the server span.
+func (ti *traceInstrumentor) Amend(additional Additional, value interface{}) {
+
+	root := ti.trace.root
+
+	ti.trace.mu.Lock()
+	defer ti.trace.mu.Unlock()
+
+	switch additional {
+	case Handler:
+		if n, found := value.(interface{ ComponentName() string }); found && n.ComponentName() != "" {
+			root.Name = n.ComponentName()
+		}
+	case ResponseStatus:
+		root.Attributes["http.status_code"] = value
+	case RequestID:
+		root.Attributes["request.id"] = value
+	}
+}
@@ -0,0 +1,105 @@
+package instrument
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracerSpans(t *testing.T) {
+
+	ex := new(collectingExporter)
+	tr := &Tracer{ServiceName: "svc", Exporter: ex}
+
+	req := httptest.NewRequest("GET", "/artist/1", nil)
+	req.Header.Set(TraceParentHeader, validTraceParent)
+
+	ctx, ri, end := tr.Begin(context.Background(), nil, req)
+
+	ri.Amend(Handler, namedHandler("artistHandler"))
+
+	endOuter := Event(ctx, "process")
+	endInner := Event(ctx, "rdbms.query", "ARTIST_BY_ID")
+	endInner()
+	endOuter()
+
+	fctx, child := ri.Fork(ctx)
+	Event(fctx, "async")()
+	ri.Integrate(child)
+
+	ri.Amend(ResponseStatus, http.StatusOK)
+	end()
+
+	spans := make(map[string]*Span)
+
+	for _, s := range ex.spans {
+		test.ExpectString(t, s.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+		test.ExpectString(t, s.Service, "svc")
+		spans[s.Name] = s
+	}
+
+	test.ExpectInt(t, len(spans), 4)
+
+	root := spans["artistHandler"]
+	test.ExpectNotNil(t, root)
+	test.ExpectString(t, root.ParentSpanID, "00f067aa0ba902b7")
+	test.ExpectBool(t, root.Kind == ServerSpan, true)
+	test.ExpectInt(t, root.Attributes["http.status_code"].(int), http.StatusOK)
+
+	test.ExpectString(t, spans["process"].ParentSpanID, root.SpanID)
+	test.ExpectString(t, spans["rdbms.query"].ParentSpanID, spans["process"].SpanID)
+	test.ExpectString(t, spans["rdbms.query"].Attributes["metadata"].([]string)[0], "ARTIST_BY_ID")
+	test.ExpectString(t, spans["async"].ParentSpanID, root.SpanID)
+}
+
+func TestTracerUnsampled(t *testing.T) {
+
+	ex := new(collectingExporter)
+	tr := &Tracer{Exporter: ex}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, _, end := tr.Begin(context.Background(), nil, req)
+	end()
+
+	test.ExpectInt(t, len(ex.spans), 0)
+
+	_, _, end = tr.Begin(context.Background(), nil, httptest.NewRequest("GET", "/", nil))
+	end()
+
+	test.ExpectInt(t, len(ex.spans), 1)
+	test.ExpectString(t, ex.spans[0].ParentSpanID, "")
+}
+
+func TestTracerServerSpanWrittenBack(t *testing.T) {
+
+	tr := new(Tracer)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceParentHeader, validTraceParent)
+
+	res := httptest.NewRecorder()
+
+	ctx, _, end := tr.Begin(context.Background(), res, req)
+	defer end()
+
+	tc, found := TraceFromContext(ctx)
+	test.ExpectBool(t, found, true)
+
+	// The response identifies the server span, which is a child of the caller's span
+	test.ExpectString(t, res.Header().Get(TraceParentHeader), tc.TraceParent())
+	test.ExpectString(t, tc.TraceIDString(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	test.ExpectBool(t, tc.SpanIDString() != "00f067aa0ba902b7", true)
+}
+
+type collectingExporter struct {
+	spans []*Span
+}
+
+func (ce *collectingExporter) ExportSpans(spans []*Span) error {
+	ce.spans = append(ce.spans, spans...)
+	return nil
+}
@@ -158,6 +158,8 @@ the grnc-ctl command line utility. See the package documentation for grnc-ctl fo
 */
 package ioc
 
+import "io/fs"
+
 // ComponentState represents what state (stopped, running) or transition between states (stopping, starting) a component is currently in.
 type ComponentState int
 
@@ -192,6 +194,22 @@ type ProtoComponents struct {
 
 	//A Base64 encoded version of the JSON files found in resource/facility-confg
 	FrameworkConfig *string
+
+	// An optional filesystem containing resources (query templates, XML templates etc.) embedded in your application's
+	// executable. See WithResources.
+	Resources fs.FS
+}
+
+// WithResources sets the filesystem (normally an embed.FS declared in your application) containing resources embedded
+// in your application's executable and returns this ProtoComponents to allow a call to be chained. E.g.
+//
+//	granitic.StartGranitic(bindings.Components().WithResources(resources))
+//
+// The filesystem is stored in the container as a component named ResourcesComponentName and facilities that load
+// templates (QueryManager, XMLWs) will load them from the filesystem rather than from the application's working directory.
+func (pc *ProtoComponents) WithResources(r fs.FS) *ProtoComponents {
+	pc.Resources = r
+	return pc
 }
 
 // Clear removes the reference to the ProtoComponent objects held in this object, encouraging garbage collection.
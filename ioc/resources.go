@@ -0,0 +1,41 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"github.com/graniticio/granitic/v2/instance"
+	"io/fs"
+)
+
+// ResourcesComponentName is the name of the component that holds any resources embedded in your application's executable.
+const ResourcesComponentName = instance.FrameworkPrefix + "Resources"
+
+// EmbeddedResources wraps a filesystem containing resources embedded in your application's executable (see
+// ProtoComponents.WithResources) so that it can be stored in the container and injected into any component with a field
+// of type fs.FS.
+type EmbeddedResources struct {
+	fs.FS
+}
+
+// AddResources stores the supplied filesystem in the container as a component named ResourcesComponentName.
+func (cc *ComponentContainer) AddResources(r fs.FS) {
+	cc.WrapAndAddProto(ResourcesComponentName, &EmbeddedResources{FS: r})
+}
+
+// Resources returns the filesystem stored with AddResources or nil if the application does not have embedded resources.
+// If called after the container is 'Accessible' nil will be returned.
+func (cc *ComponentContainer) Resources() fs.FS {
+
+	p := cc.protoComponents[ResourcesComponentName]
+
+	if p == nil {
+		return nil
+	}
+
+	if er, found := p.Component.Instance.(*EmbeddedResources); found {
+		return er.FS
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ioc
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/logging"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResources(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Error, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+	ca := &config.Accessor{JSONData: map[string]interface{}{}, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+	cc := NewComponentContainer(lm, ca, new(instance.System))
+
+	if cc.Resources() != nil {
+		t.Errorf("Did not expect resources to be available")
+	}
+
+	pc := NewProtoComponents(nil, nil, nil).WithResources(fstest.MapFS{"resource/queries/q": {Data: []byte("ID:ONE")}})
+
+	cc.AddResources(pc.Resources)
+
+	b, err := fs.ReadFile(cc.Resources(), "resource/queries/q")
+
+	if err != nil || string(b) != "ID:ONE" {
+		t.Errorf("Unexpected resource content %s %v", b, err)
+	}
+
+	// The wrapped filesystem can be injected into fields of type fs.FS
+	var _ fs.FS = cc.ProtoComponents()[ResourcesComponentName].Component.Instance.(*EmbeddedResources)
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import "sync"
+
+// NewMessageCounter creates an empty MessageCounter
+func NewMessageCounter() *MessageCounter {
+	mc := new(MessageCounter)
+	mc.counts = make(map[string]uint64)
+
+	return mc
+}
+
+// MessageCounter keeps a running total of the number of messages logged at each level (keyed by the level's label,
+// e.g. INFO). Only messages that are actually written (i.e. at a level enabled for the Logger) are counted. MessageCounter is
+// goroutine safe.
+type MessageCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// Increment adds one to the count of messages logged at the level with the supplied label.
+func (mc *MessageCounter) Increment(levelLabel string) {
+	mc.mu.Lock()
+	mc.counts[levelLabel]++
+	mc.mu.Unlock()
+}
+
+// Counts returns a copy of the number of messages logged so far, keyed by level label.
+func (mc *MessageCounter) Counts() map[string]uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	c := make(map[string]uint64, len(mc.counts))
+
+	for k, v := range mc.counts {
+		c[k] = v
+	}
+
+	return c
+}
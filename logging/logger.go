@@ -264,6 +264,7 @@ type GraniticLogger struct {
 	loggerName         string
 	writers            []LogWriter
 	formatter          *LogMessageFormatter
+	counter            *MessageCounter
 }
 
 // UpdateWritersAndFormatter implements RuntimeControllableLog.UpdateWritersAndFormatter
@@ -295,6 +296,7 @@ func (grl *GraniticLogger) log(ctx context.Context, levelLabel string, level Log
 		m := grl.formatter.Format(ctx, levelLabel, grl.loggerName, message)
 
 		grl.write(m)
+		grl.count(levelLabel)
 	}
 
 }
@@ -305,10 +307,17 @@ func (grl *GraniticLogger) logf(ctx context.Context, levelLabel string, level Lo
 		m := grl.formatter.Format(ctx, levelLabel, grl.loggerName, message)
 
 		grl.write(m)
+		grl.count(levelLabel)
 	}
 
 }
 
+func (grl *GraniticLogger) count(levelLabel string) {
+	if grl.counter != nil {
+		grl.counter.Increment(levelLabel)
+	}
+}
+
 func (grl *GraniticLogger) write(m string) {
 
 	for _, w := range grl.writers {
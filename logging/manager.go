@@ -28,9 +28,20 @@ type ComponentLoggerManager struct {
 	formatter       *LogMessageFormatter
 	disabled        bool
 	nullLogger      Logger
+	counter         *MessageCounter
 	ContextFilter   ContextFilter
 }
 
+// SetMessageCounter causes all Loggers managed by this ComponentLoggerManager (including those already created) to
+// record the number of messages they log at each level in the supplied MessageCounter.
+func (clm *ComponentLoggerManager) SetMessageCounter(mc *MessageCounter) {
+	clm.counter = mc
+
+	for _, v := range clm.created {
+		v.counter = mc
+	}
+}
+
 // LoggerByName finds a previously created Logger by the name it was given when it was created. Returns nil if no Logger
 // by that name exists.
 func (clm *ComponentLoggerManager) LoggerByName(name string) *GraniticLogger {
@@ -163,6 +174,7 @@ func (clm *ComponentLoggerManager) CreateLoggerAtLevel(componentID string, thres
 
 	l.writers = clm.writers
 	l.formatter = clm.formatter
+	l.counter = clm.counter
 
 	return l
 }
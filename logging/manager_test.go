@@ -205,3 +205,30 @@ func (dw *dummyWriter) Busy() bool {
 
 	return dw.b
 }
+
+func TestMessageCounting(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Info, make(map[string]interface{}), []LogWriter{}, NewNoPrefixFormatter())
+
+	before := clm.CreateLogger("A")
+
+	mc := NewMessageCounter()
+	clm.SetMessageCounter(mc)
+
+	after := clm.CreateLogger("B")
+
+	before.LogInfof("Counted")
+	before.LogDebugf("Not enabled")
+	after.LogErrorf("Counted %d", 1)
+	after.LogInfof("Counted")
+
+	c := mc.Counts()
+
+	if c[InfoLabel] != 2 || c[ErrorLabel] != 1 {
+		t.Errorf("Unexpected counts %v", c)
+	}
+
+	if _, found := c[DebugLabel]; found {
+		t.Errorf("Messages at disabled levels should not be counted")
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds used by a Histogram if no other bounds are supplied. They are suitable for
+// measuring durations, in seconds, of typical web service requests.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+func newVec(name, help string, labelNames []string) (*vec, error) {
+
+	if !ValidName(name) {
+		return nil, fmt.Errorf("%s is not a valid metric name", name)
+	}
+
+	for _, ln := range labelNames {
+		if !validLabelName(ln) {
+			return nil, fmt.Errorf("%s is not a valid label name for metric %s", ln, name)
+		}
+	}
+
+	v := new(vec)
+	v.name = name
+	v.help = help
+	v.labelNames = labelNames
+	v.series = make(map[string]*series)
+
+	return v, nil
+}
+
+// vec holds the values of a metric for each distinct combination of label values.
+type vec struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	labelNames []string
+	series     map[string]*series
+	keys       []string
+}
+
+type series struct {
+	labels []Label
+	value  float64
+	counts []uint64
+}
+
+// with returns the series for the supplied label values, creating it if necessary. Must be called while holding v.mu
+func (v *vec) with(labelValues []string) *series {
+
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metric %s has %d label(s) but %d value(s) were supplied", v.name, len(v.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	s := v.series[key]
+
+	if s == nil {
+		s = new(series)
+		s.labels = make([]Label, len(labelValues))
+
+		for i, lv := range labelValues {
+			s.labels[i] = Label{v.labelNames[i], lv}
+		}
+
+		v.series[key] = s
+		v.keys = append(v.keys, key)
+		sort.Strings(v.keys)
+	}
+
+	return s
+}
+
+func (v *vec) add(delta float64, labelValues []string) {
+	v.mu.Lock()
+	v.with(labelValues).value += delta
+	v.mu.Unlock()
+}
+
+func (v *vec) collect(t Type) []*Family {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	f := &Family{Name: v.name, Help: v.help, Type: t}
+
+	for _, k := range v.keys {
+		s := v.series[k]
+		f.Samples = append(f.Samples, Sample{v.name, s.labels, s.value})
+	}
+
+	return []*Family{f}
+}
+
+// Counter is a metric whose value only increases. The number of label values passed to each method must match the
+// number of label names the Counter was created with, otherwise the method will panic.
+type Counter struct {
+	v *vec
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc(labelValues ...string) {
+	c.v.add(1, labelValues)
+}
+
+// Add adds the supplied value to the counter. Panics if the value is negative.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+
+	if delta < 0 {
+		panic(fmt.Sprintf("counter %s cannot be decreased", c.v.name))
+	}
+
+	c.v.add(delta, labelValues)
+}
+
+// Collect implements Collector.Collect
+func (c *Counter) Collect() []*Family {
+	return c.v.collect(CounterType)
+}
+
+// Gauge is a metric whose value can go up and down. The number of label values passed to each method must match the
+// number of label names the Gauge was created with, otherwise the method will panic.
+type Gauge struct {
+	v *vec
+}
+
+// Set sets the value of the gauge.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.v.mu.Lock()
+	g.v.with(labelValues).value = value
+	g.v.mu.Unlock()
+}
+
+// Add adds the supplied (possibly negative) value to the gauge.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.v.add(delta, labelValues)
+}
+
+// Inc adds one to the gauge.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.v.add(1, labelValues)
+}
+
+// Dec subtracts one from the gauge.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.v.add(-1, labelValues)
+}
+
+// Collect implements Collector.Collect
+func (g *Gauge) Collect() []*Family {
+	return g.v.collect(GaugeType)
+}
+
+func newHistogram(v *vec, buckets []float64) *Histogram {
+
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	h := new(Histogram)
+	h.v = v
+	h.bounds = b
+
+	return h
+}
+
+// Histogram counts observations in a set of buckets. The number of label values passed to Observe must match the
+// number of label names the Histogram was created with, otherwise the method will panic.
+type Histogram struct {
+	v      *vec
+	bounds []float64
+}
+
+// Observe records a single value.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+
+	i := sort.SearchFloat64s(h.bounds, value)
+
+	h.v.mu.Lock()
+	defer h.v.mu.Unlock()
+
+	s := h.v.with(labelValues)
+
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.bounds)+1)
+	}
+
+	s.counts[i]++
+	s.value += value
+}
+
+// Collect implements Collector.Collect
+func (h *Histogram) Collect() []*Family {
+	h.v.mu.Lock()
+	defer h.v.mu.Unlock()
+
+	f := &Family{Name: h.v.name, Help: h.v.help, Type: HistogramType}
+
+	for _, k := range h.v.keys {
+		s := h.v.series[k]
+		f.Samples = append(f.Samples, HistogramSamples(h.v.name, s.labels, h.bounds, s.counts, s.value)...)
+	}
+
+	return []*Family{f}
+}
+
+// HistogramSamples converts the state of a histogram into the _bucket, _sum and _count samples expected by Prometheus.
+// counts holds the number of observations in each bucket (not cumulative) and must have one more element than bounds,
+// the last element being the number of observations larger than the largest bound.
+func HistogramSamples(name string, labels []Label, bounds []float64, counts []uint64, sum float64) []Sample {
+
+	samples := make([]Sample, 0, len(bounds)+3)
+
+	var cumulative uint64
+
+	for i := 0; i <= len(bounds); i++ {
+
+		if i < len(counts) {
+			cumulative += counts[i]
+		}
+
+		le := math.Inf(1)
+
+		if i < len(bounds) {
+			le = bounds[i]
+		}
+
+		bl := make([]Label, len(labels), len(labels)+1)
+		copy(bl, labels)
+		bl = append(bl, Label{"le", formatValue(le)})
+
+		samples = append(samples, Sample{name + "_bucket", bl, float64(cumulative)})
+	}
+
+	samples = append(samples, Sample{name + "_sum", labels, sum})
+	samples = append(samples, Sample{name + "_count", labels, float64(cumulative)})
+
+	return samples
+}
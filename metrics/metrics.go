@@ -0,0 +1,193 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package metrics provides types for recording numeric measurements about a running application and exposing them in the
+Prometheus text exposition format (see https://prometheus.io/docs/instrumenting/exposition_formats/ ).
+
+Application code normally interacts with a Registry that has been created by the Metrics facility and injected into
+a field on the application's components (see the facility/metrics package). Counters, gauges and histograms are
+created by calling the relevant method on the Registry:
+
+	requests, err := r.Counter("orders_received_total", "Orders received", "channel")
+
+	...
+
+	requests.Inc("web")
+
+Metrics whose values are already held elsewhere (for example the statistics maintained by a connection pool) can be
+exposed by adding a Collector to the Registry.
+*/
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Type identifies the kind of a metric.
+type Type string
+
+const (
+	// CounterType is a value that only ever increases (or is reset to zero when the application restarts)
+	CounterType Type = "counter"
+	// GaugeType is a value that can go up or down
+	GaugeType Type = "gauge"
+	// HistogramType is a distribution of observations counted in buckets
+	HistogramType Type = "histogram"
+)
+
+var metricNamePattern = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
+var labelNamePattern = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// Label is a name-value pair that distinguishes one Sample from others with the same name.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of a metric.
+type Sample struct {
+	// The name of the sample. Normally the same as the name of the Family, but histograms have samples with _bucket, _sum and _count suffixes
+	Name string
+
+	// The labels that identify this sample
+	Labels []Label
+
+	Value float64
+}
+
+// Family is a group of samples that share a name, help text and type.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+// Collector is implemented by components that can supply the current value of one or more metrics when the Registry
+// is gathered (normally when a request for metrics is received).
+type Collector interface {
+	// Collect returns the current value of the metrics managed by this Collector
+	Collect() []*Family
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.names = make(map[string]bool)
+
+	return r
+}
+
+// Registry holds the metrics and Collectors that make up an application's exposed metrics. Registry is goroutine safe.
+type Registry struct {
+	mu         sync.RWMutex
+	names      map[string]bool
+	collectors []Collector
+}
+
+// Counter creates and registers a new Counter. An error is returned if the name or any of the label names is invalid, or if a
+// metric with the same name has already been registered.
+func (r *Registry) Counter(name, help string, labelNames ...string) (*Counter, error) {
+
+	v, err := newVec(name, help, labelNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Counter{v}
+
+	return c, r.register(name, c)
+}
+
+// Gauge creates and registers a new Gauge. An error is returned if the name or any of the label names is invalid, or if a
+// metric with the same name has already been registered.
+func (r *Registry) Gauge(name, help string, labelNames ...string) (*Gauge, error) {
+
+	v, err := newVec(name, help, labelNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gauge{v}
+
+	return g, r.register(name, g)
+}
+
+// Histogram creates and registers a new Histogram with the supplied bucket upper bounds (DefaultBuckets are used if
+// no bounds are supplied). An error is returned if the name or any of the label names is invalid, if a label is named 'le'
+// or if a metric with the same name has already been registered.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) (*Histogram, error) {
+
+	for _, ln := range labelNames {
+		if ln == "le" {
+			return nil, fmt.Errorf("le is reserved and cannot be used as a label name for histogram %s", name)
+		}
+	}
+
+	v, err := newVec(name, help, labelNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h := newHistogram(v, buckets)
+
+	return h, r.register(name, h)
+}
+
+// AddCollector adds a Collector whose metrics will be included whenever the Registry is gathered. It is the
+// Collector's responsibility to make sure the names of its metrics do not clash with other metrics.
+func (r *Registry) AddCollector(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Gather collects the current value of every metric in the registry, sorted by name.
+func (r *Registry) Gather() []*Family {
+
+	r.mu.RLock()
+	collectors := make([]Collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	var families []*Family
+
+	for _, c := range collectors {
+		families = append(families, c.Collect()...)
+	}
+
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+
+	return families
+}
+
+func (r *Registry) register(name string, c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		return fmt.Errorf("a metric named %s has already been registered", name)
+	}
+
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+
+	return nil
+}
+
+// ValidName returns true if the supplied string is a valid Prometheus metric name.
+func ValidName(name string) bool {
+	return metricNamePattern.MatchString(name)
+}
+
+func validLabelName(name string) bool {
+	return labelNamePattern.MatchString(name) && !(len(name) > 1 && name[0:2] == "__")
+}
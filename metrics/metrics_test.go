@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestRegistration(t *testing.T) {
+
+	r := NewRegistry()
+
+	_, err := r.Counter("requests_total", "Requests", "method")
+	test.ExpectNil(t, err)
+
+	_, err = r.Gauge("requests_total", "Duplicate")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.Gauge("9invalid", "Invalid name")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.Counter("valid_total", "Invalid label", "bad-label")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.Histogram("latency_seconds", "Reserved label", nil, "le")
+	test.ExpectNotNil(t, err)
+}
+
+func TestCounterAndGauge(t *testing.T) {
+
+	r := NewRegistry()
+
+	c, _ := r.Counter("requests_total", "Requests", "method")
+	c.Inc("GET")
+	c.Add(2, "GET")
+	c.Inc("POST")
+
+	g, _ := r.Gauge("queue_depth", "Depth")
+	g.Set(10)
+	g.Dec()
+
+	f := r.Gather()
+
+	test.ExpectInt(t, len(f), 2)
+	test.ExpectString(t, f[0].Name, "queue_depth")
+	test.ExpectFloat(t, f[0].Samples[0].Value, 9)
+
+	test.ExpectString(t, f[1].Name, "requests_total")
+	test.ExpectInt(t, len(f[1].Samples), 2)
+	test.ExpectString(t, f[1].Samples[0].Labels[0].Value, "GET")
+	test.ExpectFloat(t, f[1].Samples[0].Value, 3)
+}
+
+func TestLabelMismatchPanics(t *testing.T) {
+
+	r := NewRegistry()
+	c, _ := r.Counter("requests_total", "Requests", "method")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	c.Inc()
+}
+
+func TestHistogram(t *testing.T) {
+
+	r := NewRegistry()
+	h, _ := r.Histogram("latency_seconds", "Latency", []float64{1, 0.5})
+
+	h.Observe(0.5)
+	h.Observe(0.7)
+	h.Observe(3)
+
+	s := h.Collect()[0].Samples
+
+	test.ExpectInt(t, len(s), 5)
+	test.ExpectString(t, s[0].Labels[0].Value, "0.5")
+	test.ExpectFloat(t, s[0].Value, 1)
+	test.ExpectFloat(t, s[1].Value, 2)
+	test.ExpectString(t, s[2].Labels[0].Value, "+Inf")
+	test.ExpectFloat(t, s[2].Value, 3)
+	test.ExpectString(t, s[3].Name, "latency_seconds_sum")
+	test.ExpectFloat(t, s[3].Value, 4.2)
+	test.ExpectFloat(t, s[4].Value, 3)
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// RuntimeCollector is a Collector that exposes statistics about the Go runtime (goroutines, memory and garbage collection).
+type RuntimeCollector struct{}
+
+// Collect implements Collector.Collect
+func (rc *RuntimeCollector) Collect() []*Family {
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	lastGC := float64(0)
+
+	if ms.LastGC > 0 {
+		lastGC = float64(ms.LastGC) / float64(time.Second)
+	}
+
+	return []*Family{
+		single("go_goroutines", "Number of goroutines that currently exist.", GaugeType, float64(runtime.NumGoroutine())),
+		{Name: "go_info", Help: "Information about the Go environment.", Type: GaugeType,
+			Samples: []Sample{{"go_info", []Label{{"version", runtime.Version()}}, 1}}},
+		single("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", GaugeType, float64(ms.Alloc)),
+		single("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", CounterType, float64(ms.TotalAlloc)),
+		single("go_memstats_sys_bytes", "Number of bytes obtained from system.", GaugeType, float64(ms.Sys)),
+		single("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", GaugeType, float64(ms.HeapAlloc)),
+		single("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", GaugeType, float64(ms.HeapInuse)),
+		single("go_memstats_heap_objects", "Number of allocated objects.", GaugeType, float64(ms.HeapObjects)),
+		single("go_memstats_mallocs_total", "Total number of mallocs.", CounterType, float64(ms.Mallocs)),
+		single("go_memstats_frees_total", "Total number of frees.", CounterType, float64(ms.Frees)),
+		single("go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.", GaugeType, lastGC),
+		single("go_gc_cycles_total", "Number of completed garbage collection cycles.", CounterType, float64(ms.NumGC)),
+		single("go_gc_pause_seconds_total", "Total time spent in stop-the-world garbage collection pauses.", CounterType, float64(ms.PauseTotalNs)/float64(time.Second)),
+	}
+}
+
+func single(name, help string, t Type, value float64) *Family {
+	return &Family{Name: name, Help: help, Type: t, Samples: []Sample{{name, nil, value}}}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestRuntimeCollector(t *testing.T) {
+
+	found := make(map[string]bool)
+
+	for _, f := range new(RuntimeCollector).Collect() {
+
+		if !ValidName(f.Name) || len(f.Samples) != 1 {
+			t.Errorf("Unexpected family %s", f.Name)
+		}
+
+		found[f.Name] = true
+	}
+
+	if !found["go_goroutines"] || !found["go_memstats_heap_alloc_bytes"] {
+		t.Errorf("Expected goroutine and memory metrics")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TextContentType is the HTTP Content-Type of the Prometheus text exposition format.
+const TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var helpEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+var labelEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"")
+
+// WriteText gathers all of the metrics in the Registry and writes them to the supplied Writer in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+
+	bw := bufio.NewWriter(w)
+
+	for _, f := range r.Gather() {
+
+		if f.Help != "" {
+			bw.WriteString("# HELP " + f.Name + " " + helpEscaper.Replace(f.Help) + "\n")
+		}
+
+		bw.WriteString("# TYPE " + f.Name + " " + string(f.Type) + "\n")
+
+		for _, s := range f.Samples {
+			bw.WriteString(s.Name)
+
+			if len(s.Labels) > 0 {
+				bw.WriteString("{")
+
+				for i, l := range s.Labels {
+					if i > 0 {
+						bw.WriteString(",")
+					}
+
+					bw.WriteString(l.Name + "=\"" + labelEscaper.Replace(l.Value) + "\"")
+				}
+
+				bw.WriteString("}")
+			}
+
+			bw.WriteString(" " + formatValue(s.Value) + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP writes the Registry's metrics in the Prometheus text exposition format, allowing the Registry to be used as an http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", TextContentType)
+	w.WriteHeader(http.StatusOK)
+
+	r.WriteText(w)
+}
+
+func formatValue(v float64) string {
+
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTextExposition(t *testing.T) {
+
+	r := NewRegistry()
+
+	c, _ := r.Counter("requests_total", "Requests\nreceived", "path")
+	c.Inc("/a\"b")
+
+	h, _ := r.Histogram("latency_seconds", "", []float64{1})
+	h.Observe(0.25)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	test.ExpectString(t, rec.Header().Get("Content-Type"), TextContentType)
+
+	expected := []string{
+		"# TYPE latency_seconds histogram",
+		"latency_seconds_bucket{le=\"1\"} 1",
+		"latency_seconds_bucket{le=\"+Inf\"} 1",
+		"latency_seconds_sum 0.25",
+		"latency_seconds_count 1",
+		"# HELP requests_total Requests\\nreceived",
+		"# TYPE requests_total counter",
+		"requests_total{path=\"/a\\\"b\"} 1",
+	}
+
+	test.ExpectString(t, rec.Body.String(), strings.Join(expected, "\n")+"\n")
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"reflect"
+	"time"
+)
+
+const (
+	// BatchRowsParam is the name of the parameter that holds the rows in each batch executed by
+	// ManagedClient.InsertBatchQIDParams. Templates should use it in a range section.
+	BatchRowsParam = "rows"
+
+	// DefaultBatchSize is the maximum number of rows inserted by each statement executed by
+	// ManagedClient.InsertBatchQIDParams if ClientManagerConfig.BatchSize is not set.
+	DefaultBatchSize = 500
+)
+
+// BatchResult summarises the statements executed by ManagedClient.InsertBatchQIDParams.
+type BatchResult struct {
+	// The number of statements successfully executed.
+	Batches int
+
+	// The total number of rows affected, as reported by the database driver. Drivers for some databases (for example
+	// MySQL) count a row that was updated by an upsert as two affected rows.
+	RowsAffected int64
+
+	// The IDs generated for the inserted rows, in the order the rows were supplied. Only set if the DatabaseProvider
+	// implements NonStandardBatchInsertProvider and the query is not an upsert (IDs are not captured for upserts, as
+	// rows that are updated rather than inserted are not allocated a new ID).
+	IDs []int64
+}
+
+// InsertBatchQIDParams inserts (or upserts) multiple rows with the supplied query. rows must be a slice of structs,
+// pointers to structs or map[string]interface{}. The rows are split into batches of at most ClientManagerConfig.BatchSize
+// rows and the query is executed once for each batch, with the batch available to the query template as the parameter
+// BatchRowsParam:
+//
+//	ID:ARTIST_INSERT
+//
+//	INSERT INTO artist (name, genre) VALUES
+//	--#range !rows ,
+//	    (${name}, ${genre})
+//	--#end
+//
+// Any other params are available to every batch. If a batch fails, the returned BatchResult describes the batches
+// that succeeded. Batches are not executed in a transaction unless one is already open.
+func (rc *ManagedClient) InsertBatchQIDParams(qid string, rows interface{}, params ...interface{}) (*BatchResult, error) {
+
+	if rows == nil || reflect.TypeOf(rows).Kind() != reflect.Slice {
+		return nil, fmt.Errorf("rows for query %s must be a slice of structs or map[string]interface{} (type is %T)", qid, rows)
+	}
+
+	rv := reflect.ValueOf(rows)
+	size := rc.batchSize
+
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	br := new(BatchResult)
+
+	for start := 0; start < rv.Len(); start += size {
+
+		end := start + size
+
+		if end > rv.Len() {
+			end = rv.Len()
+		}
+
+		p := make([]interface{}, 0, len(params)+1)
+		p = append(p, params...)
+		p = append(p, map[string]interface{}{BatchRowsParam: rv.Slice(start, end).Interface()})
+
+		if err := rc.insertBatch(qid, end-start, br, p...); err != nil {
+			return br, err
+		}
+	}
+
+	return br, nil
+}
+
+func (rc *ManagedClient) insertBatch(qid string, rows int, br *BatchResult, params ...interface{}) error {
+
+	query, args, pm, err := rc.buildQuery(qid, params...)
+
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	if rc.batchIDs != nil && !rc.isUpsert(qid) {
+
+		var ids []int64
+
+		if ids, err = rc.batchIDs(query, rc.forQID(qid), rows, args...); err == nil {
+			br.IDs = append(br.IDs, ids...)
+			br.RowsAffected += int64(len(ids))
+		}
+
+	} else {
+
+		var r sql.Result
+		var affected int64
+
+		if r, err = rc.exec(qid, query, args...); err == nil {
+			affected, err = r.RowsAffected()
+			br.RowsAffected += affected
+		}
+	}
+
+	rc.observe(qid, pm, start, err)
+
+	if err != nil {
+		return err
+	}
+
+	br.Batches++
+
+	return nil
+}
+
+// isUpsert returns true if the QueryManager reports that the supplied query contains an upsert directive.
+func (rc *ManagedClient) isUpsert(qid string) bool {
+
+	if ud, found := rc.queryManager.(dsquery.UpsertDetector); found {
+		return ud.IsUpsert(qid)
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package rdbms
+
+import (
+	"database/sql/driver"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+type batchRow struct {
+	Name  string
+	Genre string
+}
+
+func TestInsertBatchSplitsRows(t *testing.T) {
+
+	c := txClient(t, 0)
+	c.batchSize = 2
+	c.stats = NewQueryStats()
+
+	rows := []batchRow{{"A", "Pop"}, {"B", "Rock"}, {"C", "Jazz"}, {"D", "Pop"}, {"E", "Rock"}}
+
+	qm.reset()
+
+	br, err := c.InsertBatchQIDParams("BATCH_INSERT", rows, map[string]interface{}{"source": "import"})
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, br.Batches, 3)
+	test.ExpectInt(t, int(br.RowsAffected), 3)
+
+	if br.IDs != nil {
+		t.Errorf("Expected no IDs, got %v", br.IDs)
+	}
+
+	test.ExpectString(t, txDrv.log(), "BATCH_INSERT,BATCH_INSERT,BATCH_INSERT")
+	test.ExpectInt(t, int(c.stats.Snapshot()["BATCH_INSERT"].Count), 3)
+
+	// The last batch has the remaining row, converted to a map, and the common parameters
+	last := qm.lastParams[BatchRowsParam].([]map[string]interface{})
+	test.ExpectInt(t, len(last), 1)
+	test.ExpectString(t, last[0]["Name"].(string), "E")
+	test.ExpectString(t, qm.lastParams["source"].(string), "import")
+
+	_, err = c.InsertBatchQIDParams("BATCH_INSERT", batchRow{"A", "Pop"})
+	test.ExpectNotNil(t, err)
+}
+
+func TestInsertBatchStopsOnError(t *testing.T) {
+
+	c := txClient(t, 0)
+	c.batchSize = 1
+	txDrv.failOn = "BATCH"
+
+	br, err := c.InsertBatchQIDParams("BATCH_INSERT", []map[string]interface{}{{"name": "A"}, {"name": "B"}})
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, br.Batches, 0)
+	test.ExpectString(t, txDrv.log(), "BATCH_INSERT")
+}
+
+func TestInsertBatchReturnedIDs(t *testing.T) {
+
+	c := txClient(t, 0)
+	c.batchSize = 2
+	c.batchIDs = ReturningBatchInsert
+
+	txDrv.columns = []string{"id"}
+	txDrv.rows = [][]driver.Value{{int64(7)}, {int64(8)}}
+
+	br, err := c.InsertBatchQIDParams("BATCH_INSERT", []batchRow{{"A", "Pop"}, {"B", "Rock"}, {"C", "Jazz"}})
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, br.Batches, 2)
+	test.ExpectInt(t, len(br.IDs), 4)
+	test.ExpectInt(t, int(br.IDs[3]), 8)
+	test.ExpectInt(t, int(br.RowsAffected), 4)
+}
+
+func TestFirstIDBatchInsert(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.consumed()
+	ids, err := FirstIDBatchInsert("INSERT", c, 3)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(ids), 3)
+	test.ExpectInt(t, int(ids[2]-ids[0]), 2)
+}
+
+func TestInsertBatchUpsertSkipsIDs(t *testing.T) {
+
+	c := txClient(t, 0)
+	c.batchIDs = FirstIDBatchInsert
+
+	br, err := c.InsertBatchQIDParams("UPSERT_ARTIST", []batchRow{{"A", "Pop"}, {"B", "Rock"}})
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, br.Batches, 1)
+
+	if br.IDs != nil {
+		t.Errorf("Expected no IDs for an upsert, got %v", br.IDs)
+	}
+}
@@ -7,10 +7,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/logging"
+	"time"
 )
 
+// QueryEvent is the ID of the instrumentation event started whenever a ManagedClient created with a context sends a
+// statement to the database. The ID of the query (if the statement was built from a query template) is passed as metadata.
+const QueryEvent = "rdbms.query"
+
 // Client provides access to methods for executing SQL queries and managing transactions
 type Client interface {
 	FindFragment(qid string) (string, error)
@@ -21,6 +28,7 @@ type Client interface {
 	ExistingIDOrInsertParams(checkQueryID, insertQueryID string, idTarget *int64, p ...interface{}) error
 	InsertQIDParams(qid string, params ...interface{}) (sql.Result, error)
 	InsertCaptureQIDParams(qid string, target *int64, params ...interface{}) error
+	InsertBatchQIDParams(qid string, rows interface{}, params ...interface{}) (*BatchResult, error)
 	SelectBindSingleQID(qid string, target interface{}) (bool, error)
 	SelectBindSingleQIDParam(qid string, name string, value interface{}, target interface{}) (bool, error)
 	SelectBindSingleQIDParams(qid string, target interface{}, params ...interface{}) (bool, error)
@@ -32,6 +40,8 @@ type Client interface {
 	SelectQIDParams(qid string, params ...interface{}) (*sql.Rows, error)
 	UpdateQIDParams(qid string, params ...interface{}) (sql.Result, error)
 	UpdateQIDParam(qid string, name string, value interface{}) (sql.Result, error)
+	UpdateVersionedQIDParams(qid string, target interface{}, params ...interface{}) (sql.Result, error)
+	SoftDeleteQIDParams(qid string, model interface{}, params ...interface{}) (sql.Result, error)
 	StartTransaction() error
 	StartTransactionWithOptions(opts *sql.TxOptions) error
 	Rollback()
@@ -39,6 +49,8 @@ type Client interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
+	WithTransaction(ctx context.Context, opts *sql.TxOptions, f TransactionFunc) error
+	ContextClient
 }
 
 func newRdbmsClient(database *sql.DB, querymanager dsquery.QueryManager, insertFunc InsertWithReturnedID, logger logging.Logger) *ManagedClient {
@@ -46,6 +58,7 @@ func newRdbmsClient(database *sql.DB, querymanager dsquery.QueryManager, insertF
 	rc.db = database
 	rc.queryManager = querymanager
 	rc.lastID = insertFunc
+	rc.lastIDArgs = DefaultInsertWithReturnedIDArgs
 	rc.emptyParams = make(map[string]interface{})
 	rc.binder = new(RowBinder)
 	rc.tempQueries = make(map[string]string)
@@ -58,16 +71,34 @@ func newRdbmsClient(database *sql.DB, querymanager dsquery.QueryManager, insertF
 // ManagedClient is the interface application code should use to execute SQL against a database. See the package overview for the rdbms
 // package for usage.
 //
-// ManagedClient is stateful and MUST NOT be shared across goroutines
+// ManagedClient is stateful and MUST NOT be shared across goroutines.
+//
+// If the QueryManager in use implements dsquery.ParameterisedQueryManager and has bind parameters enabled, the values of
+// parameters are passed to the database driver as arguments rather than being inserted into the text of the query. The
+// statements prepared for each query ID are reused by all of the ManagedClients created by the same ClientManager
+// (unless ClientManagerConfig.DisableStatementReuse is set).
+//
+// If the ClientManager that created the client has read replicas, queries executed with the SelectXXX methods while no
+// transaction is open are sent to a replica. All other statements are sent to the primary database.
 type ManagedClient struct {
 	db              *sql.DB
+	readDB          *sql.DB
 	queryManager    dsquery.QueryManager
 	tx              *sql.Tx
 	lastID          InsertWithReturnedID
+	lastIDArgs      InsertWithReturnedIDArgs
+	batchIDs        BatchInsertWithReturnedIDs
+	batchSize       int
+	statements      *statementCache
 	tempQueries     map[string]string
 	emptyParams     map[string]interface{}
 	binder          *RowBinder
+	retry           *TransactionRetryPolicy
+	savepoints      int
+	stats           *QueryStats
+	slow            *SlowQueryPolicy
 	ctx             context.Context
+	execQID         string
 	FrameworkLogger logging.Logger
 }
 
@@ -116,13 +147,16 @@ func (rc *ManagedClient) RegisterTempQuery(qid string, query string) {
 // ExistingIDOrInsertParams finds the ID of record or if the record does not exist, inserts a new record and retrieves the newly assigned ID
 func (rc *ManagedClient) ExistingIDOrInsertParams(checkQueryID, insertQueryID string, idTarget *int64, p ...interface{}) error {
 
-	if found, err := rc.SelectBindSingleQIDParams(checkQueryID, idTarget, p...); err != nil {
+	// Check against the primary database so a record that has not yet reached a read replica is not inserted twice
+	w := rc.primary()
+
+	if found, err := w.SelectBindSingleQIDParams(checkQueryID, idTarget, p...); err != nil {
 		return err
 	} else if found {
 		return nil
 	} else {
 
-		if err = rc.InsertCaptureQIDParams(insertQueryID, idTarget, p...); err != nil {
+		if err = w.InsertCaptureQIDParams(insertQueryID, idTarget, p...); err != nil {
 			return err
 		}
 
@@ -142,14 +176,25 @@ func (rc *ManagedClient) InsertQIDParams(qid string, params ...interface{}) (sql
 // the new row's server generated ID in the target int64
 func (rc *ManagedClient) InsertCaptureQIDParams(qid string, target *int64, params ...interface{}) error {
 
-	var query string
-	var err error
+	query, args, pm, err := rc.buildQuery(qid, params...)
 
-	if query, err = rc.buildQuery(qid, params...); err != nil {
+	if err != nil {
 		return err
 	}
 
-	return rc.lastID(query, rc, target)
+	start := time.Now()
+
+	if len(args) == 0 {
+		err = rc.lastID(query, rc, target)
+	} else if rc.lastIDArgs == nil {
+		return fmt.Errorf("unable to insert using query %s: the DatabaseProvider's InsertIDFunc does not support bind parameters", qid)
+	} else {
+		err = rc.lastIDArgs(query, rc.forQID(qid), target, args...)
+	}
+
+	rc.observe(qid, pm, start, err)
+
+	return err
 }
 
 // SelectBindSingleQID executes the supplied query with the expectation that it is a 'SELECT' query that returns 0 or 1 rows.
@@ -168,13 +213,14 @@ func (rc *ManagedClient) SelectBindSingleQIDParam(qid string, name string, value
 }
 
 // SelectBindSingleQIDParams executes the supplied query with the expectation that it is a 'SELECT' query that returns 0 or 1 rows.
-// Results of the query are bound into the target struct. Returns false if no rows were found.
+// Results of the query are bound into the target struct. Returns false if no rows were found. If the target struct has
+// a field tagged with SoftDeleteTag, ExcludeSoftDeletedParam is set to true.
 func (rc *ManagedClient) SelectBindSingleQIDParams(qid string, target interface{}, params ...interface{}) (bool, error) {
 
 	var r *sql.Rows
 	var err error
 
-	if r, err = rc.SelectQIDParams(qid, params...); err != nil {
+	if r, err = rc.selectQIDParams(qid, target, params...); err != nil {
 		return false, err
 	}
 
@@ -200,12 +246,13 @@ func (rc *ManagedClient) SelectBindQIDParam(qid string, name string, value inter
 }
 
 // SelectBindQIDParams executes the supplied query with the expectation that it is a 'SELECT' query. Results of the query
-// are returned in a slice of the same type as the supplied template struct.
+// are returned in a slice of the same type as the supplied template struct. If the template struct has a field tagged
+// with SoftDeleteTag, ExcludeSoftDeletedParam is set to true.
 func (rc *ManagedClient) SelectBindQIDParams(qid string, template interface{}, params ...interface{}) ([]interface{}, error) {
 	var r *sql.Rows
 	var err error
 
-	if r, err = rc.SelectQIDParams(qid, params...); err != nil {
+	if r, err = rc.selectQIDParams(qid, template, params...); err != nil {
 		return nil, err
 	}
 
@@ -229,14 +276,35 @@ func (rc *ManagedClient) SelectQIDParam(qid string, name string, value interface
 
 // SelectQIDParams executes the supplied query with the expectation that it is a 'SELECT' query.
 func (rc *ManagedClient) SelectQIDParams(qid string, params ...interface{}) (*sql.Rows, error) {
+	return rc.selectQIDParams(qid, nil, params...)
+}
+
+// selectQIDParams executes the supplied query, setting ExcludeSoftDeletedParam if model is a struct with a field tagged
+// with SoftDeleteTag.
+func (rc *ManagedClient) selectQIDParams(qid string, model interface{}, params ...interface{}) (*sql.Rows, error) {
+
+	if softDeleteColumn(model) != "" {
+
+		if err := rc.checkExcludesSoftDeleted(qid, model); err != nil {
+			return nil, err
+		}
+
+		params = append([]interface{}{map[string]interface{}{ExcludeSoftDeletedParam: true}}, params...)
+	}
 
-	query, err := rc.buildQuery(qid, params...)
+	query, args, pm, err := rc.buildQuery(qid, params...)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return rc.Query(query)
+	start := time.Now()
+
+	r, err := rc.reader().query(qid, query, args...)
+
+	rc.observe(qid, pm, start, err)
+
+	return r, err
 
 }
 
@@ -259,29 +327,40 @@ func (rc *ManagedClient) UpdateQIDParam(qid string, name string, value interface
 
 func (rc *ManagedClient) execQIDParams(qid string, params ...interface{}) (sql.Result, error) {
 
-	var query string
-	var err error
+	query, args, pm, err := rc.buildQuery(qid, params...)
 
-	if query, err = rc.buildQuery(qid, params...); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return rc.Exec(query)
+	start := time.Now()
+
+	r, err := rc.exec(qid, query, args...)
+
+	rc.observe(qid, pm, start, err)
+
+	return r, err
 }
 
-func (rc *ManagedClient) buildQuery(qid string, p ...interface{}) (string, error) {
+// buildQuery returns the query associated with the supplied ID, the arguments to be passed to the driver with the
+// query (if the QueryManager binds parameters) and the parameters used to build the query.
+func (rc *ManagedClient) buildQuery(qid string, p ...interface{}) (string, []interface{}, map[string]interface{}, error) {
 
 	tq := rc.tempQueries[qid]
 
 	if tq != "" {
-		return tq, nil
+		return tq, nil, nil, nil
 	}
 
 	var pm map[string]interface{}
 	var err error
 
 	if pm, err = ParamsFromFieldsOrTags(p...); err != nil {
-		return "", err
+		return "", nil, nil, err
+	}
+
+	if err = expandStructSlices(pm); err != nil {
+		return "", nil, nil, err
 	}
 
 	if rc.FrameworkLogger.IsLevelEnabled(logging.Trace) {
@@ -289,8 +368,39 @@ func (rc *ManagedClient) buildQuery(qid string, p ...interface{}) (string, error
 		rc.FrameworkLogger.LogTracef("Parameters: %v", pm)
 	}
 
-	return rc.queryManager.BuildQueryFromID(qid, pm)
+	if pqm, found := rc.queryManager.(dsquery.ParameterisedQueryManager); found && pqm.BindsParameters() {
+		q, args, err := pqm.BuildParameterisedQueryFromID(qid, pm)
+		return q, args, pm, err
+	}
+
+	q, err := rc.queryManager.BuildQueryFromID(qid, pm)
+
+	return q, nil, pm, err
+
+}
+
+// statement returns a prepared statement for the supplied query if the query was built from a template with bind
+// parameters and statement reuse is enabled, along with a function that must be called once the statement has been
+// executed. Returns nil if the query should be passed directly to the database.
+func (rc *ManagedClient) statement(qid string, query string, args []interface{}) (*sql.Stmt, func(), error) {
+
+	if rc.statements == nil || qid == "" || len(args) == 0 {
+		return nil, nil, nil
+	}
+
+	ctx := rc.context()
+
+	s, release, err := rc.statements.prepare(ctx, rc.db, qid, query, len(args))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rc.tx != nil {
+		return rc.tx.StmtContext(ctx, s), release, nil
+	}
 
+	return s, release, nil
 }
 
 // StartTransaction opens a transaction on the underlying sql.DB object and re-maps all calls to non-transactional
@@ -314,16 +424,16 @@ func (rc *ManagedClient) StartTransaction() error {
 // StartTransactionWithOptions opens a transaction on the underlying sql.DB object and re-maps all calls to non-transactional
 // methods to their transactional equivalents.
 func (rc *ManagedClient) StartTransactionWithOptions(opts *sql.TxOptions) error {
+	return rc.startTransaction(rc.context(), opts)
+}
+
+func (rc *ManagedClient) startTransaction(ctx context.Context, opts *sql.TxOptions) error {
 
 	if rc.tx != nil {
 		return errors.New("Transaction already open")
 	}
 
-	var ctx context.Context
-
-	if rc.contextAware() {
-		ctx = rc.ctx
-	} else {
+	if ctx == nil {
 		ctx = context.Background()
 	}
 
@@ -362,10 +472,32 @@ func (rc *ManagedClient) CommitTransaction() error {
 
 // Exec is a pass-through to its sql.DB equivalent (or sql.Tx equivalent is a transaction is open)
 func (rc *ManagedClient) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return rc.exec(rc.execQID, query, args...)
+}
+
+func (rc *ManagedClient) exec(qid string, query string, args ...interface{}) (sql.Result, error) {
+	return rc.execKeyed(qid, qid, query, args...)
+}
+
+// execKeyed executes the supplied query, caching any statement prepared for it under key rather than its query ID.
+// Used when a method derives a different statement from a query's template.
+func (rc *ManagedClient) execKeyed(qid string, key string, query string, args ...interface{}) (sql.Result, error) {
 
 	tx := rc.tx
 
 	if rc.contextAware() {
+		defer instrument.Event(rc.ctx, QueryEvent, qid)()
+	}
+
+	if s, release, err := rc.statement(key, query, args); err != nil {
+		return nil, err
+	} else if s != nil {
+		defer release()
+		return s.ExecContext(rc.context(), args...)
+	}
+
+	if rc.contextAware() {
+
 		if tx != nil {
 			return tx.ExecContext(rc.ctx, query, args...)
 		}
@@ -383,9 +515,25 @@ func (rc *ManagedClient) Exec(query string, args ...interface{}) (sql.Result, er
 
 // Query is a pass-through to its sql.DB equivalent (or sql.Tx equivalent is a transaction is open)
 func (rc *ManagedClient) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return rc.query(rc.execQID, query, args...)
+}
+
+func (rc *ManagedClient) query(qid string, query string, args ...interface{}) (*sql.Rows, error) {
 	tx := rc.tx
 
 	if rc.contextAware() {
+		defer instrument.Event(rc.ctx, QueryEvent, qid)()
+	}
+
+	if s, release, err := rc.statement(qid, query, args); err != nil {
+		return nil, err
+	} else if s != nil {
+		defer release()
+		return s.QueryContext(rc.context(), args...)
+	}
+
+	if rc.contextAware() {
+
 		if tx != nil {
 			return tx.QueryContext(rc.ctx, query, args...)
 		}
@@ -406,6 +554,8 @@ func (rc *ManagedClient) QueryRow(query string, args ...interface{}) *sql.Row {
 	tx := rc.tx
 
 	if rc.contextAware() {
+		defer instrument.Event(rc.ctx, QueryEvent, "")()
+
 		if tx != nil {
 			return tx.QueryRowContext(rc.ctx, query, args...)
 		}
@@ -421,6 +571,53 @@ func (rc *ManagedClient) QueryRow(query string, args ...interface{}) *sql.Row {
 	return rc.db.QueryRow(query, args...)
 }
 
+// reader returns a copy of this client that sends queries to a read replica if one is available and no transaction is
+// open. Otherwise this client is returned.
+func (rc *ManagedClient) reader() *ManagedClient {
+
+	if rc.readDB == nil || rc.tx != nil {
+		return rc
+	}
+
+	c := *rc
+	c.db = rc.readDB
+	c.readDB = nil
+
+	return &c
+}
+
+// forQID returns a copy of this client whose Exec and Query methods treat queries as having been built from the
+// supplied query ID, so that the functions supplied by a DatabaseProvider to execute inserts reuse prepared statements.
+func (rc *ManagedClient) forQID(qid string) *ManagedClient {
+
+	c := *rc
+	c.execQID = qid
+
+	return &c
+}
+
+// primary returns a copy of this client that does not send queries to read replicas.
+func (rc *ManagedClient) primary() *ManagedClient {
+
+	if rc.readDB == nil {
+		return rc
+	}
+
+	c := *rc
+	c.readDB = nil
+
+	return &c
+}
+
 func (rc *ManagedClient) contextAware() bool {
 	return rc.ctx != nil
 }
+
+func (rc *ManagedClient) context() context.Context {
+
+	if rc.contextAware() {
+		return rc.ctx
+	}
+
+	return context.Background()
+}
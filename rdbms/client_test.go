@@ -6,12 +6,14 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/reflecttools"
 	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/types"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -735,6 +737,10 @@ func (tqm *testQueryManagerProxy) BuildQueryFromID(qid string, params map[string
 
 }
 
+func (tqm *testQueryManagerProxy) IsUpsert(qid string) bool {
+	return strings.HasPrefix(qid, "UPSERT")
+}
+
 func (tqm *testQueryManagerProxy) FragmentFromID(qid string) (string, error) {
 
 	tqm.lastQueryReturned = qid
@@ -759,6 +765,9 @@ type mockDriver struct {
 	colNames   []string
 	rowData    [][]driver.Value
 	forceError bool
+	prepared   int
+	closed     int
+	lastArgs   []driver.Value
 }
 
 func (d *mockDriver) consumed() {
@@ -783,6 +792,7 @@ type mockConn struct {
 }
 
 func (c *mockConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.prepared++
 	return newMockStmt(c.d), nil
 }
 
@@ -806,15 +816,18 @@ type mockStmt struct {
 }
 
 func (s *mockStmt) Close() error {
+	s.d.closed++
 	return nil
 }
 
 func (s *mockStmt) NumInput() int {
-	return 0
+	return -1
 }
 
 func (s *mockStmt) Exec(args []driver.Value) (driver.Result, error) {
 
+	s.d.lastArgs = args
+
 	if s.d.forceError {
 		drv.consumed()
 		return nil, errors.New("Forced error")
@@ -831,6 +844,8 @@ func (s *mockStmt) Exec(args []driver.Value) (driver.Result, error) {
 
 func (s *mockStmt) Query(args []driver.Value) (driver.Rows, error) {
 
+	s.d.lastArgs = args
+
 	if s.d.forceError {
 		drv.consumed()
 		return nil, errors.New("Forced error")
@@ -892,3 +907,42 @@ func (t *mockTx) Commit() error {
 func (t *mockTx) Rollback() error {
 	return nil
 }
+
+func TestQueryInstrumentation(t *testing.T) {
+
+	ri := new(recordingInstrumentor)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.ctx = instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	drv.consumed()
+	r, err := c.SelectQIDParams("SQ")
+	test.ExpectNil(t, err)
+	r.Close()
+
+	_, err = c.Exec("DIRECT")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, len(ri.events), 2)
+	test.ExpectString(t, ri.events[0], QueryEvent+" SQ")
+	test.ExpectString(t, ri.events[1], QueryEvent+" ")
+}
+
+type recordingInstrumentor struct {
+	events []string
+}
+
+func (ri *recordingInstrumentor) StartEvent(id string, metadata ...interface{}) instrument.EndEvent {
+	ri.events = append(ri.events, fmt.Sprintf("%s %v", id, metadata[0]))
+	return func() {}
+}
+
+func (ri *recordingInstrumentor) Fork(ctx context.Context) (context.Context, instrument.Instrumentor) {
+	return ctx, ri
+}
+
+func (ri *recordingInstrumentor) Integrate(instrumentor instrument.Instrumentor) {
+}
+
+func (ri *recordingInstrumentor) Amend(additional instrument.Additional, value interface{}) {
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ContextClient provides variants of the methods on Client that accept a context.Context. The supplied context is used
+// for that call only, in place of any context captured when the client was created with ClientManager.ClientFromContext,
+// so each query honours the context's cancellation and deadline and starts a QueryEvent instrumentation event (tagged
+// with the query ID) using any Instrumentor stored in the context.
+type ContextClient interface {
+	DeleteQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error)
+	DeleteQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (sql.Result, error)
+	ExistingIDOrInsertParamsCtx(ctx context.Context, checkQueryID, insertQueryID string, idTarget *int64, p ...interface{}) error
+	InsertQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error)
+	InsertCaptureQIDParamsCtx(ctx context.Context, qid string, target *int64, params ...interface{}) error
+	InsertBatchQIDParamsCtx(ctx context.Context, qid string, rows interface{}, params ...interface{}) (*BatchResult, error)
+	SelectBindSingleQIDCtx(ctx context.Context, qid string, target interface{}) (bool, error)
+	SelectBindSingleQIDParamCtx(ctx context.Context, qid string, name string, value interface{}, target interface{}) (bool, error)
+	SelectBindSingleQIDParamsCtx(ctx context.Context, qid string, target interface{}, params ...interface{}) (bool, error)
+	SelectBindQIDCtx(ctx context.Context, qid string, template interface{}) ([]interface{}, error)
+	SelectBindQIDParamCtx(ctx context.Context, qid string, name string, value interface{}, template interface{}) ([]interface{}, error)
+	SelectBindQIDParamsCtx(ctx context.Context, qid string, template interface{}, params ...interface{}) ([]interface{}, error)
+	SelectQIDCtx(ctx context.Context, qid string) (*sql.Rows, error)
+	SelectQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (*sql.Rows, error)
+	SelectQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (*sql.Rows, error)
+	UpdateQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error)
+	UpdateQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (sql.Result, error)
+	UpdateVersionedQIDParamsCtx(ctx context.Context, qid string, target interface{}, params ...interface{}) (sql.Result, error)
+	SoftDeleteQIDParamsCtx(ctx context.Context, qid string, model interface{}, params ...interface{}) (sql.Result, error)
+	ExecCtx(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryCtx(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowCtx(ctx context.Context, query string, args ...interface{}) *sql.Row
+	StartTransactionCtx(ctx context.Context, opts *sql.TxOptions) error
+}
+
+// DeleteQIDParamsCtx is the equivalent of DeleteQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) DeleteQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).DeleteQIDParams(qid, params...)
+}
+
+// DeleteQIDParamCtx is the equivalent of DeleteQIDParam, using the supplied context for this call only.
+func (rc *ManagedClient) DeleteQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).DeleteQIDParam(qid, name, value)
+}
+
+// ExistingIDOrInsertParamsCtx is the equivalent of ExistingIDOrInsertParams, using the supplied context for this call only.
+func (rc *ManagedClient) ExistingIDOrInsertParamsCtx(ctx context.Context, checkQueryID, insertQueryID string, idTarget *int64, p ...interface{}) error {
+	return rc.withContext(ctx).ExistingIDOrInsertParams(checkQueryID, insertQueryID, idTarget, p...)
+}
+
+// InsertQIDParamsCtx is the equivalent of InsertQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) InsertQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).InsertQIDParams(qid, params...)
+}
+
+// InsertCaptureQIDParamsCtx is the equivalent of InsertCaptureQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) InsertCaptureQIDParamsCtx(ctx context.Context, qid string, target *int64, params ...interface{}) error {
+	return rc.withContext(ctx).InsertCaptureQIDParams(qid, target, params...)
+}
+
+// InsertBatchQIDParamsCtx is the equivalent of InsertBatchQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) InsertBatchQIDParamsCtx(ctx context.Context, qid string, rows interface{}, params ...interface{}) (*BatchResult, error) {
+	return rc.withContext(ctx).InsertBatchQIDParams(qid, rows, params...)
+}
+
+// SelectBindSingleQIDCtx is the equivalent of SelectBindSingleQID, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindSingleQIDCtx(ctx context.Context, qid string, target interface{}) (bool, error) {
+	return rc.withContext(ctx).SelectBindSingleQID(qid, target)
+}
+
+// SelectBindSingleQIDParamCtx is the equivalent of SelectBindSingleQIDParam, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindSingleQIDParamCtx(ctx context.Context, qid string, name string, value interface{}, target interface{}) (bool, error) {
+	return rc.withContext(ctx).SelectBindSingleQIDParam(qid, name, value, target)
+}
+
+// SelectBindSingleQIDParamsCtx is the equivalent of SelectBindSingleQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindSingleQIDParamsCtx(ctx context.Context, qid string, target interface{}, params ...interface{}) (bool, error) {
+	return rc.withContext(ctx).SelectBindSingleQIDParams(qid, target, params...)
+}
+
+// SelectBindQIDCtx is the equivalent of SelectBindQID, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindQIDCtx(ctx context.Context, qid string, template interface{}) ([]interface{}, error) {
+	return rc.withContext(ctx).SelectBindQID(qid, template)
+}
+
+// SelectBindQIDParamCtx is the equivalent of SelectBindQIDParam, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindQIDParamCtx(ctx context.Context, qid string, name string, value interface{}, template interface{}) ([]interface{}, error) {
+	return rc.withContext(ctx).SelectBindQIDParam(qid, name, value, template)
+}
+
+// SelectBindQIDParamsCtx is the equivalent of SelectBindQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) SelectBindQIDParamsCtx(ctx context.Context, qid string, template interface{}, params ...interface{}) ([]interface{}, error) {
+	return rc.withContext(ctx).SelectBindQIDParams(qid, template, params...)
+}
+
+// SelectQIDCtx is the equivalent of SelectQID, using the supplied context for this call only.
+func (rc *ManagedClient) SelectQIDCtx(ctx context.Context, qid string) (*sql.Rows, error) {
+	return rc.withContext(ctx).SelectQID(qid)
+}
+
+// SelectQIDParamCtx is the equivalent of SelectQIDParam, using the supplied context for this call only.
+func (rc *ManagedClient) SelectQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (*sql.Rows, error) {
+	return rc.withContext(ctx).SelectQIDParam(qid, name, value)
+}
+
+// SelectQIDParamsCtx is the equivalent of SelectQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) SelectQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (*sql.Rows, error) {
+	return rc.withContext(ctx).SelectQIDParams(qid, params...)
+}
+
+// UpdateQIDParamsCtx is the equivalent of UpdateQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) UpdateQIDParamsCtx(ctx context.Context, qid string, params ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).UpdateQIDParams(qid, params...)
+}
+
+// UpdateQIDParamCtx is the equivalent of UpdateQIDParam, using the supplied context for this call only.
+func (rc *ManagedClient) UpdateQIDParamCtx(ctx context.Context, qid string, name string, value interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).UpdateQIDParam(qid, name, value)
+}
+
+// UpdateVersionedQIDParamsCtx is the equivalent of UpdateVersionedQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) UpdateVersionedQIDParamsCtx(ctx context.Context, qid string, target interface{}, params ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).UpdateVersionedQIDParams(qid, target, params...)
+}
+
+// SoftDeleteQIDParamsCtx is the equivalent of SoftDeleteQIDParams, using the supplied context for this call only.
+func (rc *ManagedClient) SoftDeleteQIDParamsCtx(ctx context.Context, qid string, model interface{}, params ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).SoftDeleteQIDParams(qid, model, params...)
+}
+
+// ExecCtx is the equivalent of Exec, using the supplied context for this call only.
+func (rc *ManagedClient) ExecCtx(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return rc.withContext(ctx).Exec(query, args...)
+}
+
+// QueryCtx is the equivalent of Query, using the supplied context for this call only.
+func (rc *ManagedClient) QueryCtx(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return rc.withContext(ctx).Query(query, args...)
+}
+
+// QueryRowCtx is the equivalent of QueryRow, using the supplied context for this call only.
+func (rc *ManagedClient) QueryRowCtx(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return rc.withContext(ctx).QueryRow(query, args...)
+}
+
+// StartTransactionCtx opens a transaction using the supplied context. The transaction is rolled back by the database
+// driver if the context is cancelled before the transaction is committed.
+func (rc *ManagedClient) StartTransactionCtx(ctx context.Context, opts *sql.TxOptions) error {
+	return rc.startTransaction(ctx, opts)
+}
+
+// withContext returns a copy of this client that shares its database connection, open transaction and query manager
+// but uses the supplied context.
+func (rc *ManagedClient) withContext(ctx context.Context) *ManagedClient {
+	c := *rc
+	c.ctx = ctx
+
+	return &c
+}
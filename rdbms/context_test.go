@@ -0,0 +1,102 @@
+package rdbms
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestImplementsContextClient(t *testing.T) {
+
+	var c Client = newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	if _, found := c.(ContextClient); !found {
+		t.Errorf("ManagedClient does not implement ContextClient")
+	}
+}
+
+func TestCtxVariantsInstrumented(t *testing.T) {
+
+	ri := new(recordingInstrumentor)
+	ctx := instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.consumed()
+	r, err := c.SelectQIDParamsCtx(ctx, "SQ")
+	test.ExpectNil(t, err)
+	r.Close()
+
+	_, err = c.DeleteQIDParamCtx(ctx, "DQ", "ID", 1)
+	test.ExpectNil(t, err)
+
+	_, err = c.ExecCtx(ctx, "DIRECT")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, len(ri.events), 3)
+	test.ExpectString(t, ri.events[0], QueryEvent+" SQ")
+	test.ExpectString(t, ri.events[1], QueryEvent+" DQ")
+	test.ExpectString(t, ri.events[2], QueryEvent+" ")
+
+	// The context should only apply to the call it was passed to
+	test.ExpectBool(t, c.contextAware(), false)
+}
+
+func TestCtxVariantsOverrideClientContext(t *testing.T) {
+
+	ri := new(recordingInstrumentor)
+	other := new(recordingInstrumentor)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.ctx = instrument.AddInstrumentorToContext(context.Background(), other)
+
+	_, err := c.UpdateQIDParamsCtx(instrument.AddInstrumentorToContext(context.Background(), ri), "UQ")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, len(ri.events), 1)
+	test.ExpectInt(t, len(other.events), 0)
+}
+
+func TestCancelledContext(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.consumed()
+	_, err := c.SelectQIDParamsCtx(ctx, "SQ")
+
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	_, err = c.ExecCtx(ctx, "DIRECT")
+
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// The client should still be usable with another context
+	_, err = c.ExecCtx(context.Background(), "DIRECT")
+	test.ExpectNil(t, err)
+}
+
+func TestStartTransactionCtx(t *testing.T) {
+
+	ri := new(recordingInstrumentor)
+	ctx := instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	test.ExpectNil(t, c.StartTransactionCtx(ctx, nil))
+	test.ExpectNotNil(t, c.StartTransactionCtx(ctx, nil))
+
+	_, err := c.InsertQIDParamsCtx(ctx, "IQ")
+	test.ExpectNil(t, err)
+
+	test.ExpectNil(t, c.CommitTransaction())
+	test.ExpectInt(t, len(ri.events), 1)
+}
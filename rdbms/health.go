@@ -0,0 +1,144 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// ConnectivityListener is implemented by components that need to be told when the periodic health check of a
+// ClientManager finds that its database has become unreachable or has become reachable again.
+type ConnectivityListener interface {
+	// DatabaseUnavailable is called when a health check fails after the previous check succeeded.
+	DatabaseUnavailable(clientName string, err error)
+
+	// DatabaseAvailable is called when a health check succeeds after the previous check failed.
+	DatabaseAvailable(clientName string)
+}
+
+// Health is the result of the most recent health check of the database used by a ClientManager.
+type Health struct {
+	// Whether or not the most recent check was able to ping the database.
+	Available bool
+
+	// The time the most recent check was made. Zero if no checks have been made.
+	Checked time.Time
+
+	// The number of consecutive failed checks.
+	ConsecutiveFailures int
+
+	// The error returned by the most recent failed check (nil if the most recent check succeeded).
+	LastError error
+}
+
+func newHealthMonitor(check func(context.Context) error, onChange func(available bool, err error)) *healthMonitor {
+	hm := new(healthMonitor)
+	hm.ping = check
+	hm.onChange = onChange
+	hm.health.Available = true
+
+	return hm
+}
+
+// healthMonitor periodically pings a database and records whether or not it is available.
+type healthMonitor struct {
+	mu       sync.Mutex
+	health   Health
+	ping     func(context.Context) error
+	onChange func(available bool, err error)
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+func (hm *healthMonitor) current() Health {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	return hm.health
+}
+
+// check pings the database, waiting no longer than the supplied timeout for a response.
+func (hm *healthMonitor) check(timeout time.Duration) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err := hm.ping(ctx)
+	cancel()
+
+	hm.mu.Lock()
+
+	changed := hm.health.Available != (err == nil)
+
+	hm.health.Available = err == nil
+	hm.health.Checked = time.Now()
+	hm.health.LastError = err
+
+	if err == nil {
+		hm.health.ConsecutiveFailures = 0
+	} else {
+		hm.health.ConsecutiveFailures++
+	}
+
+	hm.mu.Unlock()
+
+	if changed && hm.onChange != nil {
+		hm.onChange(err == nil, err)
+	}
+}
+
+// monitor checks the database at the supplied interval until stopMonitoring is called.
+func (hm *healthMonitor) monitor(interval time.Duration) {
+
+	hm.stop = make(chan struct{})
+	hm.stopped.Add(1)
+
+	go func() {
+		defer hm.stopped.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-hm.stop:
+				return
+			case <-t.C:
+				hm.check(interval)
+			}
+		}
+	}()
+}
+
+func (hm *healthMonitor) stopMonitoring() {
+
+	if hm.stop == nil {
+		return
+	}
+
+	close(hm.stop)
+	hm.stopped.Wait()
+	hm.stop = nil
+}
+
+// configurePool applies the connection pool settings in the supplied configuration to a sql.DB.
+func configurePool(db *sql.DB, conf *ClientManagerConfig) {
+
+	if conf.MaxOpenConnections != 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConnections)
+	}
+
+	if conf.MaxIdleConnections != 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConnections)
+	}
+
+	if conf.ConnMaxLifetimeMS != 0 {
+		db.SetConnMaxLifetime(time.Duration(conf.ConnMaxLifetimeMS) * time.Millisecond)
+	}
+
+	if conf.ConnMaxIdleTimeMS != 0 {
+		db.SetConnMaxIdleTime(time.Duration(conf.ConnMaxIdleTimeMS) * time.Millisecond)
+	}
+}
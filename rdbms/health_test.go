@@ -0,0 +1,110 @@
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+
+	var pingErr error
+	var changes []bool
+
+	hm := newHealthMonitor(func(ctx context.Context) error { return pingErr }, func(available bool, err error) {
+		changes = append(changes, available)
+	})
+
+	test.ExpectBool(t, hm.current().Available, true)
+	test.ExpectBool(t, hm.current().Checked.IsZero(), true)
+
+	hm.check(time.Second)
+	test.ExpectInt(t, len(changes), 0)
+
+	pingErr = errors.New("unreachable")
+
+	hm.check(time.Second)
+	hm.check(time.Second)
+
+	h := hm.current()
+
+	test.ExpectBool(t, h.Available, false)
+	test.ExpectInt(t, h.ConsecutiveFailures, 2)
+	test.ExpectNotNil(t, h.LastError)
+
+	pingErr = nil
+	hm.check(time.Second)
+
+	test.ExpectInt(t, hm.current().ConsecutiveFailures, 0)
+	test.ExpectInt(t, len(changes), 2)
+	test.ExpectBool(t, changes[0], false)
+	test.ExpectBool(t, changes[1], true)
+}
+
+func TestManagerHealthCheck(t *testing.T) {
+
+	p := &replicaProvider{db: db}
+	l := new(recordingListener)
+
+	m := new(GraniticRdbmsClientManager)
+	m.SharedLog = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	m.Configuration = &ClientManagerConfig{
+		ClientName:            "testClient",
+		Provider:              p,
+		HealthCheckIntervalMS: 60000,
+		ConnectivityListeners: []ConnectivityListener{l},
+	}
+
+	test.ExpectNil(t, m.StartComponent())
+
+	p.err = errors.New("unreachable")
+	m.health.check(time.Second)
+
+	test.ExpectBool(t, m.Health().Available, false)
+
+	p.err = nil
+	m.health.check(time.Second)
+
+	test.ExpectNil(t, m.Stop())
+
+	test.ExpectBool(t, m.Health().Available, true)
+	test.ExpectString(t, l.events, "-testClient +testClient ")
+}
+
+func TestPoolConfiguration(t *testing.T) {
+
+	pooled, _ := sql.Open("grnc-mock", "pooled")
+
+	m := new(GraniticRdbmsClientManager)
+	m.SharedLog = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	m.Configuration = &ClientManagerConfig{
+		Provider:           &replicaProvider{db: pooled},
+		MaxOpenConnections: 7,
+		ConnMaxLifetimeMS:  1000,
+	}
+
+	test.ExpectNil(t, m.StartComponent())
+
+	s, err := m.PoolStats()
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, s.MaxOpenConnections, 7)
+
+	// Health checks are disabled, so the database is always reported as available
+	test.ExpectBool(t, m.Health().Available, true)
+}
+
+type recordingListener struct {
+	events string
+}
+
+func (rl *recordingListener) DatabaseUnavailable(clientName string, err error) {
+	rl.events += "-" + clientName + " "
+}
+
+func (rl *recordingListener) DatabaseAvailable(clientName string) {
+	rl.events += "+" + clientName + " "
+}
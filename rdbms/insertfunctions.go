@@ -3,19 +3,31 @@
 
 package rdbms
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+)
 
 // InsertWithReturnedID is a function able execute an insert statement and return an RDBMS generated ID as an int64.
 // If your implementation requires access to the context, it is available on the *ManagedClient
 type InsertWithReturnedID func(string, Client, *int64) error
 
+// InsertWithReturnedIDArgs is a function able to execute an insert statement with bind arguments and return an RDBMS
+// generated ID as an int64. It is used instead of InsertWithReturnedID when the QueryManager binds parameters.
+type InsertWithReturnedIDArgs func(string, Client, *int64, ...interface{}) error
+
 // DefaultInsertWithReturnedID is an implementation of InsertWithReturnedID that will work with any Go database driver that implements LastInsertId
 func DefaultInsertWithReturnedID(query string, client Client, target *int64) error {
+	return DefaultInsertWithReturnedIDArgs(query, client, target)
+}
+
+// DefaultInsertWithReturnedIDArgs is an implementation of InsertWithReturnedIDArgs that will work with any Go database driver that implements LastInsertId
+func DefaultInsertWithReturnedIDArgs(query string, client Client, target *int64, args ...interface{}) error {
 	var r sql.Result
 	var err error
 	var id int64
 
-	if r, err = client.Exec(query); err != nil {
+	if r, err = client.Exec(query, args...); err != nil {
 		return err
 	}
 
@@ -27,3 +39,60 @@ func DefaultInsertWithReturnedID(query string, client Client, target *int64) err
 
 	return nil
 }
+
+// BatchInsertWithReturnedIDs is a function able to execute an insert statement that creates multiple rows (with or
+// without bind arguments) and return the RDBMS generated ID of each row, in the order the rows were supplied.
+type BatchInsertWithReturnedIDs func(query string, client Client, rows int, args ...interface{}) ([]int64, error)
+
+// FirstIDBatchInsert is an implementation of BatchInsertWithReturnedIDs for drivers where LastInsertId returns the ID
+// generated for the first row of a multi-row insert and IDs are allocated consecutively (for example MySQL with an
+// innodb_autoinc_lock_mode of 0 or 1). It is not suitable for upserts, as rows that are updated do not consume IDs, so
+// ManagedClient.InsertBatchQIDParams does not capture IDs for queries the QueryManager reports as upserts.
+func FirstIDBatchInsert(query string, client Client, rows int, args ...interface{}) ([]int64, error) {
+	var r sql.Result
+	var err error
+	var first int64
+
+	if r, err = client.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	if first, err = r.LastInsertId(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, rows)
+
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+
+	return ids, nil
+}
+
+// ReturningBatchInsert is an implementation of BatchInsertWithReturnedIDs for databases that support a RETURNING clause
+// (for example PostgreSQL and SQLite 3.35 and later). The query must end with RETURNING and the name of the ID column.
+func ReturningBatchInsert(query string, client Client, rows int, args ...interface{}) ([]int64, error) {
+	var r *sql.Rows
+	var err error
+
+	if r, err = client.Query(query, args...); err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	ids := make([]int64, 0, rows)
+
+	for r.Next() {
+		var id int64
+
+		if err = r.Scan(&id); err != nil {
+			return nil, fmt.Errorf("unable to read a generated ID (does the query end with RETURNING and an ID column?): %s", err.Error())
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, r.Err()
+}
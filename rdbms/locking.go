@@ -0,0 +1,259 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/reflecttools"
+	"reflect"
+	"time"
+)
+
+const (
+	// VersionTag is the name of a Go tag on an integer struct field that holds the version of a row used for optimistic
+	// locking. The value of the tag is the name of the column holding the version.
+	VersionTag = "dbversion"
+
+	// SoftDeleteTag is the name of a Go tag on a struct field that marks a row as soft-deleted. The value of the tag is the
+	// name of the column holding the time the row was deleted, which is NULL for rows that have not been deleted.
+	SoftDeleteTag = "dbsoftdelete"
+
+	// ExcludeSoftDeletedParam is the name of the parameter set to true when a query's results are bound into a type with
+	// a field tagged with SoftDeleteTag. Templates must exclude soft-deleted rows by placing a condition in a section that
+	// is only included when this parameter is set.
+	ExcludeSoftDeletedParam = "excludeSoftDeleted"
+)
+
+// Suffixes added to a query ID so that the statements derived from its template by UpdateVersionedQIDParams and
+// SoftDeleteQIDParams are prepared and cached separately from the statement built from the template itself.
+const (
+	versionedStatementSuffix  = "#versioned"
+	softDeleteStatementSuffix = "#softdelete"
+)
+
+// ConcurrentModificationError is returned by UpdateVersionedQIDParams when no rows were updated because the version of
+// the row no longer matches the version that was read (or the row has been deleted).
+type ConcurrentModificationError struct {
+	// The ID of the query that updated no rows.
+	QueryID string
+
+	// The version the row was expected to have.
+	Version int64
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("query %s updated no rows: the row has been modified or deleted since version %d was read", e.QueryID, e.Version)
+}
+
+// UpdateVersionedQIDParams executes the supplied UPDATE query with optimistic locking. target must be a pointer to a
+// struct with an integer field tagged with VersionTag. The query is built with target and any other params as its
+// parameters, then the assignment 'version = version + 1' is added to its SET clause and its WHERE clause is
+// restricted to rows whose version matches the value of the tagged field (bound as an argument if the QueryManager
+// binds parameters). The template should not refer to the version column itself, e.g.
+//
+//	UPDATE artist SET name = ${Name} WHERE id = ${ID}
+//
+// If no rows are updated, a *ConcurrentModificationError is returned. Otherwise the tagged field is incremented.
+func (rc *ManagedClient) UpdateVersionedQIDParams(qid string, target interface{}, params ...interface{}) (sql.Result, error) {
+
+	field, column, err := versionField(target)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute query %s: %s", qid, err.Error())
+	}
+
+	version := versionValue(field)
+
+	query, args, pm, err := rc.buildQuery(qid, append([]interface{}{target}, params...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if query, err = addAssignment(query, fmt.Sprintf("%s = %s + 1", column, column)); err != nil {
+		return nil, fmt.Errorf("unable to add a version check to query %s: %s", qid, err.Error())
+	}
+
+	query, args = rc.versionCheck(query, args, column, version)
+
+	start := time.Now()
+
+	r, err := rc.execKeyed(qid, qid+versionedStatementSuffix, query, args...)
+
+	rc.observe(qid, pm, start, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := r.RowsAffected()
+
+	if err != nil {
+		return r, err
+	}
+
+	if affected == 0 {
+		return r, &ConcurrentModificationError{QueryID: qid, Version: version}
+	}
+
+	setVersionValue(field, version+1)
+
+	return r, nil
+}
+
+// versionCheck restricts the WHERE clause of the supplied query to rows with the supplied version. If the query has
+// bind arguments, the version is bound as an additional argument in the position of its placeholder. Otherwise it is
+// written into the query.
+func (rc *ManagedClient) versionCheck(query string, args []interface{}, column string, version int64) (string, []interface{}) {
+
+	if len(args) == 0 {
+		return addPredicate(query, fmt.Sprintf("%s = %d", column, version)), args
+	}
+
+	ps := dsquery.QuestionMarkPlaceholder
+
+	if pr, found := rc.queryManager.(dsquery.PlaceholderReporter); found {
+		ps = pr.Placeholders()
+	}
+
+	query, at := insertPredicate(query, fmt.Sprintf("%s = %s", column, ps.Placeholder(len(args)+1)))
+
+	if ps.Numbered() {
+		return query, append(args, version)
+	}
+
+	// Unnumbered placeholders are bound in the order they appear
+	i := countPlaceholders(query[:at])
+
+	a := make([]interface{}, 0, len(args)+1)
+	a = append(a, args[:i]...)
+	a = append(a, version)
+	a = append(a, args[i:]...)
+
+	return query, a
+}
+
+// SoftDeleteQIDParams executes the supplied DELETE query as a soft-delete. model must be a struct (or pointer to a
+// struct) with a field tagged with SoftDeleteTag. The query is built with model and any other params as its
+// parameters, then converted into an UPDATE that sets the tagged column to CURRENT_TIMESTAMP for matching rows that
+// have not already been deleted. The query must be of the form 'DELETE FROM table [WHERE ...]'.
+func (rc *ManagedClient) SoftDeleteQIDParams(qid string, model interface{}, params ...interface{}) (sql.Result, error) {
+
+	column := softDeleteColumn(model)
+
+	if column == "" {
+		return nil, fmt.Errorf("unable to execute query %s: %T does not have a field with a %s tag", qid, model, SoftDeleteTag)
+	}
+
+	query, args, pm, err := rc.buildQuery(qid, append([]interface{}{model}, params...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if query, err = softDeleteStatement(query, column); err != nil {
+		return nil, fmt.Errorf("unable to convert query %s to a soft-delete: %s", qid, err.Error())
+	}
+
+	start := time.Now()
+
+	r, err := rc.execKeyed(qid, qid+softDeleteStatementSuffix, query, args...)
+
+	rc.observe(qid, pm, start, err)
+
+	return r, err
+}
+
+// checkExcludesSoftDeleted returns an error if the template for the supplied query does not refer to
+// ExcludeSoftDeletedParam, so would return soft-deleted rows to be bound into model. Queries registered with
+// RegisterTempQuery and QueryManagers that cannot report the parameters of a template are not checked.
+func (rc *ManagedClient) checkExcludesSoftDeleted(qid string, model interface{}) error {
+
+	pi, found := rc.queryManager.(dsquery.ParameterInspector)
+
+	if !found || rc.tempQueries[qid] != "" || pi.UsesParameter(qid, ExcludeSoftDeletedParam) {
+		return nil
+	}
+
+	return fmt.Errorf("query %s cannot be bound into %T: %T has a field tagged with %s but the query template does not refer to the %s parameter, so would not exclude soft-deleted rows",
+		qid, model, model, SoftDeleteTag, ExcludeSoftDeletedParam)
+}
+
+// versionField finds the field tagged with VersionTag on the struct pointed to by target.
+func versionField(target interface{}) (reflect.Value, string, error) {
+
+	if !reflecttools.IsPointerToStruct(target) {
+		return reflect.Value{}, "", fmt.Errorf("target must be a pointer to a struct (is %T)", target)
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		column := f.Tag.Get(VersionTag)
+
+		if column == "" {
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Field(i), column, nil
+		default:
+			return reflect.Value{}, "", fmt.Errorf("field %s tagged with %s must be an integer (is %s)", f.Name, VersionTag, f.Type)
+		}
+	}
+
+	return reflect.Value{}, "", fmt.Errorf("%T does not have a field with a %s tag", target, VersionTag)
+}
+
+func versionValue(f reflect.Value) int64 {
+
+	if f.CanInt() {
+		return f.Int()
+	}
+
+	return int64(f.Uint())
+}
+
+func setVersionValue(f reflect.Value, version int64) {
+
+	if f.CanInt() {
+		f.SetInt(version)
+	} else {
+		f.SetUint(uint64(version))
+	}
+}
+
+// softDeleteColumn returns the value of the SoftDeleteTag on the supplied struct (or pointer to a struct), or an
+// empty string if the struct does not have a tagged field.
+func softDeleteColumn(model interface{}) string {
+
+	if model == nil {
+		return ""
+	}
+
+	t := reflect.TypeOf(model)
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if column := t.Field(i).Tag.Get(SoftDeleteTag); column != "" {
+			return column
+		}
+	}
+
+	return ""
+}
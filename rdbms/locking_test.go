@@ -0,0 +1,184 @@
+package rdbms
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"testing"
+	"time"
+)
+
+type versionedArtist struct {
+	ID      int64
+	Name    string
+	Version int32 `dbversion:"version"`
+}
+
+type deletableArtist struct {
+	ID      int64
+	Name    string
+	Deleted *time.Time `dbsoftdelete:"deleted_at"`
+}
+
+func TestUpdateVersioned(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	a := &versionedArtist{ID: 1, Name: "Blur", Version: 4}
+
+	qm.reset()
+
+	update := "UPDATE artist SET name = 'x' WHERE id = 1"
+
+	_, err := c.UpdateVersionedQIDParams(update, a)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, txDrv.log(), "UPDATE artist SET version = version + 1, name = 'x' WHERE (id = 1) AND version = 4")
+	test.ExpectInt(t, int(a.Version), 5)
+	test.ExpectString(t, qm.lastParams["Name"].(string), "Blur")
+
+	txDrv.unaffected = true
+
+	_, err = c.UpdateVersionedQIDParams(update, a)
+
+	var cme *ConcurrentModificationError
+
+	if !errors.As(err, &cme) {
+		t.Fatalf("Expected a ConcurrentModificationError, got %v", err)
+	}
+
+	test.ExpectInt(t, int(cme.Version), 5)
+	test.ExpectInt(t, int(a.Version), 5)
+
+	_, err = c.UpdateVersionedQIDParams("UPDATE artist SET name = 'x'", versionedArtist{})
+	test.ExpectNotNil(t, err)
+
+	_, err = c.UpdateVersionedQIDParams("UPDATE artist SET name = 'x'", &deletableArtist{})
+	test.ExpectNotNil(t, err)
+}
+
+func TestUpdateVersionedBindsVersion(t *testing.T) {
+
+	c := txClient(t, 0)
+	c.queryManager = new(bindingQueryManager)
+
+	a := &versionedArtist{ID: 1, Name: "Blur", Version: 4}
+
+	_, err := c.UpdateVersionedQIDParams("UPDATE artist SET name = 'x'", a, map[string]interface{}{"name": "Blur"})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, txDrv.log(), "UPDATE artist SET version = version + 1, name = 'x' WHERE (name = ?) AND version = ?")
+	test.ExpectInt(t, len(txDrv.lastArgs), 2)
+	test.ExpectString(t, txDrv.lastArgs[0].(string), "Blur")
+	test.ExpectInt(t, int(txDrv.lastArgs[1].(int64)), 4)
+
+	// Unnumbered placeholders are bound in the order they appear
+	q, args := c.versionCheck("UPDATE a SET x = ? WHERE id = ? ORDER BY id LIMIT ?", []interface{}{1, 2, 3}, "version", 4)
+	test.ExpectString(t, q, "UPDATE a SET x = ? WHERE (id = ?) AND version = ?\nORDER BY id LIMIT ?")
+	test.ExpectString(t, fmt.Sprint(args), "[1 2 4 3]")
+
+	c.queryManager = &bindingQueryManager{placeholders: dsquery.DollarPlaceholder}
+
+	q, args = c.versionCheck("UPDATE a SET x = $1 WHERE id = $2 LIMIT $3", []interface{}{1, 2, 3}, "version", 4)
+	test.ExpectString(t, q, "UPDATE a SET x = $1 WHERE (id = $2) AND version = $4\nLIMIT $3")
+	test.ExpectString(t, fmt.Sprint(args), "[1 2 3 4]")
+}
+
+func TestDerivedStatementsCachedSeparately(t *testing.T) {
+
+	sc := newStatementCache()
+
+	c := newRdbmsClient(db, new(bindingQueryManager), DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.statements = sc
+
+	drv.prepared = 0
+
+	for i := 0; i < 2; i++ {
+		_, err := c.UpdateQIDParams("UPDATE artist SET name = 'x'", map[string]interface{}{"name": "Blur"})
+		test.ExpectNil(t, err)
+
+		_, err = c.UpdateVersionedQIDParams("UPDATE artist SET name = 'x'", &versionedArtist{}, map[string]interface{}{"name": "Blur"})
+		test.ExpectNil(t, err)
+
+		_, err = c.SoftDeleteQIDParams("DELETE FROM artist", &deletableArtist{}, map[string]interface{}{"name": "Blur"})
+		test.ExpectNil(t, err)
+
+		_, err = c.DeleteQIDParams("DELETE FROM artist", map[string]interface{}{"name": "Blur"})
+		test.ExpectNil(t, err)
+	}
+
+	test.ExpectInt(t, sc.size(), 4)
+	test.ExpectInt(t, drv.prepared, 4)
+}
+
+func TestSoftDelete(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	_, err := c.SoftDeleteQIDParams("DELETE FROM artist WHERE id = 1", &deletableArtist{ID: 1})
+	test.ExpectNil(t, err)
+	test.ExpectString(t, txDrv.log(), "UPDATE artist SET deleted_at = CURRENT_TIMESTAMP\nWHERE (id = 1) AND deleted_at IS NULL")
+
+	_, err = c.SoftDeleteQIDParams("DELETE FROM artist WHERE id = 1", &versionedArtist{ID: 1})
+	test.ExpectNotNil(t, err)
+}
+
+func TestSoftDeletedRowsFiltered(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	txDrv.columns = []string{"ID", "Name"}
+	txDrv.rows = [][]driver.Value{{int64(1), "Blur"}}
+
+	excluded := func() bool {
+		v, found := qm.lastParams[ExcludeSoftDeletedParam]
+		return found && v.(bool)
+	}
+
+	_, err := c.SelectBindQIDParams("SELECT ID, Name FROM artist", new(deletableArtist))
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, excluded(), true)
+
+	_, err = c.SelectBindSingleQIDParams("SELECT ID, Name FROM artist WHERE id = 1", new(deletableArtist), map[string]interface{}{"id": 1})
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, excluded(), true)
+	test.ExpectInt(t, qm.lastParams["id"].(int), 1)
+
+	_, err = SelectAll[deletableArtist](c, "SELECT ID, Name FROM artist ORDER BY name")
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, excluded(), true)
+
+	// Types without a soft-delete field are not filtered
+	_, err = SelectAll[versionedArtist](c, "SELECT ID, Name FROM artist")
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, excluded(), false)
+
+	// Templates that do not refer to the parameter are rejected
+	c.queryManager = new(inspectingQueryManager)
+
+	_, err = c.SelectBindQIDParams("SELECT ID, Name FROM artist", new(deletableArtist))
+	test.ExpectNotNil(t, err)
+
+	_, err = c.SelectBindQIDParams("SELECT ID, Name FROM artist", new(versionedArtist))
+	test.ExpectNil(t, err)
+
+	c.queryManager = qm
+
+	// Queries are not rewritten
+	test.ExpectString(t, txDrv.log(), "SELECT ID, Name FROM artist,"+
+		"SELECT ID, Name FROM artist WHERE id = 1,"+
+		"SELECT ID, Name FROM artist ORDER BY name,"+
+		"SELECT ID, Name FROM artist,"+
+		"SELECT ID, Name FROM artist")
+}
+
+// inspectingQueryManager reports that a template refers to a parameter if the template's ID contains the parameter's name
+type inspectingQueryManager struct {
+	testQueryManagerProxy
+}
+
+func (iqm *inspectingQueryManager) UsesParameter(qid string, name string) bool {
+	return strings.Contains(qid, name)
+}
@@ -145,6 +145,77 @@ If the method contains the word Bind (not BindSingle), you will supply an exampl
 	}
 
 
+Typed results
+
+The generic functions SelectAll, SelectOne and SelectIter execute a query with a Client and bind the results into
+values of the type you specify, so no type assertions are needed:
+
+	artists, err := rdbms.SelectAll[ArtistSearchResult](rc, "ARTIST_SEARCH_BASE", params)
+
+	detail, err := rdbms.SelectOne[ArtistDetail](rc, "ARTIST_DETAIL", rid)
+
+SelectIter returns a Rows that binds each row as it is read, which avoids holding a large result set in memory.
+
+
+Batch inserts and upserts
+
+InsertBatchQIDParams inserts a slice of structs or maps with a multi-row INSERT. The rows are split into batches of
+ClientManagerConfig.BatchSize rows, each available to the query template as the parameter BatchRowsParam:
+
+	ID:ARTIST_UPSERT
+
+	INSERT INTO artist (id, name, genre) VALUES
+	--#range !rows ,
+	    (${id}, ${name}, ${genre})
+	--#end
+	--#upsert id name,genre
+
+	result, err := rc.InsertBatchQIDParams("ARTIST_UPSERT", artists)
+
+The upsert directive (see the dsquery package) makes the statement update rows that already exist. The returned
+BatchResult includes the number of rows affected and, if the DatabaseProvider implements NonStandardBatchInsertProvider,
+the generated ID of each row.
+
+
+Optimistic locking and soft deletes
+
+UpdateVersionedQIDParams executes an UPDATE query with a version check. The struct passed to it must have an integer
+field tagged with VersionTag, naming the column that holds the row's version:
+
+	type Artist struct {
+	  ID      int64
+	  Name    string
+	  Version int64 `dbversion:"version"`
+	}
+
+	_, err := rc.UpdateVersionedQIDParams("ARTIST_UPDATE", artist)
+
+The assignment 'version = version + 1' is added to the query's SET clause and its WHERE clause is restricted to rows
+with the struct's current version, so the template does not refer to the version column:
+
+	ID:ARTIST_UPDATE
+	UPDATE artist SET name = ${Name} WHERE id = ${ID}
+
+If no rows are updated, a *ConcurrentModificationError is returned. Otherwise the struct's version field is incremented.
+
+SoftDeleteQIDParams converts a 'DELETE FROM table WHERE ...' query into an UPDATE that sets the column named by a field
+tagged with SoftDeleteTag to CURRENT_TIMESTAMP. The SelectBindXXX methods and the SelectAll, SelectOne and SelectIter
+functions set the parameter ExcludeSoftDeletedParam to true if the type the results are bound into has a field tagged
+with SoftDeleteTag. Templates are not modified, so they must exclude soft-deleted rows themselves by placing a condition
+naming the table (or its alias) in a section that is only included when the parameter is set. If the QueryManager can
+report a template's parameters (as the QueryManager facility's can), a template that does not refer to the parameter is
+rejected with an error rather than returning soft-deleted rows:
+
+	ID:ARTIST_SEARCH
+	SELECT a.id, a.name FROM artist a JOIN genre g ON a.genre_id = g.id
+	WHERE g.name = ${genre}
+	--#if excludeSoftDeleted
+	AND a.deleted_at IS NULL
+	--#end
+
+Use SelectQIDParams (or a type without the tag) to include soft-deleted rows.
+
+
 Transactions
 
 To call start a transaction, invoke the StartTransaction method on the RDBMSCLient like:
@@ -159,6 +230,36 @@ and end your method with:
 
 The deferred Rollback call will do nothing if the transaction has previously been commited.
 
+Alternatively, pass a function to WithTransaction, which commits the transaction if the function returns nil and rolls
+it back if the function returns an error or panics:
+
+	err := db.WithTransaction(ctx, nil, func(tc rdbms.Client) error {
+	  if _, err := tc.UpdateQIDParams("DEBIT_ACCOUNT", params); err != nil {
+	    return err
+	  }
+
+	  _, err := tc.UpdateQIDParams("CREDIT_ACCOUNT", params)
+	  return err
+	})
+
+If the transaction fails because of a serialization failure or deadlock, the function is called again in a new
+transaction, up to ClientManagerConfig.TransactionRetries times, with a backoff between attempts. Calling
+WithTransaction on a Client that already has an open transaction creates a savepoint, so an error in the nested
+function only rolls back the statements it executed.
+
+
+Contexts
+
+Every method on Client has a variant with the suffix Ctx that accepts a context.Context as its first argument (see
+ContextClient). The context is used for that call only, so each query honours the cancellation and deadline of
+the context and starts a QueryEvent instrumentation event, tagged with the query's ID, using any instrument.Instrumentor
+stored in the context:
+
+	rows, err := rc.SelectBindQIDParamsCtx(ctx, "ARTIST_SEARCH_BASE", ar, params)
+
+A context passed to StartTransactionCtx is used to start the transaction. Contexts supplied to ClientManager.ClientFromContext
+are used by the methods without the Ctx suffix.
+
 
 Direct access to Go DB methods
 
@@ -166,19 +267,32 @@ ManagedClient provides pass-through access to sql.DB's Exec, Query and QueryRow
 with Granitic's transaction pattern as described above.
 
 
-Multiple databases
+Query statistics and slow queries
 
-This iteration of Granitic is optimised for the most common use-case for RDBMS access, where a particular Granitic
-application will access a single logical database. It is fully acknowledged that there are many situations where an application
-needs to access mutiple logical databases.
+Each execution of a query with the XXXQIDXXX methods is recorded in a QueryStats shared by all of the ManagedClients
+created by the same ClientManager (unless ClientManagerConfig.DisableQueryStats is set). The number of executions, the
+number of errors and the distribution of execution times are recorded for each query ID.
 
-Facility support for that use-case will be added in later versions of Granitic, but for now you have two options:
+If ClientManagerConfig.SlowQueryThresholdMS is set, any query taking at least that long is logged at WARN with its
+query ID and duration. If LogSlowQueryParameters is true, the parameters supplied to the query are also logged, with
+the values of any parameters named in RedactParameters replaced with RedactedValue.
 
-Option 1: use this facility to provide support for your application's 'main' database and manually add components of type rdbms.DefaultRDBMSClientManager to
-your component definition file to support your other database.
 
-Option 2: disable this facility and manually add components of type rdbms.GraniticRdbmsClientManager to
-your component definition file to support all of your databases.
+Multiple databases and read replicas
+
+The RdbmsAccess facility can create a separate ClientManager for each of the databases your application needs to
+access, each with its own DatabaseProvider, QueryManager and injected field names. A database can also have one or
+more read replicas. Queries executed with the SelectXXX methods of ManagedClient outside of a transaction are then sent
+to a healthy replica, with all other statements sent to the primary database. See the package documentation for
+facility/rdbms for details.
+
+
+Testing
+
+The test/rdbmstest package provides a ClientManager and DatabaseProvider for unit testing components that use this
+package. Statements are not sent to a real database - the query ID and parameters of each statement are recorded and
+results scripted by your test are returned.
+
 */
 package rdbms
 
@@ -189,6 +303,8 @@ import (
 	"github.com/graniticio/granitic/v2/dsquery"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
+	"sync"
+	"time"
 )
 
 /*
@@ -206,6 +322,18 @@ type NonStandardInsertProvider interface {
 	InsertIDFunc() InsertWithReturnedID
 }
 
+// NonStandardParameterisedInsertProvider is an optional interface for DatabaseProvider implementations that implement
+// NonStandardInsertProvider and are also able to capture the last inserted ID when the QueryManager binds parameters.
+type NonStandardParameterisedInsertProvider interface {
+	InsertIDArgsFunc() InsertWithReturnedIDArgs
+}
+
+// NonStandardBatchInsertProvider is an optional interface for DatabaseProvider implementations that are able to capture
+// the IDs generated by an insert statement that creates multiple rows (see ManagedClient.InsertBatchQIDParams).
+type NonStandardBatchInsertProvider interface {
+	BatchInsertIDFunc() BatchInsertWithReturnedIDs
+}
+
 /*
 ContextAwareDatabaseProvider is implemented by DatabaseProvider implementations that need to be given a context when establishing a database connection
 */
@@ -245,6 +373,83 @@ type ClientManagerConfig struct {
 
 	// Name that will be given to the ClientManager component that will be created. If not set, it will be set the value of ClientName + "Manager"
 	ManagerName string
+
+	// If true, statements prepared for queries built with bind parameters will be closed after use rather than
+	// being reused by subsequent ManagedClients.
+	DisableStatementReuse bool
+
+	// DatabaseProviders for read replicas of the database. If set, queries executed with the SelectXXX methods of
+	// ManagedClient outside of a transaction are sent to a healthy replica. All other statements are sent to Provider.
+	ReplicaProviders []DatabaseProvider
+
+	// How often (in milliseconds) the health of each replica is checked. If not set, DefaultReplicaCheckInterval is used.
+	ReplicaCheckIntervalMS int
+
+	// The name of the DatabaseProvider component to inject into Provider. Used by the RdbmsAccess facility.
+	ProviderName string
+
+	// The names of the DatabaseProvider components to use as ReplicaProviders. Used by the RdbmsAccess facility.
+	ReplicaProviderNames []string
+
+	// The name of the QueryManager component to use. Used by the RdbmsAccess facility - if not set the QueryManager
+	// created by the QueryManager facility is used.
+	QueryManagerName string
+
+	// If true, columns with snake_case names are bound to struct fields with CamelCase names (see RowBinder.SnakeCaseColumns).
+	SnakeCaseColumns bool
+
+	// The maximum number of open connections to the database (see sql.DB.SetMaxOpenConns). Zero means the pool's
+	// setting is not changed.
+	MaxOpenConnections int
+
+	// The maximum number of idle connections to the database (see sql.DB.SetMaxIdleConns). Zero means the pool's
+	// setting is not changed and a negative value means no idle connections are retained.
+	MaxIdleConnections int
+
+	// The maximum time in milliseconds a connection may be reused (see sql.DB.SetConnMaxLifetime). Zero means the pool's
+	// setting is not changed.
+	ConnMaxLifetimeMS int
+
+	// The maximum time in milliseconds a connection may be idle (see sql.DB.SetConnMaxIdleTime). Zero means the pool's
+	// setting is not changed.
+	ConnMaxIdleTimeMS int
+
+	// How often (in milliseconds) the database is pinged to check that it is still reachable. Zero disables the check.
+	HealthCheckIntervalMS int
+
+	// Components to notify when the health check finds that the database has become unreachable or reachable again.
+	ConnectivityListeners []ConnectivityListener
+
+	// If true, the RdbmsAccess facility suspends the HTTP server (if the HTTPServer facility is enabled) while the health
+	// check finds that the database is unreachable. Requires HealthCheckIntervalMS to be set.
+	SuspendHTTPServerWhenUnavailable bool
+
+	// The maximum number of times ManagedClient.WithTransaction retries a transaction that failed because of a
+	// serialization failure or deadlock. Zero disables retries.
+	TransactionRetries int
+
+	// How long (in milliseconds) to wait before retrying a failed transaction. The wait is doubled for each subsequent retry.
+	TransactionRetryBackoffMS int
+
+	// The longest time (in milliseconds) to wait between retries of a failed transaction. Zero means no limit.
+	TransactionRetryMaxBackoffMS int
+
+	// If true, the number of executions, errors and execution times of each query are not recorded (see QueryStats).
+	DisableQueryStats bool
+
+	// Queries taking at least this many milliseconds are logged at WARN. Zero disables logging of slow queries.
+	SlowQueryThresholdMS int
+
+	// If true, the parameters supplied to a slow query are included in the log message.
+	LogSlowQueryParameters bool
+
+	// The names of parameters (case insensitive) whose values are replaced with RedactedValue when the parameters of a
+	// slow query are logged.
+	RedactParameters []string
+
+	// The maximum number of rows inserted by each statement executed by ManagedClient.InsertBatchQIDParams. If not set,
+	// DefaultBatchSize is used.
+	BatchSize int
 }
 
 /*
@@ -267,7 +472,13 @@ type GraniticRdbmsClientManager struct {
 
 	SharedLog logging.Logger
 
-	state ioc.ComponentState
+	state      ioc.ComponentState
+	statements *statementCache
+	stats      *QueryStats
+	replicas   *replicaSet
+	health     *healthMonitor
+	poolMutex  sync.Mutex
+	pooled     map[*sql.DB]bool
 }
 
 // BlockAccess returns true if BlockUntilConnected is set to true and a connection to the underlying RDBMS
@@ -310,7 +521,10 @@ func (cm *GraniticRdbmsClientManager) Client() (Client, error) {
 		return nil, err
 	}
 
-	return newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog), nil
+	rc := cm.newClient(db)
+	rc.readDB = cm.replicaDatabase(nil)
+
+	return rc, nil
 }
 
 // ClientFromContext implements ClientManager.ClientFromContext
@@ -337,12 +551,151 @@ func (cm *GraniticRdbmsClientManager) ClientFromContext(ctx context.Context) (Cl
 		}
 	}
 
-	rc := newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog)
+	rc := cm.newClient(db)
+	rc.readDB = cm.replicaDatabase(ctx)
 	rc.ctx = ctx
 
 	return rc, nil
 }
 
+// PoolStats returns statistics about the connection pool maintained by the sql.DB returned by this manager's DatabaseProvider.
+func (cm *GraniticRdbmsClientManager) PoolStats() (sql.DBStats, error) {
+
+	db, err := cm.Configuration.Provider.Database()
+
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+
+	return db.Stats(), nil
+}
+
+// Health returns the result of the most recent health check. If health checking is not enabled, the database is
+// always reported as available.
+func (cm *GraniticRdbmsClientManager) Health() Health {
+
+	if cm.health == nil {
+		return Health{Available: true}
+	}
+
+	return cm.health.current()
+}
+
+// ping is used by the health check to confirm the database is reachable.
+func (cm *GraniticRdbmsClientManager) ping(ctx context.Context) error {
+
+	db, err := cm.Configuration.Provider.Database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.PingContext(ctx)
+}
+
+func (cm *GraniticRdbmsClientManager) connectivityChanged(available bool, err error) {
+
+	conf := cm.Configuration
+
+	if available {
+		cm.SharedLog.LogInfof("Database used by %s is reachable again", conf.ClientName)
+	} else {
+		cm.SharedLog.LogErrorf("Database used by %s is unreachable: %s", conf.ClientName, err.Error())
+	}
+
+	for _, l := range conf.ConnectivityListeners {
+
+		if available {
+			l.DatabaseAvailable(conf.ClientName)
+		} else {
+			l.DatabaseUnavailable(conf.ClientName, err)
+		}
+	}
+}
+
+// configurePool applies the configured connection pool settings to a sql.DB the first time it is seen
+func (cm *GraniticRdbmsClientManager) configurePool(db *sql.DB) {
+
+	if db == nil {
+		return
+	}
+
+	cm.poolMutex.Lock()
+	defer cm.poolMutex.Unlock()
+
+	if cm.pooled[db] {
+		return
+	}
+
+	if cm.pooled == nil {
+		cm.pooled = make(map[*sql.DB]bool)
+	}
+
+	configurePool(db, cm.Configuration)
+	cm.pooled[db] = true
+}
+
+func (cm *GraniticRdbmsClientManager) newClient(db *sql.DB) *ManagedClient {
+	cm.configurePool(db)
+
+	rc := newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog)
+	rc.lastIDArgs = cm.chooseInsertArgsFunction()
+	rc.batchIDs = cm.chooseBatchInsertFunction()
+	rc.batchSize = cm.Configuration.BatchSize
+	rc.statements = cm.statements
+	rc.binder.SnakeCaseColumns = cm.Configuration.SnakeCaseColumns
+	rc.retry = cm.chooseRetryPolicy()
+	rc.stats = cm.stats
+	rc.slow = cm.slowQueryPolicy()
+
+	return rc
+}
+
+// QueryStats returns the statistics recorded for queries executed by ManagedClients created by this manager, or nil if
+// ClientManagerConfig.DisableQueryStats is set.
+func (cm *GraniticRdbmsClientManager) QueryStats() *QueryStats {
+	return cm.stats
+}
+
+func (cm *GraniticRdbmsClientManager) slowQueryPolicy() *SlowQueryPolicy {
+
+	conf := cm.Configuration
+
+	if conf.SlowQueryThresholdMS <= 0 {
+		return nil
+	}
+
+	sp := new(SlowQueryPolicy)
+	sp.Threshold = time.Duration(conf.SlowQueryThresholdMS) * time.Millisecond
+	sp.LogParameters = conf.LogSlowQueryParameters
+	sp.Redact = conf.RedactParameters
+
+	return sp
+}
+
+// HealthyReplicas returns the number of read replicas that passed their most recent health check and the total number
+// of read replicas configured.
+func (cm *GraniticRdbmsClientManager) HealthyReplicas() (healthy int, configured int) {
+
+	if cm.replicas == nil {
+		return 0, 0
+	}
+
+	return cm.replicas.healthy(), len(cm.replicas.replicas)
+}
+
+func (cm *GraniticRdbmsClientManager) replicaDatabase(ctx context.Context) *sql.DB {
+
+	if cm.replicas == nil {
+		return nil
+	}
+
+	db := cm.replicas.database(ctx)
+	cm.configurePool(db)
+
+	return db
+}
+
 func (cm *GraniticRdbmsClientManager) chooseInsertFunction() InsertWithReturnedID {
 
 	if iwi, found := cm.Configuration.Provider.(NonStandardInsertProvider); found {
@@ -352,7 +705,48 @@ func (cm *GraniticRdbmsClientManager) chooseInsertFunction() InsertWithReturnedI
 	return DefaultInsertWithReturnedID
 }
 
-// StartComponent selects a DatabaseProvider to use
+func (cm *GraniticRdbmsClientManager) chooseBatchInsertFunction() BatchInsertWithReturnedIDs {
+
+	if bip, found := cm.Configuration.Provider.(NonStandardBatchInsertProvider); found {
+		return bip.BatchInsertIDFunc()
+	}
+
+	return nil
+}
+
+func (cm *GraniticRdbmsClientManager) chooseRetryPolicy() *TransactionRetryPolicy {
+
+	conf := cm.Configuration
+
+	tp := new(TransactionRetryPolicy)
+	tp.MaxRetries = conf.TransactionRetries
+	tp.InitialBackoff = time.Duration(conf.TransactionRetryBackoffMS) * time.Millisecond
+	tp.MaxBackoff = time.Duration(conf.TransactionRetryMaxBackoffMS) * time.Millisecond
+
+	if rep, found := conf.Provider.(RetryableErrorProvider); found {
+		tp.Retryable = rep.RetryableError
+	}
+
+	return tp
+}
+
+func (cm *GraniticRdbmsClientManager) chooseInsertArgsFunction() InsertWithReturnedIDArgs {
+
+	p := cm.Configuration.Provider
+
+	if iwi, found := p.(NonStandardParameterisedInsertProvider); found {
+		return iwi.InsertIDArgsFunc()
+	}
+
+	if _, found := p.(NonStandardInsertProvider); found {
+		// Provider has a custom insert function that can't accept bind arguments
+		return nil
+	}
+
+	return DefaultInsertWithReturnedIDArgs
+}
+
+// StartComponent prepares the statement cache and starts checking the health of the database and any read replicas
 func (cm *GraniticRdbmsClientManager) StartComponent() error {
 
 	if cm.state != ioc.StoppedState {
@@ -361,6 +755,51 @@ func (cm *GraniticRdbmsClientManager) StartComponent() error {
 
 	cm.state = ioc.StartingState
 
+	conf := cm.Configuration
+
+	if !conf.DisableStatementReuse {
+		cm.statements = newStatementCache()
+
+		if rn, found := cm.QueryManager.(dsquery.ReloadNotifier); found {
+			// Statements prepared from the previous versions of templates will never be reused
+			sc := cm.statements
+			rn.OnReload(func() { sc.clear() })
+		}
+	}
+
+	if !conf.DisableQueryStats {
+		cm.stats = NewQueryStats()
+	}
+
+	if conf.Provider != nil {
+
+		if db, err := conf.Provider.Database(); err == nil {
+			// Apply pool settings now so they are in place before the first ManagedClient is created
+			cm.configurePool(db)
+		}
+	}
+
+	if len(conf.ReplicaProviders) > 0 {
+
+		interval := DefaultReplicaCheckInterval
+
+		if conf.ReplicaCheckIntervalMS > 0 {
+			interval = time.Duration(conf.ReplicaCheckIntervalMS) * time.Millisecond
+		}
+
+		cm.replicas = newReplicaSet(conf.ReplicaProviders, cm.SharedLog)
+		cm.replicas.check(interval)
+		cm.replicas.monitor(interval)
+	}
+
+	if conf.HealthCheckIntervalMS > 0 {
+
+		interval := time.Duration(conf.HealthCheckIntervalMS) * time.Millisecond
+
+		cm.health = newHealthMonitor(cm.ping, cm.connectivityChanged)
+		cm.health.monitor(interval)
+	}
+
 	cm.state = ioc.RunningState
 
 	return nil
@@ -376,7 +815,20 @@ func (cm *GraniticRdbmsClientManager) ReadyToStop() (bool, error) {
 	return true, nil
 }
 
-// Stop always returns nil
+// Stop closes any statements that have been prepared for reuse and stops any health checks
 func (cm *GraniticRdbmsClientManager) Stop() error {
+
+	if cm.replicas != nil {
+		cm.replicas.stopMonitoring()
+	}
+
+	if cm.health != nil {
+		cm.health.stopMonitoring()
+	}
+
+	if cm.statements != nil {
+		return cm.statements.close()
+	}
+
 	return nil
 }
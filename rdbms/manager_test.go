@@ -45,3 +45,21 @@ func (mp *mockProvider) Database() (*sql.DB, error) {
 
 	return nil, nil
 }
+
+func TestSnakeCaseColumnsPassedToClients(t *testing.T) {
+
+	m := new(GraniticRdbmsClientManager)
+	m.SharedLog = new(logging.ConsoleErrorLogger)
+	m.Configuration = &ClientManagerConfig{Provider: new(mockProvider), SnakeCaseColumns: true}
+	m.state = ioc.RunningState
+
+	c, err := m.Client()
+
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if !c.(*ManagedClient).binder.SnakeCaseColumns {
+		t.Errorf("Expected client's RowBinder to match snake_case columns")
+	}
+}
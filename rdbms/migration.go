@@ -0,0 +1,618 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/logging"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DefaultMigrationTable is the name of the table used to record applied migrations if Migrator.Table is not set.
+const DefaultMigrationTable = "grnc_schema_migrations"
+
+// DefaultMigrationTableStatement is used to create the migration table if Migrator.CreateTableStatement is not set. The
+// %s verb is replaced with the name of the table.
+const DefaultMigrationTableStatement = "CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, description VARCHAR(255) NOT NULL, applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"
+
+// DefaultMigrationLockTableStatement is used to create the table that prevents more than one instance applying
+// migrations at the same time if Migrator.CreateLockTableStatement is not set. The %s verb is replaced with the name of
+// the migration table followed by _lock.
+const DefaultMigrationLockTableStatement = "CREATE TABLE IF NOT EXISTS %s (id INT NOT NULL PRIMARY KEY, locked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"
+
+// DefaultMigrationLockWait is how long a Migrator waits for another instance to finish applying migrations if
+// Migrator.LockWaitMS is not set.
+const DefaultMigrationLockWait = time.Minute
+
+// NoTransactionMarker can be included in a migration file to cause its statements to be executed outside of a
+// transaction (for statements that cannot be executed in a transaction on some databases).
+const NoTransactionMarker = "-- grnc:no-transaction"
+
+// NoSplitMarker can be included in a migration file to cause the whole file to be sent to the database as a single
+// statement, rather than being split at each semicolon (for example, for a stored procedure whose body contains
+// semicolons).
+const NoSplitMarker = "-- grnc:no-split"
+
+// How often a Migrator checks whether another instance has finished applying migrations
+const migrationLockPoll = 500 * time.Millisecond
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// Migration is a single, versioned change to a database schema.
+type Migration struct {
+	// The version number from the start of the migration's file name.
+	Version int64
+
+	// The rest of the migration's file name, with underscores replaced by spaces.
+	Description string
+
+	// The path of the migration's file. Empty if the migration has been applied but its file no longer exists.
+	File string
+
+	// Whether or not the migration has been applied to the database.
+	Applied bool
+}
+
+/*
+Migrator applies versioned SQL files to a database, recording the version of each applied file in a table so that
+each file is only applied once.
+
+Files are read from Directory and must be named with a version number, an underscore and a description, for example
+0001_create_artist.sql. Files are applied in order of version number. Each file may contain any number of statements
+separated by semicolons (see SplitStatements), or the line NoSplitMarker to be executed as a single statement. The
+statements in a file, and the recording of its version, are executed in a single transaction unless the file contains
+the line NoTransactionMarker.
+
+Before applying migrations, the Migrator inserts a row into a lock table (the migration table's name followed by
+_lock) so that only one instance of an application applies migrations at a time. Other instances wait up to LockWaitMS
+for the row to be deleted. If an instance stops while applying migrations, the row must be deleted manually.
+
+If RunAtStartup is true, pending migrations are applied when the container asks whether the Migrator is blocking access
+to the application (see ioc.AccessibilityBlocker), so the application does not become accessible (for example, the HTTP
+server does not start listening) until the database schema is up to date.
+*/
+type Migrator struct {
+	// Used to create the Client that executes migrations.
+	ClientManager ClientManager
+
+	// The ClientName of the database to migrate. Used by the RdbmsAccess facility to find the ClientManager.
+	Database string
+
+	// The directory containing migration files.
+	Directory string
+
+	// The name of the table used to record applied migrations. If not set, DefaultMigrationTable is used.
+	Table string
+
+	// The statement used to create the migration table if it does not exist. If not set, DefaultMigrationTableStatement
+	// is used.
+	CreateTableStatement string
+
+	// The statement used to create the lock table if it does not exist. If not set, DefaultMigrationLockTableStatement
+	// is used.
+	CreateLockTableStatement string
+
+	// How long (in milliseconds) to wait for another instance to finish applying migrations. If not set,
+	// DefaultMigrationLockWait is used.
+	LockWaitMS int
+
+	// The SQL dialect of the database (mysql, postgresql, sqlite or sqlserver). Determines the style of placeholder used
+	// when recording applied migrations. If not set, ? placeholders are used.
+	Dialect string
+
+	// Whether or not pending migrations are applied before the application becomes accessible.
+	RunAtStartup bool
+
+	// If true, pending migrations found at startup are logged rather than applied.
+	DryRun bool
+
+	FrameworkLogger logging.Logger
+
+	mu       sync.Mutex
+	migrated bool
+	lockPoll time.Duration
+}
+
+// BlockAccess implements ioc.AccessibilityBlocker. If RunAtStartup is true, pending migrations are applied (or logged if
+// DryRun is true) the first time this method is called. Access is blocked until the migrations have been applied
+// successfully. Failed migrations are attempted again the next time this method is called.
+func (m *Migrator) BlockAccess() (bool, error) {
+
+	if !m.RunAtStartup {
+		return false, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.migrated {
+		return false, nil
+	}
+
+	if m.DryRun {
+
+		pending, err := m.pending()
+
+		if err != nil {
+			return true, err
+		}
+
+		for _, mg := range pending {
+			m.FrameworkLogger.LogInfof("Dry run: migration %d (%s) is pending", mg.Version, mg.Description)
+		}
+
+		m.migrated = true
+
+		return false, nil
+	}
+
+	if _, err := m.up(); err != nil {
+		return true, err
+	}
+
+	m.migrated = true
+
+	return false, nil
+}
+
+// Status returns all known migrations in version order, including migrations that have been applied but whose file no
+// longer exists.
+func (m *Migrator) Status() ([]*Migration, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.status()
+}
+
+// Pending returns the migrations that have not yet been applied, in the order they will be applied.
+func (m *Migrator) Pending() ([]*Migration, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pending()
+}
+
+// Up applies all pending migrations in version order, stopping at the first migration that fails. Returns the
+// migrations that were applied successfully.
+func (m *Migrator) Up() ([]*Migration, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.up()
+}
+
+func (m *Migrator) status() ([]*Migration, error) {
+
+	files, err := m.files()
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := m.ClientManager.Client()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mg := range files {
+
+		if _, found := applied[mg.Version]; found {
+			mg.Applied = true
+			delete(applied, mg.Version)
+		}
+	}
+
+	for v, d := range applied {
+		files = append(files, &Migration{Version: v, Description: d, Applied: true})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+
+	return files, nil
+}
+
+func (m *Migrator) pending() ([]*Migration, error) {
+
+	all, err := m.status()
+
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*Migration, 0)
+
+	for _, mg := range all {
+		if !mg.Applied {
+			pending = append(pending, mg)
+		}
+	}
+
+	return pending, nil
+}
+
+func (m *Migrator) up() ([]*Migration, error) {
+
+	ps, err := dsquery.PlaceholderStyleForDialect(m.Dialect)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := m.ClientManager.Client()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.lock(c); err != nil {
+		return nil, err
+	}
+
+	defer m.unlock(c)
+
+	// Find pending migrations after acquiring the lock, as another instance may have just applied them
+	pending, err := m.pending()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]*Migration, 0)
+
+	for _, mg := range pending {
+
+		if err := m.apply(c, mg, ps); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %s", mg.Version, mg.Description, err.Error())
+		}
+
+		m.FrameworkLogger.LogInfof("Applied migration %d (%s)", mg.Version, mg.Description)
+
+		mg.Applied = true
+		applied = append(applied, mg)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) apply(c Client, mg *Migration, ps dsquery.PlaceholderStyle) error {
+
+	b, err := os.ReadFile(mg.File)
+
+	if err != nil {
+		return err
+	}
+
+	text := string(b)
+
+	statements := []string{strings.TrimSpace(text)}
+
+	if !strings.Contains(text, NoSplitMarker) {
+		statements = SplitStatements(text)
+	}
+
+	record := fmt.Sprintf("INSERT INTO %s (version, description) VALUES (%s, %s)", m.table(), ps.Placeholder(1), ps.Placeholder(2))
+
+	run := func(tc Client) error {
+
+		for _, s := range statements {
+			if _, err := tc.Exec(s); err != nil {
+				return err
+			}
+		}
+
+		_, err := tc.Exec(record, mg.Version, mg.Description)
+
+		return err
+	}
+
+	if strings.Contains(text, NoTransactionMarker) {
+		return run(c)
+	}
+
+	return c.WithTransaction(context.Background(), nil, run)
+}
+
+// lock creates the lock table if necessary and inserts the row that shows migrations are being applied, waiting for
+// any other instance that has inserted the row to delete it.
+func (m *Migrator) lock(c Client) error {
+
+	stmt := m.CreateLockTableStatement
+
+	if stmt == "" {
+		stmt = DefaultMigrationLockTableStatement
+	}
+
+	if _, err := c.Exec(fmt.Sprintf(stmt, m.lockTable())); err != nil {
+		return fmt.Errorf("unable to create migration lock table %s: %s", m.lockTable(), err.Error())
+	}
+
+	wait := DefaultMigrationLockWait
+
+	if m.LockWaitMS > 0 {
+		wait = time.Duration(m.LockWaitMS) * time.Millisecond
+	}
+
+	poll := m.lockPoll
+
+	if poll == 0 {
+		poll = migrationLockPoll
+	}
+
+	deadline := time.Now().Add(wait)
+	insert := fmt.Sprintf("INSERT INTO %s (id) VALUES (1)", m.lockTable())
+	waiting := false
+
+	for {
+
+		_, err := c.Exec(insert)
+
+		if err == nil {
+			return nil
+		}
+
+		var count int64
+
+		if cerr := c.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", m.lockTable())).Scan(&count); cerr != nil || count == 0 {
+			// The insert did not fail because another instance holds the lock
+			return fmt.Errorf("unable to lock migration lock table %s: %s", m.lockTable(), err.Error())
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for another instance to finish applying migrations. If no other instance is " +
+				"applying migrations, delete the row from " + m.lockTable())
+		}
+
+		if !waiting {
+			m.FrameworkLogger.LogInfof("Waiting for another instance to finish applying migrations")
+			waiting = true
+		}
+
+		time.Sleep(poll)
+	}
+}
+
+func (m *Migrator) unlock(c Client) {
+
+	if _, err := c.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = 1", m.lockTable())); err != nil {
+		m.FrameworkLogger.LogErrorf("Unable to delete the row from migration lock table %s: %s", m.lockTable(), err.Error())
+	}
+}
+
+// applied creates the migration table if necessary and returns the description of each applied migration, keyed by version.
+func (m *Migrator) applied(c Client) (map[int64]string, error) {
+
+	stmt := m.CreateTableStatement
+
+	if stmt == "" {
+		stmt = DefaultMigrationTableStatement
+	}
+
+	if _, err := c.Exec(fmt.Sprintf(stmt, m.table())); err != nil {
+		return nil, fmt.Errorf("unable to create migration table %s: %s", m.table(), err.Error())
+	}
+
+	r, err := c.Query(fmt.Sprintf("SELECT version, description FROM %s", m.table()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	applied := make(map[int64]string)
+
+	for r.Next() {
+
+		var v int64
+		var d string
+
+		if err := r.Scan(&v, &d); err != nil {
+			return nil, err
+		}
+
+		applied[v] = d
+	}
+
+	return applied, r.Err()
+}
+
+// files returns a Migration for each migration file in Directory, in version order.
+func (m *Migrator) files() ([]*Migration, error) {
+
+	entries, err := os.ReadDir(m.Directory)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read migrations directory: %s", err.Error())
+	}
+
+	migrations := make([]*Migration, 0)
+	versions := make(map[int64]string)
+
+	for _, e := range entries {
+
+		name := e.Name()
+
+		if e.IsDir() || filepath.Ext(name) != ".sql" {
+			continue
+		}
+
+		parts := migrationFilePattern.FindStringSubmatch(name)
+
+		if parts == nil {
+			return nil, fmt.Errorf("migration file %s must be named <version>_<description>.sql", name)
+		}
+
+		v, err := strconv.ParseInt(parts[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s has an invalid version: %s", name, err.Error())
+		}
+
+		if other, found := versions[v]; found {
+			return nil, fmt.Errorf("migration files %s and %s have the same version", other, name)
+		}
+
+		versions[v] = name
+
+		mg := new(Migration)
+		mg.Version = v
+		mg.Description = strings.ReplaceAll(parts[2], "_", " ")
+		mg.File = filepath.Join(m.Directory, name)
+
+		migrations = append(migrations, mg)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m *Migrator) table() string {
+
+	if m.Table == "" {
+		return DefaultMigrationTable
+	}
+
+	return m.Table
+}
+
+func (m *Migrator) lockTable() string {
+	return m.table() + "_lock"
+}
+
+// SplitStatements splits the supplied SQL into individual statements at each semicolon that is not inside a quoted
+// string, quoted identifier, PostgreSQL dollar-quoted string ($$...$$ or $tag$...$tag$) or comment. Statements that
+// contain only whitespace and comments are discarded.
+func SplitStatements(text string) []string {
+
+	statements := make([]string, 0)
+
+	var quote rune
+	lineComment, blockComment, code := false, false, false
+	start := 0
+
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+
+		r := runes[i]
+
+		var next rune
+
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case lineComment:
+			lineComment = r != '\n'
+		case blockComment:
+			if r == '*' && next == '/' {
+				blockComment = false
+				i++
+			}
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '-' && next == '-':
+			lineComment = true
+			i++
+		case r == '/' && next == '*':
+			blockComment = true
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			code = true
+		case r == '$' && (i == 0 || !isIdentifierRune(runes[i-1])):
+			if tag := dollarQuoteTag(runes[i:]); tag != "" {
+				i = closingDollarQuote(runes, i+len([]rune(tag)), tag)
+			}
+
+			code = true
+		case r == ';':
+			if code {
+				statements = append(statements, strings.TrimSpace(string(runes[start:i])))
+			}
+
+			start = i + 1
+			code = false
+		default:
+			if !isSpace(r) {
+				code = true
+			}
+		}
+	}
+
+	if code {
+		statements = append(statements, strings.TrimSpace(string(runes[start:])))
+	}
+
+	return statements
+}
+
+// dollarQuoteTag returns the tag ($$ or $name$) that opens a dollar-quoted string at the start of the supplied text, or
+// an empty string if the text does not start with a dollar quote (for example, if it starts with a $1 placeholder).
+func dollarQuoteTag(text []rune) string {
+
+	for i := 1; i < len(text); i++ {
+
+		r := text[i]
+
+		switch {
+		case r == '$':
+			return string(text[:i+1])
+		case r == '_' || unicode.IsLetter(r) || (i > 1 && unicode.IsDigit(r)):
+			continue
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// closingDollarQuote returns the position of the last rune of the tag that closes the dollar-quoted string whose
+// content starts at from.
+func closingDollarQuote(runes []rune, from int, tag string) int {
+
+	t := []rune(tag)
+
+	for i := from; i+len(t) <= len(runes); i++ {
+		if string(runes[i:i+len(t)]) == tag {
+			return i + len(t) - 1
+		}
+	}
+
+	return len(runes)
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
@@ -0,0 +1,290 @@
+package rdbms
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func migrator(t *testing.T, files map[string]string) *Migrator {
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		test.ExpectNil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	m := new(Migrator)
+	m.ClientManager = &singleClientManager{c: txClient(t, 0)}
+	m.Directory = dir
+	m.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+
+	return m
+}
+
+func TestMigrationStatus(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"0002_add_label.sql":      "ALTER TABLE artist ADD label VARCHAR(50);",
+		"0001_create_artist.sql":  "CREATE TABLE artist (id BIGINT);",
+		"0010_index_artist.sql":   "CREATE INDEX artist_name ON artist (name);",
+		"README.md":               "Not a migration",
+		"0003_applied_no_file.go": "Ignored",
+	})
+
+	txDrv.columns = []string{"version", "description"}
+	txDrv.rows = [][]driver.Value{{int64(1), "create artist"}, {int64(5), "removed"}}
+
+	all, err := m.Status()
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(all), 4)
+
+	test.ExpectBool(t, all[0].Version == 1 && all[0].Applied, true)
+	test.ExpectBool(t, all[1].Version == 2 && !all[1].Applied, true)
+	test.ExpectString(t, all[1].Description, "add label")
+	test.ExpectBool(t, all[2].Version == 5 && all[2].Applied && all[2].File == "", true)
+	test.ExpectBool(t, all[3].Version == 10 && !all[3].Applied, true)
+
+	pending, err := m.Pending()
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(pending), 2)
+
+	if !strings.HasPrefix(txDrv.log(), "CREATE TABLE IF NOT EXISTS grnc_schema_migrations") {
+		t.Errorf("Expected migration table to be created, got %s", txDrv.log())
+	}
+}
+
+func TestMigrationUp(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"1_create_artist.sql":  "CREATE TABLE artist (id BIGINT);\n-- A comment; with a semicolon\nINSERT INTO artist VALUES ('a;b');\n",
+		"2_artist's_index.sql": "-- grnc:no-transaction\nCREATE INDEX artist_name ON artist (name)",
+	})
+
+	m.Table = "migrations"
+	m.CreateTableStatement = "CREATE TABLE %s (v INT)"
+	m.CreateLockTableStatement = "CREATE TABLE %s (id INT)"
+	m.Dialect = "postgresql"
+
+	applied, err := m.Up()
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(applied), 2)
+
+	expected := []string{
+		"CREATE TABLE migrations_lock (id INT)",
+		"INSERT INTO migrations_lock (id) VALUES (1)",
+		"CREATE TABLE migrations (v INT)",
+		"SELECT version, description FROM migrations",
+		"BEGIN",
+		"CREATE TABLE artist (id BIGINT)",
+		"-- A comment; with a semicolon\nINSERT INTO artist VALUES ('a;b')",
+		"INSERT INTO migrations (version, description) VALUES ($1, $2)",
+		"COMMIT",
+		"-- grnc:no-transaction\nCREATE INDEX artist_name ON artist (name)",
+		"INSERT INTO migrations (version, description) VALUES ($1, $2)",
+		"DELETE FROM migrations_lock WHERE id = 1",
+	}
+
+	test.ExpectString(t, txDrv.log(), strings.Join(expected, ","))
+
+	// Descriptions are bound rather than written into the statement
+	test.ExpectInt(t, len(txDrv.lastArgs), 2)
+	test.ExpectInt(t, int(txDrv.lastArgs[0].(int64)), 2)
+	test.ExpectString(t, txDrv.lastArgs[1].(string), "artist's index")
+
+	m.Dialect = "oracle"
+
+	_, err = m.Up()
+	test.ExpectNotNil(t, err)
+}
+
+func TestUnsplitMigration(t *testing.T) {
+
+	procedure := "-- grnc:no-split\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END"
+
+	m := migrator(t, map[string]string{"1_procedure.sql": procedure})
+
+	_, err := m.Up()
+	test.ExpectNil(t, err)
+
+	if !strings.Contains(txDrv.log(), ","+procedure+",") {
+		t.Errorf("Expected file to be executed as a single statement, got %s", txDrv.log())
+	}
+}
+
+func TestMigrationWaitsForLock(t *testing.T) {
+
+	m := migrator(t, map[string]string{"1_first.sql": "CREATE TABLE first (id BIGINT)"})
+	m.LockWaitMS = 20
+	m.lockPoll = time.Millisecond
+
+	// Another instance holds the lock
+	txDrv.failOn = "INSERT INTO grnc_schema_migrations_lock"
+	txDrv.columns = []string{"count"}
+	txDrv.rows = [][]driver.Value{{int64(1)}}
+
+	_, err := m.Up()
+	test.ExpectNotNil(t, err)
+
+	if strings.Contains(txDrv.log(), "first") || strings.Contains(txDrv.log(), "DELETE") {
+		t.Errorf("Expected no migrations to be applied and the lock not to be released, got %s", txDrv.log())
+	}
+
+	// The insert failed for another reason
+	txDrv.rows = [][]driver.Value{{int64(0)}}
+
+	_, err = m.Up()
+	test.ExpectNotNil(t, err)
+
+	if !strings.Contains(err.Error(), "unable to lock") {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+}
+
+func TestFailedMigrationStops(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"1_first.sql":  "CREATE TABLE first (id BIGINT)",
+		"2_second.sql": "CREATE TABLE broken (id BIGINT)",
+		"3_third.sql":  "CREATE TABLE third (id BIGINT)",
+	})
+
+	txDrv.failOn = "broken"
+
+	applied, err := m.Up()
+
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, len(applied), 1)
+
+	if strings.Contains(txDrv.log(), "third") {
+		t.Errorf("Expected migrations after the failed migration not to be applied")
+	}
+
+	if !strings.Contains(err.Error(), "migration 2 (second) failed") {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+}
+
+func TestMigrationBlocksAccess(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"1_first.sql": "CREATE TABLE broken (id BIGINT)",
+	})
+
+	block, err := m.BlockAccess()
+	test.ExpectBool(t, block, false)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, txDrv.log(), "")
+
+	m.RunAtStartup = true
+	txDrv.failOn = "broken"
+
+	block, err = m.BlockAccess()
+	test.ExpectBool(t, block, true)
+	test.ExpectNotNil(t, err)
+
+	txDrv.failOn = ""
+
+	block, err = m.BlockAccess()
+	test.ExpectBool(t, block, false)
+	test.ExpectNil(t, err)
+
+	// Migrations are only run once
+	txDrv.reset()
+
+	m.BlockAccess()
+	test.ExpectString(t, txDrv.log(), "")
+}
+
+func TestMigrationDryRun(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"1_first.sql": "CREATE TABLE first (id BIGINT)",
+	})
+
+	m.RunAtStartup = true
+	m.DryRun = true
+
+	block, err := m.BlockAccess()
+	test.ExpectBool(t, block, false)
+	test.ExpectNil(t, err)
+
+	if strings.Contains(txDrv.log(), "first") {
+		t.Errorf("Expected migration not to be applied")
+	}
+}
+
+func TestInvalidMigrationFiles(t *testing.T) {
+
+	m := migrator(t, map[string]string{
+		"create_artist.sql": "CREATE TABLE artist (id BIGINT)",
+	})
+
+	_, err := m.Status()
+	test.ExpectNotNil(t, err)
+
+	m = migrator(t, map[string]string{
+		"1_create_artist.sql": "CREATE TABLE artist (id BIGINT)",
+		"01_create_label.sql": "CREATE TABLE label (id BIGINT)",
+	})
+
+	_, err = m.Status()
+	test.ExpectNotNil(t, err)
+
+	m = migrator(t, nil)
+	m.Directory = filepath.Join(m.Directory, "missing")
+
+	_, err = m.Status()
+	test.ExpectNotNil(t, err)
+}
+
+func TestSplitStatements(t *testing.T) {
+
+	s := SplitStatements(`
+-- Leading comment;
+CREATE TABLE a (id INT);
+
+/* block; comment */
+INSERT INTO a VALUES ('x;y'), ("p;q"), (` + "`r;s`" + `);
+;
+  UPDATE a SET id = 2`)
+
+	test.ExpectInt(t, len(s), 3)
+	test.ExpectString(t, s[0], "-- Leading comment;\nCREATE TABLE a (id INT)")
+	test.ExpectString(t, s[1], "/* block; comment */\nINSERT INTO a VALUES ('x;y'), (\"p;q\"), (`r;s`)")
+	test.ExpectString(t, s[2], "UPDATE a SET id = 2")
+
+	test.ExpectInt(t, len(SplitStatements("-- only a comment\n")), 0)
+
+	s = SplitStatements(`CREATE FUNCTION f() RETURNS INT AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;
+CREATE FUNCTION g() RETURNS INT AS $body$ SELECT '$$;'; $body$ LANGUAGE sql;
+SELECT a$b FROM t WHERE c = $1;
+DO $é$ BEGIN PERFORM 1; END $é$`)
+
+	test.ExpectInt(t, len(s), 4)
+	test.ExpectString(t, s[0], "CREATE FUNCTION f() RETURNS INT AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql")
+	test.ExpectString(t, s[1], "CREATE FUNCTION g() RETURNS INT AS $body$ SELECT '$$;'; $body$ LANGUAGE sql")
+	test.ExpectString(t, s[2], "SELECT a$b FROM t WHERE c = $1")
+	test.ExpectString(t, s[3], "DO $é$ BEGIN PERFORM 1; END $é$")
+}
+
+type singleClientManager struct {
+	c Client
+}
+
+func (cm *singleClientManager) Client() (Client, error) {
+	return cm.c, nil
+}
+
+func (cm *singleClientManager) ClientFromContext(ctx context.Context) (Client, error) {
+	return cm.c, nil
+}
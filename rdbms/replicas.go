@@ -0,0 +1,177 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"github.com/graniticio/granitic/v2/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReplicaCheckInterval is used if ClientManagerConfig.ReplicaCheckIntervalMS is not set.
+const DefaultReplicaCheckInterval = 10 * time.Second
+
+func newReplicaSet(providers []DatabaseProvider, log logging.Logger) *replicaSet {
+	rs := new(replicaSet)
+	rs.log = log
+
+	for i, p := range providers {
+		r := new(replica)
+		r.index = i
+		r.provider = p
+		r.healthy = 1
+
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	return rs
+}
+
+type replica struct {
+	index    int
+	provider DatabaseProvider
+	healthy  int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// setHealthy records the health of the replica, returning true if it has changed.
+func (r *replica) setHealthy(healthy bool) bool {
+
+	var v int32
+
+	if healthy {
+		v = 1
+	}
+
+	return atomic.SwapInt32(&r.healthy, v) != v
+}
+
+func (r *replica) database(ctx context.Context) (*sql.DB, error) {
+
+	if cdp, found := r.provider.(ContextAwareDatabaseProvider); found && ctx != nil {
+		return cdp.DatabaseFromContext(ctx)
+	}
+
+	return r.provider.Database()
+}
+
+// replicaSet distributes read-only queries across the read replicas of a database, skipping any replica that failed
+// its most recent health check.
+type replicaSet struct {
+	replicas []*replica
+	next     uint32
+	log      logging.Logger
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+// database returns a connection to the next healthy replica or nil if no replica is available, in which case the
+// caller should use the primary database.
+func (rs *replicaSet) database(ctx context.Context) *sql.DB {
+
+	count := len(rs.replicas)
+	start := int(atomic.AddUint32(&rs.next, 1))
+
+	for i := 0; i < count; i++ {
+
+		r := rs.replicas[(start+i)%count]
+
+		if !r.isHealthy() {
+			continue
+		}
+
+		db, err := r.database(ctx)
+
+		if err == nil {
+			return db
+		}
+
+		if r.setHealthy(false) {
+			rs.log.LogErrorf("Unable to connect to read replica %d. Replica will not be used until it passes a health check: %s", r.index, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// healthy returns the number of replicas that passed their most recent health check.
+func (rs *replicaSet) healthy() int {
+
+	h := 0
+
+	for _, r := range rs.replicas {
+		if r.isHealthy() {
+			h++
+		}
+	}
+
+	return h
+}
+
+// check pings each replica, waiting no longer than the supplied timeout for a response.
+func (rs *replicaSet) check(timeout time.Duration) {
+
+	for _, r := range rs.replicas {
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+		db, err := r.provider.Database()
+
+		if err == nil {
+			err = db.PingContext(ctx)
+		}
+
+		cancel()
+
+		if !r.setHealthy(err == nil) {
+			continue
+		}
+
+		if err != nil {
+			rs.log.LogErrorf("Read replica %d failed its health check and will not be used: %s", r.index, err.Error())
+		} else {
+			rs.log.LogInfof("Read replica %d passed its health check and will be used again", r.index)
+		}
+	}
+}
+
+// monitor checks the health of each replica at the supplied interval until stopMonitoring is called.
+func (rs *replicaSet) monitor(interval time.Duration) {
+
+	rs.stop = make(chan struct{})
+	rs.stopped.Add(1)
+
+	go func() {
+		defer rs.stopped.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-rs.stop:
+				return
+			case <-t.C:
+				rs.check(interval)
+			}
+		}
+	}()
+}
+
+func (rs *replicaSet) stopMonitoring() {
+
+	if rs.stop == nil {
+		return
+	}
+
+	close(rs.stop)
+	rs.stopped.Wait()
+	rs.stop = nil
+}
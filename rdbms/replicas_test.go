@@ -0,0 +1,131 @@
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+func TestReplicaSelection(t *testing.T) {
+
+	a, _ := sql.Open("grnc-mock", "a")
+	b, _ := sql.Open("grnc-mock", "b")
+
+	rs := newReplicaSet([]DatabaseProvider{&replicaProvider{db: a}, &replicaProvider{db: b}}, new(logging.ConsoleErrorLogger))
+
+	test.ExpectInt(t, rs.healthy(), 2)
+
+	first := rs.database(nil)
+	second := rs.database(nil)
+
+	if first == second {
+		t.Errorf("Expected replicas to be used in turn")
+	}
+
+	rs.replicas[0].setHealthy(false)
+
+	for i := 0; i < 3; i++ {
+		if rs.database(nil) != b {
+			t.Errorf("Expected unhealthy replica to be skipped")
+		}
+	}
+
+	rs.replicas[1].setHealthy(false)
+
+	if rs.database(nil) != nil {
+		t.Errorf("Expected no replica to be available")
+	}
+}
+
+func TestReplicaHealthCheck(t *testing.T) {
+
+	failing := &replicaProvider{err: errors.New("unreachable")}
+
+	rs := newReplicaSet([]DatabaseProvider{&replicaProvider{db: db}, failing}, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	rs.check(time.Second)
+	test.ExpectInt(t, rs.healthy(), 1)
+
+	failing.err = nil
+	failing.db = db
+
+	rs.check(time.Second)
+	test.ExpectInt(t, rs.healthy(), 2)
+
+	// A replica that can't be connected to when a client is created is marked as unhealthy
+	failing.err = errors.New("unreachable")
+	rs.database(nil)
+	rs.database(nil)
+	test.ExpectInt(t, rs.healthy(), 1)
+}
+
+func TestSelectsSentToReplica(t *testing.T) {
+
+	replica, _ := sql.Open("grnc-mock", "replica")
+	replica.Close()
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.readDB = replica
+
+	// The replica is closed, so a query sent to it will fail
+	drv.consumed()
+	_, err := c.SelectQIDParams("SQ")
+	test.ExpectNotNil(t, err)
+
+	_, err = c.UpdateQIDParams("UQ")
+	test.ExpectNil(t, err)
+
+	_, err = c.Exec("DIRECT")
+	test.ExpectNil(t, err)
+
+	// Selects inside a transaction use the primary database
+	test.ExpectNil(t, c.StartTransaction())
+
+	drv.consumed()
+	r, err := c.SelectQIDParams("SQ")
+	test.ExpectNil(t, err)
+	r.Close()
+
+	c.Rollback()
+}
+
+func TestManagerUsesReplicas(t *testing.T) {
+
+	m := new(GraniticRdbmsClientManager)
+	m.SharedLog = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	m.Configuration = &ClientManagerConfig{
+		Provider:               &replicaProvider{db: db},
+		ReplicaProviders:       []DatabaseProvider{&replicaProvider{db: db}, &replicaProvider{err: errors.New("unreachable")}},
+		ReplicaCheckIntervalMS: 50,
+	}
+
+	test.ExpectNil(t, m.StartComponent())
+	test.ExpectBool(t, m.state == ioc.RunningState, true)
+
+	healthy, configured := m.HealthyReplicas()
+	test.ExpectInt(t, healthy, 1)
+	test.ExpectInt(t, configured, 2)
+
+	c, err := m.ClientFromContext(context.Background())
+	test.ExpectNil(t, err)
+
+	if c.(*ManagedClient).readDB != db {
+		t.Errorf("Expected client to have been given the healthy replica")
+	}
+
+	test.ExpectNil(t, m.Stop())
+}
+
+type replicaProvider struct {
+	db  *sql.DB
+	err error
+}
+
+func (rp *replicaProvider) Database() (*sql.DB, error) {
+	return rp.db, rp.err
+}
@@ -0,0 +1,259 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Keywords that end the WHERE clause of a statement (or the point where a WHERE clause would be if the statement
+// does not have one).
+var clauseTerminators = map[string]bool{
+	"GROUP":     true,
+	"HAVING":    true,
+	"WINDOW":    true,
+	"ORDER":     true,
+	"LIMIT":     true,
+	"OFFSET":    true,
+	"FETCH":     true,
+	"FOR":       true,
+	"RETURNING": true,
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+}
+
+// sqlWord is a keyword or identifier that appears in a statement outside of quotes, comments and parentheses.
+type sqlWord struct {
+	word  string
+	start int
+	end   int
+}
+
+// topLevelWords returns the words (in upper case) of the supplied statement that are not quoted, commented or
+// enclosed in parentheses.
+func topLevelWords(query string) []sqlWord {
+
+	var words []sqlWord
+
+	depth := 0
+
+	for i := 0; i < len(query); i++ {
+
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = closingQuote(query, i)
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				i += n
+			} else {
+				i = len(query)
+			}
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				i += n + 3
+			} else {
+				i = len(query)
+			}
+
+		case c == '(':
+			depth++
+
+		case c == ')':
+			depth--
+
+		case isWordByte(c):
+			start := i
+
+			for i+1 < len(query) && isWordByte(query[i+1]) {
+				i++
+			}
+
+			if depth == 0 {
+				words = append(words, sqlWord{word: strings.ToUpper(query[start : i+1]), start: start, end: i + 1})
+			}
+		}
+	}
+
+	return words
+}
+
+// closingQuote returns the position of the quote that closes the quoted string or identifier starting at open.
+// Doubled quotes and backslash escaped characters do not close the string.
+func closingQuote(query string, open int) int {
+
+	q := query[open]
+
+	for i := open + 1; i < len(query); i++ {
+
+		switch query[i] {
+		case '\\':
+			i++
+		case q:
+			if i+1 < len(query) && query[i+1] == q {
+				i++
+				continue
+			}
+
+			return i
+		}
+	}
+
+	return len(query)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// trimStatement removes trailing whitespace and semicolons from a statement.
+func trimStatement(query string) string {
+	return strings.TrimRight(query, " \t\r\n;")
+}
+
+// addPredicate returns the supplied statement with condition ANDed with its WHERE clause. A WHERE clause is added if
+// the statement does not have one.
+func addPredicate(query string, condition string) string {
+	q, _ := insertPredicate(query, condition)
+	return q
+}
+
+// insertPredicate behaves like addPredicate, but also returns the offset in the new statement at which condition was written.
+func insertPredicate(query string, condition string) (string, int) {
+
+	query = trimStatement(query)
+	words := topLevelWords(query)
+
+	where := -1
+
+	for i, w := range words {
+		if w.word == "WHERE" {
+			where = i
+			break
+		}
+	}
+
+	end := len(query)
+
+	for _, w := range words[where+1:] {
+		if clauseTerminators[w.word] {
+			end = w.start
+			break
+		}
+	}
+
+	rest := strings.TrimSpace(query[end:])
+
+	var b strings.Builder
+
+	if where >= 0 {
+		clause := strings.TrimSpace(query[words[where].end:end])
+
+		if strings.Contains(clause, "--") {
+			// Make sure a trailing comment does not hide the rest of the clause
+			clause += "\n"
+		}
+
+		b.WriteString(query[:words[where].end])
+		b.WriteString(fmt.Sprintf(" (%s) AND ", clause))
+	} else {
+		b.WriteString(strings.TrimSpace(query[:end]))
+		b.WriteString("\nWHERE ")
+	}
+
+	at := b.Len()
+	b.WriteString(condition)
+
+	if rest != "" {
+		b.WriteString("\n")
+		b.WriteString(rest)
+	}
+
+	return b.String(), at
+}
+
+// addAssignment returns the supplied UPDATE statement with assignment added as the first assignment of its SET clause.
+func addAssignment(query string, assignment string) (string, error) {
+
+	for _, w := range topLevelWords(query) {
+		if w.word == "SET" {
+			return query[:w.end] + " " + assignment + "," + query[w.end:], nil
+		}
+	}
+
+	return "", errors.New("statement does not have a SET clause")
+}
+
+// countPlaceholders returns the number of ? placeholders in the supplied statement that are not quoted or commented.
+func countPlaceholders(query string) int {
+
+	n := 0
+
+	for i := 0; i < len(query); i++ {
+
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = closingQuote(query, i)
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			if e := strings.IndexByte(query[i:], '\n'); e >= 0 {
+				i += e
+			} else {
+				i = len(query)
+			}
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			if e := strings.Index(query[i+2:], "*/"); e >= 0 {
+				i += e + 3
+			} else {
+				i = len(query)
+			}
+
+		case c == '?':
+			n++
+		}
+	}
+
+	return n
+}
+
+// softDeleteStatement converts a DELETE statement into an UPDATE statement that sets the supplied column to the
+// current time for rows that have not already been deleted.
+func softDeleteStatement(query string, column string) (string, error) {
+
+	query = trimStatement(query)
+	words := topLevelWords(query)
+
+	if len(words) < 3 || words[0].word != "DELETE" || words[1].word != "FROM" {
+		return "", errors.New("statement is not of the form DELETE FROM table")
+	}
+
+	end := len(query)
+
+	for _, w := range words[2:] {
+
+		if w.word == "USING" {
+			return "", errors.New("DELETE statements with a USING clause cannot be converted")
+		}
+
+		if w.word == "WHERE" || clauseTerminators[w.word] {
+			end = w.start
+			break
+		}
+	}
+
+	table := strings.TrimSpace(query[words[1].end:end])
+
+	update := fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP\n%s", table, column, query[end:])
+
+	return addPredicate(update, column+" IS NULL"), nil
+}
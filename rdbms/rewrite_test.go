@@ -0,0 +1,39 @@
+package rdbms
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestAddPredicate(t *testing.T) {
+
+	tests := map[string]string{
+		"SELECT id FROM artist":                                                                 "SELECT id FROM artist\nWHERE deleted IS NULL",
+		"SELECT id FROM artist WHERE a = 1 OR b = 2;":                                           "SELECT id FROM artist WHERE (a = 1 OR b = 2) AND deleted IS NULL",
+		"SELECT id FROM artist\nWHERE name = 'order by'\nORDER BY name\n":                       "SELECT id FROM artist\nWHERE (name = 'order by') AND deleted IS NULL\nORDER BY name",
+		"SELECT id FROM artist ORDER BY name LIMIT 10":                                          "SELECT id FROM artist\nWHERE deleted IS NULL\nORDER BY name LIMIT 10",
+		"SELECT id FROM artist WHERE id IN (SELECT a FROM b WHERE c = 1 ORDER BY a) -- where\n": "SELECT id FROM artist WHERE (id IN (SELECT a FROM b WHERE c = 1 ORDER BY a) -- where\n) AND deleted IS NULL",
+		"SELECT \"where\", 'it''s' FROM artist GROUP BY genre":                                  "SELECT \"where\", 'it''s' FROM artist\nWHERE deleted IS NULL\nGROUP BY genre",
+	}
+
+	for query, expected := range tests {
+		test.ExpectString(t, addPredicate(query, "deleted IS NULL"), expected)
+	}
+}
+
+func TestSoftDeleteStatement(t *testing.T) {
+
+	q, err := softDeleteStatement("DELETE FROM artist WHERE id = $1;", "deleted_at")
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "UPDATE artist SET deleted_at = CURRENT_TIMESTAMP\nWHERE (id = $1) AND deleted_at IS NULL")
+
+	q, err = softDeleteStatement("delete from artist", "deleted_at")
+	test.ExpectNil(t, err)
+	test.ExpectString(t, q, "UPDATE artist SET deleted_at = CURRENT_TIMESTAMP\nWHERE deleted_at IS NULL")
+
+	_, err = softDeleteStatement("DELETE FROM artist USING genre WHERE artist.genre = genre.id", "deleted_at")
+	test.ExpectNotNil(t, err)
+
+	_, err = softDeleteStatement("UPDATE artist SET name = 'a'", "deleted_at")
+	test.ExpectNotNil(t, err)
+}
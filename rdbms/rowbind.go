@@ -11,10 +11,15 @@ import (
 	"github.com/graniticio/granitic/v2/types"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // RowBinder is used to extract the data from the results of a SQL query and inject the data into a target data structure.
 type RowBinder struct {
+	// If true, columns with snake_case names (e.g. artist_name) are matched to fields with CamelCase names
+	// (e.g. ArtistName), ignoring case and underscores.
+	SnakeCaseColumns bool
 }
 
 /*
@@ -87,14 +92,54 @@ a) Finding a field whose name exactly matches the column name or alias.
 
 b) Finding a field with the 'column' struct tag with a value that exactly matches the column name or alias.
 
-A target field may be a bool, any native int/uint type, any native float type, a string or any of the
-	Granitic nilable types.
+c) If SnakeCaseColumns is true, finding a field whose name or 'column' tag matches the column name when case and
+underscores are ignored (so artist_name matches ArtistName).
+
+A field with the tag `column:"-"` is never populated.
+
+A target field may be a bool, any native int/uint type, any native float type, a string, a time.Time, a []byte (or
+a type based on []byte such as json.RawMessage), any of the Granitic nilable types or any type that implements
+sql.Scanner (including sql.NullString and the other sql.Null types).
+
+Fields that are structs (or pointers to structs) not covered above are populated from columns whose names are prefixed
+with the field's name and an underscore. For example, the field Artist.Name of the field
+
+	Artist ArtistDetail
+
+is populated from the column Artist_Name (or artist_name if SnakeCaseColumns is true). A 'column' tag on the struct
+field replaces the prefix (so `column:"a_"` would populate Artist.Name from a_Name). The fields of embedded structs are
+populated from unprefixed columns. A pointer to a struct is only allocated if at least one of its columns is not NULL.
 */
 func (rb *RowBinder) BindRows(r *sql.Rows, t interface{}) ([]interface{}, error) {
 
-	var err error
-	var columnNames []string
-	var targetScanners map[string]*scanner
+	scanners, err := rb.columnScanners(r, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0)
+
+	for r.Next() {
+
+		if err := r.Scan(scanners...); err != nil {
+			return nil, err
+		}
+
+		if built, err := rb.buildAndPopulate(t, scanners); err == nil {
+			results = append(results, built)
+		} else {
+			return nil, err
+		}
+
+	}
+
+	return results, nil
+}
+
+// columnScanners returns a scanner for each column in the supplied results, each of which will receive the column's value
+// for the field of the template struct that matches the column.
+func (rb *RowBinder) columnScanners(r *sql.Rows, t interface{}) ([]interface{}, error) {
 
 	if r == nil {
 		return nil, errors.New("nil *sql.Rows supplied")
@@ -104,137 +149,281 @@ func (rb *RowBinder) BindRows(r *sql.Rows, t interface{}) ([]interface{}, error)
 		return nil, errors.New("template must be a pointer to a struct")
 	}
 
-	if columnNames, err = r.Columns(); err != nil {
+	columnNames, err := r.Columns()
+
+	if err != nil {
 		return nil, err
 	}
 
-	colCount := len(columnNames)
-
-	targetScanners = rb.generateTargets(t)
+	targetScanners := rb.generateTargets(t)
 
-	scanners := make([]interface{}, colCount)
-
-	results := make([]interface{}, 0)
-
-	matchedTargets := 0
+	scanners := make([]interface{}, len(columnNames))
 
 	for i, cn := range columnNames {
 
 		scanner := targetScanners[cn]
 
+		if scanner == nil && rb.SnakeCaseColumns {
+			scanner = targetScanners[normaliseColumn(cn)]
+		}
+
 		if scanner == nil {
 			return nil, fmt.Errorf("no field available to receive column %s (no matching field name or 'column:' tag)", cn)
 		}
 
 		scanners[i] = scanner
-		matchedTargets++
-
 	}
 
-	if matchedTargets != colCount {
-		return nil, fmt.Errorf("not all of the columns in the results could be matched to fields on the template")
-	}
+	return scanners, nil
+}
 
-	for r.Next() {
+func (rb *RowBinder) buildAndPopulate(t interface{}, scanners []interface{}) (interface{}, error) {
 
-		if err := r.Scan(scanners...); err != nil {
-			return nil, err
+	r := reflect.New(reflect.TypeOf(t).Elem()).Interface()
+
+	rv := reflect.ValueOf(r).Elem()
+
+	for _, s := range scanners {
+
+		v := s.(*scanner)
+
+		if v.val == nil {
+			continue
 		}
 
-		if built, err := rb.buildAndPopulate(t, scanners); err == nil {
-			results = append(results, built)
-		} else {
+		if err := setField(fieldByIndex(rv, v.index), v); err != nil {
 			return nil, err
 		}
 
 	}
 
-	return results, nil
+	return r, nil
+
 }
 
-func (rb *RowBinder) buildAndPopulate(t interface{}, scanners []interface{}) (r interface{}, err error) {
+func setField(f reflect.Value, v *scanner) (err error) {
 
-	r = reflect.New(reflect.TypeOf(t).Elem()).Interface()
+	pv := reflect.ValueOf(v.val)
 
-	rv := reflect.ValueOf(r).Elem()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to set field %s with value of type %T", v.field, v.val)
+		}
+	}()
 
-	for _, s := range scanners {
+	if pv.Type() != f.Type() && convertible(pv.Type(), f.Type()) {
+		pv = pv.Convert(f.Type())
+	}
 
-		v := s.(*scanner)
+	f.Set(pv)
+
+	return nil
+}
+
+// convertible returns true if a value from the database can be safely converted to the type of the target field
+// (e.g. an int64 to an int or a []byte to a json.RawMessage)
+func convertible(from, to reflect.Type) bool {
+
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+
+	fk, tk := from.Kind(), to.Kind()
+
+	switch {
+	case fk == tk:
+		return true
+	case isInt(fk) && isInt(tk), isUint(fk) && isUint(tk), isFloat(fk) && isFloat(tk):
+		return true
+	}
+
+	return false
+}
 
-		f := rv.FieldByName(v.field)
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
 
-		if v.val != nil {
+// fieldByIndex returns the nested field with the supplied index, allocating any nil pointers to structs on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
 
-			pv := reflect.ValueOf(v.val)
+	for i, x := range index {
 
-			defer func() {
-				if r := recover(); r != nil {
-					err = fmt.Errorf("unable to set field %s with value of type %T", v.field, pv.Interface())
-				}
-			}()
+		if i > 0 && v.Kind() == reflect.Ptr {
 
-			f.Set(pv)
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
 		}
 
+		v = v.Field(x)
 	}
 
-	return r, err
-
+	return v
 }
 
 func (rb *RowBinder) generateTargets(t interface{}) map[string]*scanner {
 
 	targets := make(map[string]*scanner)
 
-	rv := reflect.ValueOf(t).Elem()
-	rt := reflect.TypeOf(t).Elem()
+	st := reflect.TypeOf(t).Elem()
+
+	rb.addTargets(targets, st, "", nil, []reflect.Type{st})
+
+	return targets
+}
+
+type nestedStruct struct {
+	t      reflect.Type
+	prefix string
+	index  []int
+}
+
+// addTargets creates a scanner for each field of the supplied struct type that can receive a column, then recurses into
+// nested and embedded structs (so that fields closer to the top level take precedence). ancestors holds the types
+// of the structs being recursed into, to prevent self-referencing types being followed indefinitely.
+func (rb *RowBinder) addTargets(targets map[string]*scanner, st reflect.Type, prefix string, parent []int, ancestors []reflect.Type) {
+
+	var nested []nestedStruct
+
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+
+		if f.PkgPath != "" && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			// Unexported field (or unexported embedded pointer, which cannot be allocated)
+			continue
+		}
 
-FieldLoop:
-	for i := 0; i < rt.NumField(); i++ {
-		f := rt.Field(i)
 		alias := f.Tag.Get("column")
 
-		fv := rv.FieldByName(f.Name)
+		if alias == "-" {
+			continue
+		}
 
-		if fv.CanSet() {
+		index := append(append([]int{}, parent...), i)
+		ft := f.Type
 
-			s := new(scanner)
-			s.field = f.Name
-			s.kind = fv.Kind()
+		s := new(scanner)
+		s.field = f.Name
+		s.index = index
+		s.kind = ft.Kind()
+		s.typ = ft
 
-			if s.kind == reflect.Ptr {
+		switch {
 
-				i := fv.Interface()
+		case implementsScanner(ft):
+			s.custom = true
 
-				switch i.(type) {
+		case s.kind == reflect.Ptr && nilableKind(ft) != unset:
+			s.nilable = nilableKind(ft)
 
-				case *types.NilableBool:
-					s.nilable = nilBool
-				case *types.NilableString:
-					s.nilable = nilString
-				case *types.NilableFloat64:
-					s.nilable = nilFloat
-				case *types.NilableInt64:
-					s.nilable = nilInt
-				default:
-					//Ignore other fields
-					continue FieldLoop
-				}
+		case ft == timeType, s.kind == reflect.Slice && ft.Elem().Kind() == reflect.Uint8:
+			// Populated directly
 
+		case s.kind == reflect.Struct, s.kind == reflect.Ptr && ft.Elem().Kind() == reflect.Struct:
+
+			nt := ft
+
+			if s.kind == reflect.Ptr {
+				nt = ft.Elem()
 			}
 
+			np := prefix
+
 			if alias != "" {
-				targets[alias] = s
-			} else {
-				targets[f.Name] = s
+				np += alias
+			} else if !f.Anonymous {
+				np += f.Name + "_"
+			}
+
+			nested = append(nested, nestedStruct{nt, np, index})
+			continue
+
+		case s.kind == reflect.Ptr, s.kind == reflect.Func, s.kind == reflect.Chan, s.kind == reflect.Interface:
+			//Ignore other fields
+			continue
+		}
+
+		name := f.Name
+
+		if alias != "" {
+			name = alias
+		}
+
+		name = prefix + name
+
+		if _, found := targets[name]; found {
+			// A field closer to the top level takes precedence
+			continue
+		}
+
+		targets[name] = s
 
+		if rb.SnakeCaseColumns {
+			n := normaliseColumn(name)
+
+			if _, found := targets[n]; !found {
+				targets[n] = s
 			}
+		}
+	}
 
+NestedLoop:
+	for _, n := range nested {
+
+		for _, a := range ancestors {
+			if a == n.t {
+				continue NestedLoop
+			}
 		}
+
+		rb.addTargets(targets, n.t, n.prefix, n.index, append(ancestors, n.t))
 	}
+}
 
-	return targets
+// normaliseColumn converts a column or field name to a form in which snake_case columns and CamelCase fields match
+func normaliseColumn(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// implementsScanner returns true if a field of the supplied type (or a pointer to it) implements sql.Scanner.
+func implementsScanner(t reflect.Type) bool {
+
+	if t.Kind() == reflect.Ptr {
+		return t.Implements(scannerType) && nilableKind(t) == unset
+	}
+
+	return reflect.PtrTo(t).Implements(scannerType)
+}
+
+func nilableKind(t reflect.Type) nilableType {
+
+	switch reflect.Zero(t).Interface().(type) {
+	case *types.NilableBool:
+		return nilBool
+	case *types.NilableString:
+		return nilString
+	case *types.NilableFloat64:
+		return nilFloat
+	case *types.NilableInt64:
+		return nilInt
+	}
+
+	return unset
 }
 
 type nilableType int
@@ -249,13 +438,38 @@ const (
 
 type scanner struct {
 	kind    reflect.Kind
+	typ     reflect.Type
 	field   string
+	index   []int
 	nilable nilableType
+	custom  bool
 	val     interface{}
 }
 
 func (s *scanner) Scan(src interface{}) error {
 
+	if s.custom {
+		return s.scanCustom(src)
+	}
+
+	if src == nil {
+		s.val = nil
+		return nil
+	}
+
+	if s.typ == timeType {
+		return s.toTime(src)
+	}
+
+	if s.kind == reflect.Slice {
+
+		if b, found := src.([]byte); found {
+			// The driver may reuse the slice for the next row
+			s.val = append([]byte{}, b...)
+			return nil
+		}
+	}
+
 	if b, found := src.([]byte); found {
 		sv := string(b)
 
@@ -265,6 +479,8 @@ func (s *scanner) Scan(src interface{}) error {
 
 		s.val = sv
 
+	} else if sv, found := src.(string); found && s.kind != reflect.String && s.kind != reflect.Invalid {
+		return s.convert(sv)
 	} else {
 		s.val = src
 	}
@@ -272,6 +488,75 @@ func (s *scanner) Scan(src interface{}) error {
 	return nil
 }
 
+// scanCustom passes the value from the database to a new instance of a field type that implements sql.Scanner
+func (s *scanner) scanCustom(src interface{}) error {
+
+	if s.kind == reflect.Ptr {
+
+		if src == nil {
+			s.val = nil
+			return nil
+		}
+
+		nv := reflect.New(s.typ.Elem())
+
+		if err := nv.Interface().(sql.Scanner).Scan(src); err != nil {
+			return err
+		}
+
+		s.val = nv.Interface()
+
+		return nil
+	}
+
+	nv := reflect.New(s.typ)
+
+	if err := nv.Interface().(sql.Scanner).Scan(src); err != nil {
+		return err
+	}
+
+	s.val = nv.Elem().Interface()
+
+	return nil
+}
+
+// Layouts used to parse times from drivers that return them as text
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func (s *scanner) toTime(src interface{}) error {
+
+	var sv string
+
+	switch v := src.(type) {
+	case time.Time:
+		s.val = v
+		return nil
+	case []byte:
+		sv = string(v)
+	case string:
+		sv = v
+	default:
+		s.val = src
+		return nil
+	}
+
+	for _, l := range timeLayouts {
+
+		if t, err := time.Parse(l, sv); err == nil {
+			s.val = t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("RowBinder: unable to parse %s as a time for field %s", sv, s.field)
+}
+
 func (s *scanner) convert(sv string) error {
 
 	switch s.kind {
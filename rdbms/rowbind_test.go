@@ -1,12 +1,17 @@
 package rdbms
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/types"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestScan(t *testing.T) {
@@ -264,3 +269,185 @@ func TestBindRowsBasicErrors(t *testing.T) {
 	fmt.Println(err)
 
 }
+
+func TestNestedStructs(t *testing.T) {
+
+	rb := new(RowBinder)
+
+	drv.colNames = []string{"ID", "Name", "Artist_Name", "a_Name", "Label_Name", "Created"}
+	drv.rowData = [][]driver.Value{
+		{int64(1), "Album", "Artist", "Alias", nil, "2019-03-04 05:06:07"},
+		{int64(2), "Other", "Artist", "Alias", "Label", []byte("2019-03-04T05:06:07Z")},
+	}
+
+	r, _ := db.Query("")
+
+	results, err := rb.BindRows(r, new(album))
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(results), 2)
+
+	a := results[0].(*album)
+
+	test.ExpectInt(t, a.ID, 1)
+	test.ExpectString(t, a.Name, "Album")
+	test.ExpectString(t, a.Artist.Name, "Artist")
+	test.ExpectString(t, a.Aliased.Name, "Alias")
+	test.ExpectInt(t, a.Created.Year(), 2019)
+
+	if a.Label != nil {
+		t.Errorf("Expected nested pointer with only NULL columns to remain nil")
+	}
+
+	a = results[1].(*album)
+
+	if a.Label == nil || a.Label.Name != "Label" {
+		t.Errorf("Expected nested pointer to be populated")
+	}
+}
+
+func TestSnakeCaseColumns(t *testing.T) {
+
+	rb := new(RowBinder)
+	rb.SnakeCaseColumns = true
+
+	drv.colNames = []string{"id", "name", "artist_name", "label_name", "release_id"}
+	drv.rowData = [][]driver.Value{{int64(1), "Album", "Artist", "Label", int64(9)}}
+
+	r, _ := db.Query("")
+
+	results, err := rb.BindRows(r, new(album))
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(results), 1)
+
+	a := results[0].(*album)
+
+	test.ExpectString(t, a.Artist.Name, "Artist")
+	test.ExpectString(t, a.Label.Name, "Label")
+	test.ExpectInt(t, int(a.ReleaseID), 9)
+
+	drv.colNames = []string{"artist_name"}
+	drv.rowData = [][]driver.Value{{"Artist"}}
+
+	r, _ = db.Query("")
+
+	_, err = new(RowBinder).BindRows(r, new(album))
+	test.ExpectNotNil(t, err)
+}
+
+func TestScannerTypes(t *testing.T) {
+
+	rb := new(RowBinder)
+
+	drv.colNames = []string{"Nickname", "Plays", "Meta", "Raw", "Custom", "CustomPtr"}
+	drv.rowData = [][]driver.Value{
+		{nil, int64(3), []byte(`{"a":1}`), []byte{1, 2}, "abc", nil},
+		{"Nick", nil, nil, nil, "def", "ghi"},
+	}
+
+	r, _ := db.Query("")
+
+	results, err := rb.BindRows(r, new(scannerTarget))
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(results), 2)
+
+	st := results[0].(*scannerTarget)
+
+	test.ExpectBool(t, st.Nickname.Valid, false)
+	test.ExpectInt(t, int(st.Plays.Int64), 3)
+	test.ExpectString(t, string(st.Meta), `{"a":1}`)
+	test.ExpectInt(t, len(st.Raw), 2)
+	test.ExpectString(t, st.Custom.v, "ABC")
+
+	if st.CustomPtr != nil {
+		t.Errorf("Expected NULL to leave pointer to sql.Scanner nil")
+	}
+
+	st = results[1].(*scannerTarget)
+
+	test.ExpectString(t, st.Nickname.String, "Nick")
+	test.ExpectBool(t, st.Plays.Valid, false)
+	test.ExpectString(t, st.CustomPtr.v, "GHI")
+
+	drv.colNames = []string{"Custom"}
+	drv.rowData = [][]driver.Value{{int64(1)}}
+
+	r, _ = db.Query("")
+
+	_, err = rb.BindRows(r, new(scannerTarget))
+	test.ExpectNotNil(t, err)
+
+	// Fields tagged with column:"-" are never populated
+	drv.colNames = []string{"Ignored"}
+	drv.rowData = [][]driver.Value{{"x"}}
+
+	r, _ = db.Query("")
+
+	_, err = rb.BindRows(r, new(scannerTarget))
+	test.ExpectNotNil(t, err)
+}
+
+func TestSelfReferencingStruct(t *testing.T) {
+
+	targets := new(RowBinder).generateTargets(new(node))
+
+	if targets["Name"] == nil || targets["Parent_Name"] != nil {
+		t.Errorf("Unexpected targets %v", targets)
+	}
+}
+
+type album struct {
+	release
+	Name    string
+	Created time.Time
+	Artist  artist
+	Aliased artist `column:"a_"`
+	Label   *label
+}
+
+type release struct {
+	ID        int
+	ReleaseID int64
+}
+
+type artist struct {
+	Name string
+}
+
+type label struct {
+	Name string
+}
+
+type node struct {
+	Name   string
+	Parent *node
+}
+
+type scannerTarget struct {
+	Nickname  sql.NullString
+	Plays     sql.NullInt64
+	Meta      json.RawMessage
+	Raw       []byte
+	Custom    upperScanner
+	CustomPtr *upperScanner
+	Ignored   string `column:"-"`
+}
+
+type upperScanner struct {
+	v string
+}
+
+func (us *upperScanner) Scan(src interface{}) error {
+
+	s, found := src.(string)
+
+	if !found {
+		return errors.New("not a string")
+	}
+
+	us.v = strings.ToUpper(s)
+
+	return nil
+}
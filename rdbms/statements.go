@@ -0,0 +1,182 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+func newStatementCache() *statementCache {
+	sc := new(statementCache)
+	sc.statements = make(map[statementKey]*cachedStatement)
+	sc.preparing = make(map[statementKey]chan struct{})
+
+	return sc
+}
+
+// statementKey identifies a prepared statement by the sql.DB it was prepared against, the ID of the query it was built
+// from and its number of placeholders (which varies for templates that expand slices into lists of placeholders).
+type statementKey struct {
+	db           *sql.DB
+	qid          string
+	placeholders int
+}
+
+// cachedStatement is a prepared statement and the number of statements currently being started with it. A statement
+// that has been replaced is closed once no statements are being started with it.
+type cachedStatement struct {
+	query    string
+	stmt     *sql.Stmt
+	users    int
+	replaced bool
+}
+
+// statementCache holds the prepared statements created for queries built with bind parameters so that they can be
+// reused by every ManagedClient created by a ClientManager. At most one statement is held for each query ID and number
+// of placeholders; if the text of the query changes (because it has optional sections or its template has been
+// reloaded) the previous statement is closed and replaced.
+//
+// Statements are prepared without holding mu, so a slow or unreachable database does not block queries that already
+// have a statement. Callers needing a statement that is being prepared by another goroutine wait for it to finish.
+type statementCache struct {
+	mu         sync.Mutex
+	statements map[statementKey]*cachedStatement
+	// preparing holds a channel for each key with a statement being prepared, closed when preparation finishes
+	preparing map[statementKey]chan struct{}
+	// generation is incremented whenever the cache is cleared
+	generation int
+}
+
+// prepare returns a prepared statement for the supplied query and a function that must be called once the statement
+// has been executed (any sql.Rows returned by the statement do not need to have been closed).
+func (sc *statementCache) prepare(ctx context.Context, db *sql.DB, qid string, query string, placeholders int) (*sql.Stmt, func(), error) {
+
+	k := statementKey{db, qid, placeholders}
+
+	sc.mu.Lock()
+
+	for {
+		if cs := sc.statements[k]; cs != nil && cs.query == query {
+			return sc.use(cs)
+		}
+
+		done := sc.preparing[k]
+
+		if done == nil {
+			break
+		}
+
+		sc.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		sc.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	sc.preparing[k] = done
+	generation := sc.generation
+
+	sc.mu.Unlock()
+
+	s, err := db.PrepareContext(ctx, query)
+
+	sc.mu.Lock()
+
+	delete(sc.preparing, k)
+	close(done)
+
+	if err != nil {
+		sc.mu.Unlock()
+		return nil, nil, err
+	}
+
+	cs := &cachedStatement{query: query, stmt: s}
+
+	if sc.generation != generation {
+		// The cache was cleared while the statement was being prepared, so it is used for this query only
+		cs.replaced = true
+		return sc.use(cs)
+	}
+
+	if existing := sc.statements[k]; existing != nil {
+		sc.retire(existing)
+	}
+
+	sc.statements[k] = cs
+
+	return sc.use(cs)
+}
+
+// use records that a statement is being started with the cached statement and returns the statement and the function
+// that releases it. Must be called while holding mu, which is released.
+func (sc *statementCache) use(cs *cachedStatement) (*sql.Stmt, func(), error) {
+
+	cs.users++
+
+	sc.mu.Unlock()
+
+	release := func() {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+
+		cs.users--
+
+		if cs.replaced && cs.users == 0 {
+			cs.stmt.Close()
+		}
+	}
+
+	return cs.stmt, release, nil
+}
+
+// retire closes the statement if it is not in use, or marks it to be closed once it is no longer in use. Must be called
+// while holding mu.
+func (sc *statementCache) retire(cs *cachedStatement) error {
+
+	cs.replaced = true
+
+	if cs.users == 0 {
+		return cs.stmt.Close()
+	}
+
+	return nil
+}
+
+// clear closes or retires all statements, so that new statements are prepared the next time each query is executed.
+func (sc *statementCache) clear() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var firstErr error
+
+	sc.generation++
+
+	for k, cs := range sc.statements {
+		if err := sc.retire(cs); err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		delete(sc.statements, k)
+	}
+
+	return firstErr
+}
+
+func (sc *statementCache) size() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return len(sc.statements)
+}
+
+func (sc *statementCache) close() error {
+	return sc.clear()
+}
@@ -0,0 +1,359 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBoundParametersPassedAsArgs(t *testing.T) {
+
+	bqm := new(bindingQueryManager)
+
+	c := newRdbmsClient(db, bqm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.consumed()
+	_, err := c.UpdateQIDParam("UPDATE_NAME", "name", "O'Brien")
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, bqm.lastQID, "UPDATE_NAME")
+	test.ExpectInt(t, len(drv.lastArgs), 1)
+	test.ExpectString(t, drv.lastArgs[0].(string), "O'Brien")
+
+	var id int64
+
+	err = c.InsertCaptureQIDParams("INSERT_NAME", &id, map[string]interface{}{"name": "Smith"})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, int(id), 1)
+	test.ExpectString(t, drv.lastArgs[0].(string), "Smith")
+
+	c.lastIDArgs = nil
+
+	err = c.InsertCaptureQIDParams("INSERT_NAME", &id, map[string]interface{}{"name": "Smith"})
+	test.ExpectNotNil(t, err)
+}
+
+func TestPreparedStatementsReused(t *testing.T) {
+
+	bqm := new(bindingQueryManager)
+	sc := newStatementCache()
+
+	drv.prepared = 0
+
+	for i := 0; i < 3; i++ {
+		c := newRdbmsClient(db, bqm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+		c.statements = sc
+		c.ctx = context.Background()
+
+		drv.consumed()
+		r, err := c.SelectQIDParam("SELECT_NAME", "name", "Jones")
+
+		test.ExpectNil(t, err)
+		r.Close()
+	}
+
+	test.ExpectInt(t, sc.size(), 1)
+	test.ExpectInt(t, drv.prepared, 1)
+
+	c := newRdbmsClient(db, bqm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.statements = sc
+
+	test.ExpectNil(t, c.StartTransaction())
+
+	_, err := c.DeleteQIDParam("DELETE_NAME", "name", "Jones")
+	test.ExpectNil(t, err)
+	test.ExpectNil(t, c.CommitTransaction())
+
+	test.ExpectInt(t, sc.size(), 2)
+
+	test.ExpectNil(t, sc.close())
+	test.ExpectInt(t, sc.size(), 0)
+}
+
+func TestPreparedStatementsReusedForCapturedInserts(t *testing.T) {
+
+	bqm := new(bindingQueryManager)
+	sc := newStatementCache()
+
+	c := newRdbmsClient(db, bqm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.statements = sc
+
+	drv.prepared = 0
+
+	var id int64
+
+	for i := 0; i < 2; i++ {
+		drv.consumed()
+		test.ExpectNil(t, c.InsertCaptureQIDParams("INSERT_NAME", &id, map[string]interface{}{"name": "Smith"}))
+	}
+
+	test.ExpectInt(t, int(id), 1)
+	test.ExpectInt(t, sc.size(), 1)
+	test.ExpectInt(t, drv.prepared, 1)
+
+	// Queries executed directly are not prepared
+	_, err := c.Exec("DELETE FROM names WHERE name = ?", "Smith")
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, sc.size(), 1)
+}
+
+func TestChangedStatementsReplaced(t *testing.T) {
+
+	bqm := new(bindingQueryManager)
+	sc := newStatementCache()
+
+	c := newRdbmsClient(db, bqm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.statements = sc
+
+	drv.prepared = 0
+	drv.closed = 0
+
+	drv.consumed()
+	_, err := c.UpdateQIDParam("UPDATE_NAME", "name", "Jones")
+	test.ExpectNil(t, err)
+
+	// Same query ID and number of placeholders but different text (e.g. the template has been edited)
+	bqm.suffix = " AND active = 1"
+
+	drv.consumed()
+	_, err = c.UpdateQIDParam("UPDATE_NAME", "name", "Jones")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, sc.size(), 1)
+	test.ExpectInt(t, drv.prepared, 2)
+	test.ExpectInt(t, drv.closed, 1)
+
+	// Statements in use when the cache is cleared are closed once they have been executed
+	s, release, err := sc.prepare(context.Background(), db, "UPDATE_NAME", "UPDATE_NAME WHERE name = ? AND active = 1", 1)
+	test.ExpectNil(t, err)
+
+	test.ExpectNil(t, sc.clear())
+	test.ExpectInt(t, sc.size(), 0)
+	test.ExpectInt(t, drv.closed, 1)
+
+	drv.consumed()
+	_, err = s.Exec("Jones")
+	test.ExpectNil(t, err)
+
+	release()
+	test.ExpectInt(t, drv.closed, 2)
+}
+
+func TestStatementsPreparedWithoutBlockingCache(t *testing.T) {
+
+	gd := newGatedDriver()
+	gdb := sql.OpenDB(gd)
+	defer gdb.Close()
+
+	sc := newStatementCache()
+
+	var wg sync.WaitGroup
+
+	slow := func() {
+		defer wg.Done()
+
+		s, release, err := sc.prepare(context.Background(), gdb, "SLOW", "SLOW QUERY", 0)
+
+		if err != nil {
+			t.Errorf("Unexpected error %s", err)
+			return
+		}
+
+		s.Close()
+		release()
+	}
+
+	wg.Add(2)
+	go slow()
+
+	<-gd.started
+
+	go slow()
+
+	// Other queries can be prepared while a statement is being prepared
+	prepared := make(chan error)
+
+	go func() {
+		_, release, err := sc.prepare(context.Background(), gdb, "FAST", "FAST QUERY", 0)
+
+		if err == nil {
+			release()
+		}
+
+		prepared <- err
+	}()
+
+	select {
+	case err := <-prepared:
+		test.ExpectNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Statement preparation blocked by another statement being prepared")
+	}
+
+	close(gd.release)
+	wg.Wait()
+
+	test.ExpectInt(t, gd.count("SLOW QUERY"), 1)
+	test.ExpectInt(t, sc.size(), 2)
+
+	// Statements prepared while the cache is cleared are not cached
+	gd.release = make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, release, err := sc.prepare(context.Background(), gdb, "SLOW", "SLOW QUERY REVISED", 0)
+
+		if err != nil {
+			t.Errorf("Unexpected error %s", err)
+			return
+		}
+
+		release()
+	}()
+
+	<-gd.started
+
+	test.ExpectNil(t, sc.clear())
+	close(gd.release)
+	wg.Wait()
+
+	test.ExpectInt(t, sc.size(), 0)
+}
+
+func newGatedDriver() *gatedDriver {
+	gd := new(gatedDriver)
+	gd.started = make(chan string, 10)
+	gd.release = make(chan struct{})
+	gd.prepared = make(map[string]int)
+
+	return gd
+}
+
+// gatedDriver blocks the preparation of queries starting with SLOW until release is closed
+type gatedDriver struct {
+	mu       sync.Mutex
+	started  chan string
+	release  chan struct{}
+	prepared map[string]int
+}
+
+func (gd *gatedDriver) count(query string) int {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+
+	return gd.prepared[query]
+}
+
+func (gd *gatedDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &gatedConn{gd: gd}, nil
+}
+
+func (gd *gatedDriver) Driver() driver.Driver {
+	return drv
+}
+
+type gatedConn struct {
+	gd *gatedDriver
+}
+
+func (gc *gatedConn) Prepare(query string) (driver.Stmt, error) {
+
+	gc.gd.mu.Lock()
+	gc.gd.prepared[query]++
+	release := gc.gd.release
+	gc.gd.mu.Unlock()
+
+	if strings.HasPrefix(query, "SLOW") {
+		gc.gd.started <- query
+		<-release
+	}
+
+	return newMockStmt(new(mockDriver)), nil
+}
+
+func (gc *gatedConn) Close() error {
+	return nil
+}
+
+func (gc *gatedConn) Begin() (driver.Tx, error) {
+	return new(mockTx), nil
+}
+
+func TestStatementsClearedOnTemplateReload(t *testing.T) {
+
+	rqm := new(reloadingQueryManager)
+
+	cm := new(GraniticRdbmsClientManager)
+	cm.Configuration = new(ClientManagerConfig)
+	cm.Configuration.Provider = &replicaProvider{db: db}
+	cm.QueryManager = rqm
+	cm.FrameworkLogger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+	cm.SharedLog = cm.FrameworkLogger
+
+	test.ExpectNil(t, cm.StartComponent())
+	defer cm.Stop()
+
+	c, err := cm.Client()
+	test.ExpectNil(t, err)
+
+	drv.consumed()
+	_, err = c.UpdateQIDParam("UPDATE_NAME", "name", "Jones")
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, cm.statements.size(), 1)
+	test.ExpectInt(t, len(rqm.listeners), 1)
+
+	rqm.listeners[0]()
+
+	test.ExpectInt(t, cm.statements.size(), 0)
+}
+
+type reloadingQueryManager struct {
+	bindingQueryManager
+	listeners []func()
+}
+
+func (rqm *reloadingQueryManager) OnReload(f func()) {
+	rqm.listeners = append(rqm.listeners, f)
+}
+
+type bindingQueryManager struct {
+	lastQID      string
+	suffix       string
+	placeholders dsquery.PlaceholderStyle
+}
+
+func (bqm *bindingQueryManager) Placeholders() dsquery.PlaceholderStyle {
+	return bqm.placeholders
+}
+
+func (bqm *bindingQueryManager) BuildQueryFromID(qid string, params map[string]interface{}) (string, error) {
+	return qid, nil
+}
+
+func (bqm *bindingQueryManager) FragmentFromID(qid string) (string, error) {
+	return qid, nil
+}
+
+func (bqm *bindingQueryManager) BindsParameters() bool {
+	return true
+}
+
+func (bqm *bindingQueryManager) BuildParameterisedQueryFromID(qid string, params map[string]interface{}) (string, []interface{}, error) {
+	bqm.lastQID = qid
+
+	return qid + " WHERE name = ?" + bqm.suffix, []interface{}{params["name"]}, nil
+}
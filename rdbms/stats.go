@@ -0,0 +1,180 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/instrument"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue replaces the value of a redacted parameter when a slow query is logged.
+const RedactedValue = "[REDACTED]"
+
+// NewQueryStats creates an empty QueryStats.
+func NewQueryStats() *QueryStats {
+
+	qs := new(QueryStats)
+	qs.queries = make(map[string]*queryStat)
+
+	return qs
+}
+
+// QueryStats records the number of times each query (identified by its query ID) has been executed, the number of
+// executions that returned an error and the distribution of execution times. Statements executed with the Exec, Query
+// and QueryRow pass-through methods of ManagedClient are not recorded. QueryStats is goroutine safe.
+type QueryStats struct {
+	mu      sync.RWMutex
+	queries map[string]*queryStat
+}
+
+type queryStat struct {
+	mu      sync.Mutex
+	count   uint64
+	errors  uint64
+	latency *instrument.Histogram
+}
+
+// QueryStatistics is a point-in-time copy of the statistics recorded for a single query.
+type QueryStatistics struct {
+	// The number of times the query was executed.
+	Count uint64
+
+	// The number of executions that returned an error.
+	Errors uint64
+
+	// The distribution of execution times. For queries returning rows, this is the time taken for the database to start
+	// returning rows, not the time taken to read them.
+	Latency instrument.HistogramSnapshot
+}
+
+// Record adds a single execution of the identified query.
+func (qs *QueryStats) Record(qid string, d time.Duration, err error) {
+
+	qs.mu.RLock()
+	s := qs.queries[qid]
+	qs.mu.RUnlock()
+
+	if s == nil {
+		qs.mu.Lock()
+
+		if s = qs.queries[qid]; s == nil {
+			s = &queryStat{latency: instrument.NewHistogram(nil)}
+			qs.queries[qid] = s
+		}
+
+		qs.mu.Unlock()
+	}
+
+	s.latency.Observe(d)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+
+	if err != nil {
+		s.errors++
+	}
+}
+
+// Snapshot returns a copy of the statistics recorded for each query, keyed by query ID.
+func (qs *QueryStats) Snapshot() map[string]QueryStatistics {
+
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+
+	snap := make(map[string]QueryStatistics, len(qs.queries))
+
+	for qid, s := range qs.queries {
+
+		s.mu.Lock()
+		snap[qid] = QueryStatistics{Count: s.count, Errors: s.errors, Latency: s.latency.Snapshot()}
+		s.mu.Unlock()
+	}
+
+	return snap
+}
+
+// Reset discards all recorded statistics.
+func (qs *QueryStats) Reset() {
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	qs.queries = make(map[string]*queryStat)
+}
+
+// SlowQueryPolicy determines which queries executed by a ManagedClient are considered slow and how they are logged.
+type SlowQueryPolicy struct {
+	// Queries taking at least this long are logged at WARN. Zero disables logging of slow queries.
+	Threshold time.Duration
+
+	// If true, the parameters supplied to a slow query are included in the log message.
+	LogParameters bool
+
+	// The names of parameters (case insensitive) whose values are replaced with RedactedValue when parameters are logged.
+	Redact []string
+}
+
+// observe records the execution of a query in the client's QueryStats and logs the query if it was slow.
+func (rc *ManagedClient) observe(qid string, params map[string]interface{}, start time.Time, err error) {
+
+	if qid == "" {
+		return
+	}
+
+	d := time.Since(start)
+
+	if rc.stats != nil {
+		rc.stats.Record(qid, d, err)
+	}
+
+	sp := rc.slow
+
+	if sp == nil || sp.Threshold <= 0 || d < sp.Threshold {
+		return
+	}
+
+	if sp.LogParameters {
+		rc.FrameworkLogger.LogWarnf("Slow query %s took %s (threshold %s) with parameters %s", qid, d, sp.Threshold,
+			describeParams(params, sp.Redact))
+	} else {
+		rc.FrameworkLogger.LogWarnf("Slow query %s took %s (threshold %s)", qid, d, sp.Threshold)
+	}
+}
+
+// describeParams formats the supplied parameters as name=value pairs, sorted by name, replacing the values of
+// any parameters named in redact with RedactedValue.
+func describeParams(params map[string]interface{}, redact []string) string {
+
+	names := make([]string, 0, len(params))
+
+	for n := range params {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+
+	for i, n := range names {
+
+		var v interface{} = params[n]
+
+		for _, r := range redact {
+			if strings.EqualFold(r, n) {
+				v = RedactedValue
+				break
+			}
+		}
+
+		pairs[i] = fmt.Sprintf("%s=%v", n, v)
+	}
+
+	return "[" + strings.Join(pairs, " ") + "]"
+}
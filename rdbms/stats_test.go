@@ -0,0 +1,107 @@
+package rdbms
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryStatsRecordAndReset(t *testing.T) {
+
+	qs := NewQueryStats()
+
+	qs.Record("A", time.Millisecond, nil)
+	qs.Record("A", 3*time.Millisecond, errors.New("failed"))
+	qs.Record("B", time.Millisecond, nil)
+
+	snap := qs.Snapshot()
+
+	test.ExpectInt(t, len(snap), 2)
+	test.ExpectInt(t, int(snap["A"].Count), 2)
+	test.ExpectInt(t, int(snap["A"].Errors), 1)
+	test.ExpectInt(t, int(snap["B"].Count), 1)
+	test.ExpectInt(t, int(snap["B"].Errors), 0)
+
+	if snap["A"].Latency.Max < 3*time.Millisecond {
+		t.Errorf("Unexpected max latency %s", snap["A"].Latency.Max)
+	}
+
+	qs.Reset()
+
+	test.ExpectInt(t, len(qs.Snapshot()), 0)
+}
+
+func TestDescribeParamsRedacts(t *testing.T) {
+
+	p := map[string]interface{}{"name": "Ann", "Password": "secret", "id": 1}
+
+	test.ExpectString(t, describeParams(p, []string{"password"}), "[Password=[REDACTED] id=1 name=Ann]")
+	test.ExpectString(t, describeParams(nil, nil), "[]")
+}
+
+func TestSlowQueriesLogged(t *testing.T) {
+
+	wl := new(warnLogger)
+	wl.Logger = logging.CreateAnonymousLogger("testLog", logging.Fatal)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, wl)
+	c.stats = NewQueryStats()
+	c.slow = &SlowQueryPolicy{Threshold: time.Second, LogParameters: true, Redact: []string{"pass"}}
+
+	p := map[string]interface{}{"user": "ann", "pass": "secret"}
+
+	c.observe("FAST", p, time.Now(), nil)
+	test.ExpectInt(t, len(wl.messages), 0)
+
+	c.observe("SLOW", p, time.Now().Add(-2*time.Second), nil)
+	test.ExpectInt(t, len(wl.messages), 1)
+
+	m := wl.messages[0]
+
+	if !strings.HasPrefix(m, "Slow query SLOW took") || !strings.HasSuffix(m, "with parameters [pass=[REDACTED] user=ann]") {
+		t.Errorf("Unexpected message %s", m)
+	}
+
+	c.slow.LogParameters = false
+	c.observe("SLOW", p, time.Now().Add(-2*time.Second), errors.New("failed"))
+
+	if strings.Contains(wl.messages[1], "parameters") {
+		t.Errorf("Unexpected message %s", wl.messages[1])
+	}
+
+	// Queries without an ID are not recorded
+	c.observe("", p, time.Now().Add(-2*time.Second), nil)
+	test.ExpectInt(t, len(wl.messages), 2)
+
+	snap := c.stats.Snapshot()
+
+	test.ExpectInt(t, len(snap), 2)
+	test.ExpectInt(t, int(snap["SLOW"].Count), 2)
+	test.ExpectInt(t, int(snap["SLOW"].Errors), 1)
+}
+
+func TestSelectRecordsStatistics(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.stats = NewQueryStats()
+
+	drv.consumed()
+	r, err := c.SelectQIDParams("SQ")
+	test.ExpectNil(t, err)
+	r.Close()
+
+	test.ExpectInt(t, int(c.stats.Snapshot()["SQ"].Count), 1)
+}
+
+type warnLogger struct {
+	logging.Logger
+	messages []string
+}
+
+func (wl *warnLogger) LogWarnf(format string, a ...interface{}) {
+	wl.messages = append(wl.messages, fmt.Sprintf(format, a...))
+}
@@ -64,7 +64,7 @@ func ParamsFromTags(sources ...interface{}) (map[string]interface{}, error) {
 ParamsFromFieldsOrTags takes one or more objects (that must be a map[string]interface{} or a pointer to a struct) and
 returns a single map[string]interface{}. Keys and values are copied from supplied map[string]interface{}s as-is. For
 pointers to structs, the object will have its fields added to the map  using field names as keys (unless the dbparam tag is set)
-and the field value as the map value.
+and the field value as the map value. Fields with zero values and empty slices are not added to the map.
 
 An error is returned if one of the arguments is not a map[string]interface{} pointer to a struct.
 */
@@ -107,7 +107,13 @@ ArgLoop:
 			tagVal := field.Tag.Get(DBParamTag)
 			fieldValInterface := argVal.FieldByName(field.Name).Interface()
 
-			if reflecttools.IsSliceOrArray(fieldValInterface) || reflecttools.IsZero(fieldValInterface) {
+			if reflecttools.IsSliceOrArray(fieldValInterface) {
+
+				if reflect.ValueOf(fieldValInterface).Len() == 0 {
+					continue FieldLoop
+				}
+
+			} else if reflecttools.IsZero(fieldValInterface) {
 				continue FieldLoop
 			}
 
@@ -128,6 +134,46 @@ ArgLoop:
 	return p, nil
 }
 
+// expandStructSlices replaces any values in the supplied map that are slices of structs (or pointers to structs) with
+// a slice of maps created by ParamsFromFieldsOrTags, so that they can be used in the range sections of query templates.
+func expandStructSlices(p map[string]interface{}) error {
+
+	for k, v := range p {
+
+		if v == nil || !reflecttools.IsSliceOrArray(v) {
+			continue
+		}
+
+		rv := reflect.ValueOf(v)
+		et := rv.Type().Elem()
+
+		if et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+
+		if et.Kind() != reflect.Struct {
+			continue
+		}
+
+		elements := make([]map[string]interface{}, rv.Len())
+
+		for i := range elements {
+
+			ep, err := ParamsFromFieldsOrTags(rv.Index(i).Interface())
+
+			if err != nil {
+				return err
+			}
+
+			elements[i] = ep
+		}
+
+		p[k] = elements
+	}
+
+	return nil
+}
+
 func mergeMapInto(source, target map[string]interface{}) {
 
 	for k, v := range source {
@@ -108,3 +108,40 @@ func TestMixTypes(t *testing.T) {
 	test.ExpectNil(t, p["E"])
 
 }
+
+type sliceParamsTest struct {
+	IDs     []int64
+	Empty   []string
+	Artists []*artistParam
+}
+
+type artistParam struct {
+	Name  string `dbparam:"name"`
+	Genre string
+}
+
+func TestSliceParams(t *testing.T) {
+
+	s := new(sliceParamsTest)
+	s.IDs = []int64{1, 2}
+	s.Empty = []string{}
+	s.Artists = []*artistParam{{Name: "Blur", Genre: "Pop"}, {Name: "Oasis"}}
+
+	p, err := ParamsFromFieldsOrTags(s)
+	test.ExpectNil(t, err)
+
+	test.ExpectInt(t, len(p), 2)
+	test.ExpectInt(t, len(p["IDs"].([]int64)), 2)
+	test.ExpectNil(t, p["Empty"])
+
+	err = expandStructSlices(p)
+	test.ExpectNil(t, err)
+
+	a := p["Artists"].([]map[string]interface{})
+
+	test.ExpectInt(t, len(a), 2)
+	test.ExpectString(t, a[0]["name"].(string), "Blur")
+	test.ExpectString(t, a[0]["Genre"].(string), "Pop")
+	test.ExpectNil(t, a[1]["Genre"])
+	test.ExpectInt(t, len(p["IDs"].([]int64)), 2)
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TransactionFunc is a function that executes statements using the supplied Client as part of a transaction started by
+// Client.WithTransaction. Returning an error causes the transaction (or savepoint) to be rolled back.
+type TransactionFunc func(Client) error
+
+// RetryableErrorProvider is an optional interface for DatabaseProvider implementations that can identify the errors
+// returned by their driver that mean a transaction failed because of a serialization failure or deadlock and can be
+// safely retried. If the provider does not implement this interface, IsRetryableError is used.
+type RetryableErrorProvider interface {
+	RetryableError(err error) bool
+}
+
+// TransactionRetryPolicy controls whether and how often WithTransaction retries a transaction that failed because of a
+// serialization failure or deadlock.
+type TransactionRetryPolicy struct {
+	// The maximum number of times a transaction is retried after its first attempt fails.
+	MaxRetries int
+
+	// How long to wait before the first retry. The wait is doubled for each subsequent retry.
+	InitialBackoff time.Duration
+
+	// The longest time to wait between retries. If zero, there is no limit.
+	MaxBackoff time.Duration
+
+	// Returns true if the supplied error means the transaction can be retried. If nil, IsRetryableError is used.
+	Retryable func(error) bool
+}
+
+func (tp *TransactionRetryPolicy) retryable(err error) bool {
+
+	if tp.Retryable != nil {
+		return tp.Retryable(err)
+	}
+
+	return IsRetryableError(err)
+}
+
+// backoff returns how long to wait before the supplied retry (starting at 1).
+func (tp *TransactionRetryPolicy) backoff(retry int) time.Duration {
+
+	b := tp.InitialBackoff
+
+	for i := 1; i < retry; i++ {
+		b *= 2
+
+		if tp.MaxBackoff > 0 && b >= tp.MaxBackoff {
+			return tp.MaxBackoff
+		}
+	}
+
+	if tp.MaxBackoff > 0 && b > tp.MaxBackoff {
+		return tp.MaxBackoff
+	}
+
+	return b
+}
+
+// SQLSTATE codes reported by drivers when a transaction is rolled back because of a serialization failure or deadlock
+var retryableStates = []string{"40001", "40P01"}
+
+// Fragments of messages reported by drivers that do not expose a SQLSTATE
+var retryableMessages = []string{"deadlock", "serialization failure", "could not serialize", "error 1213", "error 1205", "database is locked"}
+
+// IsRetryableError returns true if the supplied error (or any error it wraps) appears to have been caused by a
+// serialization failure or deadlock. Errors that have a SQLState() string method are checked for the SQLSTATE codes
+// 40001 and 40P01. Otherwise the error's message is checked for text commonly used by drivers to report these conditions.
+func IsRetryableError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	var se interface{ SQLState() string }
+
+	if errors.As(err, &se) {
+
+		state := se.SQLState()
+
+		for _, s := range retryableStates {
+			if state == s {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	m := strings.ToLower(err.Error())
+
+	for _, r := range retryableMessages {
+		if strings.Contains(m, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+WithTransaction calls the supplied function with a Client that executes all statements in a transaction started with
+the supplied context and options (either of which may be nil). If the function returns nil, the transaction is
+committed. If the function returns an error or panics, the transaction is rolled back.
+
+If the transaction fails because of a serialization failure or deadlock, it is retried (by calling the function again)
+according to the TransactionRetryPolicy of the ClientManager that created this client.
+
+If this client already has an open transaction, a savepoint is created instead. An error or panic in the function
+rolls back to the savepoint, leaving the enclosing transaction open. Savepoints are never retried - the enclosing
+transaction should be retried instead.
+*/
+func (rc *ManagedClient) WithTransaction(ctx context.Context, opts *sql.TxOptions, f TransactionFunc) error {
+
+	if ctx == nil {
+		ctx = rc.context()
+	}
+
+	if rc.tx != nil {
+		return rc.withSavepoint(ctx, f)
+	}
+
+	for retry := 0; ; retry++ {
+
+		err := rc.transaction(ctx, opts, f)
+
+		if err == nil || rc.retry == nil || retry >= rc.retry.MaxRetries || !rc.retry.retryable(err) {
+			return err
+		}
+
+		wait := rc.retry.backoff(retry + 1)
+
+		rc.FrameworkLogger.LogDebugf("Transaction failed with a retryable error. Retrying in %s: %s", wait, err.Error())
+
+		t := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func (rc *ManagedClient) transaction(ctx context.Context, opts *sql.TxOptions, f TransactionFunc) error {
+
+	c := rc.withContext(ctx)
+
+	if err := c.startTransaction(ctx, opts); err != nil {
+		return err
+	}
+
+	// Roll back if the function returns an error or panics
+	defer c.Rollback()
+
+	if err := f(c); err != nil {
+		return err
+	}
+
+	return c.CommitTransaction()
+}
+
+func (rc *ManagedClient) withSavepoint(ctx context.Context, f TransactionFunc) error {
+
+	c := rc.withContext(ctx)
+	c.savepoints++
+
+	name := fmt.Sprintf("grnc_savepoint_%d", c.savepoints)
+
+	if _, err := c.exec("", "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			// The function panicked
+			c.exec("", "ROLLBACK TO SAVEPOINT "+name)
+		}
+	}()
+
+	err := f(c)
+	completed = true
+
+	if err != nil {
+
+		if _, rerr := c.exec("", "ROLLBACK TO SAVEPOINT "+name); rerr != nil {
+			return fmt.Errorf("unable to roll back to savepoint after error (%s): %s", err.Error(), rerr.Error())
+		}
+
+		return err
+	}
+
+	_, err = c.exec("", "RELEASE SAVEPOINT "+name)
+
+	return err
+}
@@ -0,0 +1,428 @@
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var txDrv = new(recordingDriver)
+
+func init() {
+	sql.Register("grnc-tx-mock", txDrv)
+}
+
+func txClient(t *testing.T, retries int) *ManagedClient {
+
+	txDrv.reset()
+
+	tdb, err := sql.Open("grnc-tx-mock", "")
+	test.ExpectNil(t, err)
+	tdb.SetMaxOpenConns(1)
+
+	c := newRdbmsClient(tdb, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.retry = &TransactionRetryPolicy{MaxRetries: retries, InitialBackoff: time.Millisecond}
+
+	return c
+}
+
+func TestWithTransactionCommits(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	err := c.WithTransaction(nil, nil, func(tc Client) error {
+		_, err := tc.Exec("UPDATE A")
+		return err
+	})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, txDrv.log(), "BEGIN,UPDATE A,COMMIT")
+
+	// The original client is not left in a transaction
+	if c.tx != nil {
+		t.Errorf("Expected client not to have an open transaction")
+	}
+}
+
+func TestWithTransactionRollsBackOnError(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	err := c.WithTransaction(context.Background(), new(sql.TxOptions), func(tc Client) error {
+		tc.Exec("UPDATE A")
+		return errors.New("failed")
+	})
+
+	test.ExpectNotNil(t, err)
+	test.ExpectString(t, txDrv.log(), "BEGIN,UPDATE A,ROLLBACK")
+}
+
+func TestWithTransactionRollsBackOnPanic(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	defer func() {
+		r := recover()
+
+		if r == nil {
+			t.Fatalf("Expected panic to be propagated")
+		}
+
+		test.ExpectString(t, txDrv.log(), "BEGIN,UPDATE A,ROLLBACK")
+	}()
+
+	c.WithTransaction(nil, nil, func(tc Client) error {
+		tc.Exec("UPDATE A")
+		panic("failed")
+	})
+}
+
+func TestWithTransactionSavepoints(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	err := c.WithTransaction(nil, nil, func(tc Client) error {
+
+		tc.Exec("UPDATE A")
+
+		err := tc.WithTransaction(nil, nil, func(sc Client) error {
+			sc.Exec("UPDATE B")
+
+			return sc.WithTransaction(nil, nil, func(Client) error {
+				return errors.New("failed")
+			})
+		})
+
+		test.ExpectNotNil(t, err)
+
+		return tc.WithTransaction(nil, nil, func(sc Client) error {
+			_, err := sc.Exec("UPDATE C")
+			return err
+		})
+	})
+
+	test.ExpectNil(t, err)
+
+	expected := []string{
+		"BEGIN", "UPDATE A",
+		"SAVEPOINT grnc_savepoint_1", "UPDATE B",
+		"SAVEPOINT grnc_savepoint_2", "ROLLBACK TO SAVEPOINT grnc_savepoint_2",
+		"ROLLBACK TO SAVEPOINT grnc_savepoint_1",
+		"SAVEPOINT grnc_savepoint_1", "UPDATE C", "RELEASE SAVEPOINT grnc_savepoint_1",
+		"COMMIT",
+	}
+
+	test.ExpectString(t, txDrv.log(), strings.Join(expected, ","))
+}
+
+func TestSavepointRolledBackOnPanic(t *testing.T) {
+
+	c := txClient(t, 0)
+
+	test.ExpectNil(t, c.StartTransaction())
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected panic to be propagated")
+			}
+		}()
+
+		c.WithTransaction(nil, nil, func(Client) error {
+			panic("failed")
+		})
+	}()
+
+	test.ExpectNil(t, c.CommitTransaction())
+	test.ExpectString(t, txDrv.log(), "BEGIN,SAVEPOINT grnc_savepoint_1,ROLLBACK TO SAVEPOINT grnc_savepoint_1,COMMIT")
+}
+
+func TestWithTransactionRetries(t *testing.T) {
+
+	c := txClient(t, 2)
+
+	calls := 0
+
+	err := c.WithTransaction(nil, nil, func(tc Client) error {
+		calls++
+
+		if calls < 3 {
+			return sqlStateError("40001")
+		}
+
+		return nil
+	})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, calls, 3)
+	test.ExpectString(t, txDrv.log(), "BEGIN,ROLLBACK,BEGIN,ROLLBACK,BEGIN,COMMIT")
+
+	// Retries are limited
+	calls = 0
+
+	err = c.WithTransaction(nil, nil, func(tc Client) error {
+		calls++
+		return sqlStateError("40P01")
+	})
+
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, calls, 3)
+
+	// Other errors are not retried
+	calls = 0
+
+	err = c.WithTransaction(nil, nil, func(tc Client) error {
+		calls++
+		return sqlStateError("23505")
+	})
+
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, calls, 1)
+}
+
+func TestWithTransactionRetriesFailedCommit(t *testing.T) {
+
+	c := txClient(t, 1)
+
+	txDrv.commitErrors = []error{errors.New("ERROR: could not serialize access due to concurrent update")}
+
+	calls := 0
+
+	err := c.WithTransaction(nil, nil, func(tc Client) error {
+		calls++
+		return nil
+	})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, calls, 2)
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+
+	c := txClient(t, 5)
+	c.retry.InitialBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+
+	err := c.WithTransaction(ctx, nil, func(tc Client) error {
+		calls++
+		cancel()
+		return errors.New("Deadlock found when trying to get lock")
+	})
+
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, calls, 1)
+}
+
+func TestCustomRetryableFunction(t *testing.T) {
+
+	c := txClient(t, 1)
+	c.retry.Retryable = func(err error) bool {
+		return err.Error() == "busy"
+	}
+
+	calls := 0
+
+	c.WithTransaction(nil, nil, func(tc Client) error {
+		calls++
+		return errors.New("busy")
+	})
+
+	test.ExpectInt(t, calls, 2)
+}
+
+func TestIsRetryableError(t *testing.T) {
+
+	test.ExpectBool(t, IsRetryableError(nil), false)
+	test.ExpectBool(t, IsRetryableError(sqlStateError("40001")), true)
+	test.ExpectBool(t, IsRetryableError(fmt.Errorf("wrapped: %w", sqlStateError("40P01"))), true)
+	test.ExpectBool(t, IsRetryableError(sqlStateError("42P01")), false)
+	test.ExpectBool(t, IsRetryableError(errors.New("Error 1213: Deadlock found when trying to get lock")), true)
+	test.ExpectBool(t, IsRetryableError(errors.New("syntax error")), false)
+}
+
+func TestRetryBackoff(t *testing.T) {
+
+	tp := &TransactionRetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	test.ExpectBool(t, tp.backoff(1) == 10*time.Millisecond, true)
+	test.ExpectBool(t, tp.backoff(2) == 20*time.Millisecond, true)
+	test.ExpectBool(t, tp.backoff(3) == 40*time.Millisecond, true)
+	test.ExpectBool(t, tp.backoff(4) == 50*time.Millisecond, true)
+	test.ExpectBool(t, tp.backoff(20) == 50*time.Millisecond, true)
+}
+
+func TestManagerRetryPolicy(t *testing.T) {
+
+	m := new(GraniticRdbmsClientManager)
+	m.Configuration = &ClientManagerConfig{
+		Provider:                     new(retryingProvider),
+		TransactionRetries:           4,
+		TransactionRetryBackoffMS:    20,
+		TransactionRetryMaxBackoffMS: 200,
+	}
+
+	tp := m.chooseRetryPolicy()
+
+	test.ExpectInt(t, tp.MaxRetries, 4)
+	test.ExpectBool(t, tp.InitialBackoff == 20*time.Millisecond, true)
+	test.ExpectBool(t, tp.MaxBackoff == 200*time.Millisecond, true)
+	test.ExpectBool(t, tp.retryable(errors.New("provider specific")), true)
+	test.ExpectBool(t, tp.retryable(sqlStateError("40001")), false)
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string {
+	return "SQLSTATE " + string(e)
+}
+
+func (e sqlStateError) SQLState() string {
+	return string(e)
+}
+
+type retryingProvider struct {
+	replicaProvider
+}
+
+func (rp *retryingProvider) RetryableError(err error) bool {
+	return err.Error() == "provider specific"
+}
+
+// recordingDriver records the statements executed and the transactions committed and rolled back
+type recordingDriver struct {
+	mu           sync.Mutex
+	statements   []string
+	commitErrors []error
+	// Returned by all queries
+	columns []string
+	rows    [][]driver.Value
+	// Statements containing this text fail
+	failOn string
+	// If true, statements report that no rows were affected
+	unaffected bool
+	// The arguments passed to the most recent statement executed with arguments
+	lastArgs []driver.Value
+}
+
+func (d *recordingDriver) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.statements = nil
+	d.commitErrors = nil
+	d.columns = nil
+	d.rows = nil
+	d.failOn = ""
+	d.unaffected = false
+	d.lastArgs = nil
+}
+
+func (d *recordingDriver) record(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.statements = append(d.statements, s)
+}
+
+func (d *recordingDriver) log() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return strings.Join(d.statements, ",")
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{d: d}, nil
+}
+
+type recordingConn struct {
+	d *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{d: c.d, query: query}, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN")
+
+	return &recordingTx{d: c.d}, nil
+}
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error {
+	return nil
+}
+
+func (s *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(s.query)
+
+	if len(args) > 0 {
+		s.d.lastArgs = args
+	}
+
+	if s.d.failOn != "" && strings.Contains(s.query, s.d.failOn) {
+		return nil, errors.New("Forced error")
+	}
+
+	if s.d.unaffected {
+		return driver.RowsAffected(0), nil
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(s.query)
+
+	return newMockRows(s.d.columns, s.d.rows), nil
+}
+
+type recordingTx struct {
+	d *recordingDriver
+}
+
+func (t *recordingTx) Commit() error {
+	t.d.record("COMMIT")
+
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+
+	if len(t.d.commitErrors) > 0 {
+		err := t.d.commitErrors[0]
+		t.d.commitErrors = t.d.commitErrors[1:]
+
+		return err
+	}
+
+	return nil
+}
+
+func (t *recordingTx) Rollback() error {
+	t.d.record("ROLLBACK")
+
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbms
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	rt "github.com/graniticio/granitic/v2/reflecttools"
+	"time"
+)
+
+// SelectAll executes the query with the supplied ID using the supplied Client and returns each row of the results
+// bound into a T. T is normally a struct, in which case columns are mapped to fields as described in
+// RowBinder.BindRows. Any other type (including time.Time and types implementing sql.Scanner) is populated directly
+// from the results' single column. If T is a struct with a field tagged with SoftDeleteTag, ExcludeSoftDeletedParam
+// is set to true.
+func SelectAll[T any](c Client, qid string, params ...interface{}) ([]T, error) {
+	return collect(SelectIter[T](c, qid, params...))
+}
+
+// SelectAllCtx is the equivalent of SelectAll, using the supplied context for this call only.
+func SelectAllCtx[T any](ctx context.Context, c Client, qid string, params ...interface{}) ([]T, error) {
+	return collect(SelectIterCtx[T](ctx, c, qid, params...))
+}
+
+// SelectOne executes the query with the supplied ID using the supplied Client with the expectation that it returns
+// zero or one rows. Returns nil if no rows were found or an error if more than one row was found. See SelectAll for
+// how results are bound into a T.
+func SelectOne[T any](c Client, qid string, params ...interface{}) (*T, error) {
+	r, err := SelectIter[T](c, qid, params...)
+
+	return single(qid, r, err)
+}
+
+// SelectOneCtx is the equivalent of SelectOne, using the supplied context for this call only.
+func SelectOneCtx[T any](ctx context.Context, c Client, qid string, params ...interface{}) (*T, error) {
+	r, err := SelectIterCtx[T](ctx, c, qid, params...)
+
+	return single(qid, r, err)
+}
+
+// SelectIter executes the query with the supplied ID using the supplied Client and returns a Rows that binds each row
+// of the results into a T as it is read, rather than building a slice of all of the results. See SelectAll for
+// how results are bound into a T.
+//
+// The returned Rows must be closed when it is no longer needed.
+func SelectIter[T any](c Client, qid string, params ...interface{}) (*Rows[T], error) {
+
+	var r *sql.Rows
+	var err error
+
+	if mc, found := c.(*ManagedClient); found {
+		r, err = mc.selectQIDParams(qid, new(T), params...)
+	} else {
+		r, err = c.SelectQIDParams(qid, params...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newRows[T](c, r)
+}
+
+// SelectIterCtx is the equivalent of SelectIter, using the supplied context for this call only.
+func SelectIterCtx[T any](ctx context.Context, c Client, qid string, params ...interface{}) (*Rows[T], error) {
+
+	var r *sql.Rows
+	var err error
+
+	if mc, found := c.(*ManagedClient); found {
+		r, err = mc.withContext(ctx).selectQIDParams(qid, new(T), params...)
+	} else {
+		r, err = c.SelectQIDParamsCtx(ctx, qid, params...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newRows[T](c, r)
+}
+
+// NewRows creates a Rows that binds each row of the supplied results into a T. The sql.Rows will be closed if
+// the columns in the results cannot be matched to the fields of T.
+func NewRows[T any](r *sql.Rows) (*Rows[T], error) {
+	return NewRowsWithBinder[T](r, new(RowBinder))
+}
+
+// NewRowsWithBinder is the equivalent of NewRows, using the supplied RowBinder to match columns to the fields of T.
+func NewRowsWithBinder[T any](r *sql.Rows, rb *RowBinder) (*Rows[T], error) {
+
+	tr := new(Rows[T])
+	tr.rows = r
+	tr.binder = rb
+	tr.template = new(T)
+
+	if scansDirectly(tr.template) {
+		return tr, nil
+	}
+
+	scanners, err := tr.binder.columnScanners(r, tr.template)
+
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	tr.scanners = scanners
+
+	return tr, nil
+}
+
+// Rows is a typed wrapper around sql.Rows that binds each row into a new T when Next is called. Like sql.Rows,
+// it is not safe for use by multiple goroutines.
+//
+//	r, err := rdbms.SelectIter[ArtistSummary](rc, "ARTIST_SEARCH", params)
+//
+//	if err != nil {
+//	  return err
+//	}
+//
+//	defer r.Close()
+//
+//	for r.Next() {
+//	  process(r.Value())
+//	}
+//
+//	return r.Err()
+type Rows[T any] struct {
+	rows     *sql.Rows
+	binder   *RowBinder
+	template *T
+	scanners []interface{}
+	current  *T
+	err      error
+}
+
+// Next reads and binds the next row of the results, returning false if there are no more rows or an error occurred.
+// Check Err after Next returns false to distinguish between the two cases.
+func (r *Rows[T]) Next() bool {
+
+	r.current = nil
+
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+
+	if r.scanners == nil {
+
+		v := new(T)
+
+		if r.err = r.rows.Scan(v); r.err != nil {
+			return false
+		}
+
+		r.current = v
+
+		return true
+	}
+
+	if r.err = r.rows.Scan(r.scanners...); r.err != nil {
+		return false
+	}
+
+	built, err := r.binder.buildAndPopulate(r.template, r.scanners)
+
+	if err != nil {
+		r.err = err
+		return false
+	}
+
+	r.current = built.(*T)
+
+	return true
+}
+
+// Value returns the row read by the most recent call to Next. Each row is bound into a new T.
+func (r *Rows[T]) Value() *T {
+	return r.current
+}
+
+// Err returns the error, if any, that was encountered while reading or binding the results.
+func (r *Rows[T]) Err() error {
+
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.rows.Err()
+}
+
+// Close closes the underlying sql.Rows.
+func (r *Rows[T]) Close() error {
+	return r.rows.Close()
+}
+
+// newRows uses the RowBinder of the supplied Client, if it has one, so the Client's column matching settings are applied.
+func newRows[T any](c Client, r *sql.Rows) (*Rows[T], error) {
+
+	if mc, found := c.(*ManagedClient); found && mc.binder != nil {
+		return NewRowsWithBinder[T](r, mc.binder)
+	}
+
+	return NewRows[T](r)
+}
+
+func collect[T any](r *Rows[T], err error) ([]T, error) {
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	results := make([]T, 0)
+
+	for r.Next() {
+		results = append(results, *r.Value())
+	}
+
+	return results, r.Err()
+}
+
+func single[T any](qid string, r *Rows[T], err error) (*T, error) {
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	if !r.Next() {
+		return nil, r.Err()
+	}
+
+	v := r.Value()
+
+	if r.Next() {
+		return nil, fmt.Errorf("query %s returned more than one row, expected zero or one row", qid)
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// scansDirectly returns true if the supplied target should be passed to sql.Rows.Scan rather than having its fields
+// populated from the columns of the results.
+func scansDirectly(t interface{}) bool {
+
+	switch t.(type) {
+	case sql.Scanner, *time.Time:
+		return true
+	}
+
+	return !rt.IsPointerToStruct(t)
+}
@@ -0,0 +1,122 @@
+package rdbms
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+func TestSelectAll(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.colNames = []string{"Int64Result", "ColumnAlias"}
+	drv.rowData = [][]driver.Value{{int64(45), "a"}, {int64(32), "b"}}
+
+	results, err := SelectAll[testTarget](c, "SA", map[string]interface{}{"p1": "v1"})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(results), 2)
+	test.ExpectInt(t, int(results[1].Int64Result), 32)
+	test.ExpectString(t, results[1].Aliased, "b")
+	test.ExpectString(t, qm.lastParams["p1"].(string), "v1")
+
+	results, err = SelectAllCtx[testTarget](context.Background(), c, "SA")
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(results), 0)
+
+	drv.forceError = true
+	_, err = SelectAll[testTarget](c, "SA")
+	test.ExpectNotNil(t, err)
+
+	drv.colNames = []string{"Unmatched"}
+	drv.rowData = [][]driver.Value{{int64(45)}}
+
+	_, err = SelectAll[testTarget](c, "SA")
+	test.ExpectNotNil(t, err)
+}
+
+func TestSelectAllNonStruct(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.colNames = []string{"ID"}
+	drv.rowData = [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}
+
+	ids, err := SelectAll[int64](c, "IDS")
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(ids), 3)
+	test.ExpectInt(t, int(ids[2]), 3)
+
+	now := time.Now()
+
+	drv.colNames = []string{"Created"}
+	drv.rowData = [][]driver.Value{{now}}
+
+	created, err := SelectOne[time.Time](c, "CREATED")
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, created.Equal(now), true)
+}
+
+func TestSelectOne(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.colNames = []string{"StrResult"}
+	drv.rowData = [][]driver.Value{{"found"}}
+
+	r, err := SelectOne[testTarget](c, "SO")
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, r.StrResult, "found")
+
+	r, err = SelectOneCtx[testTarget](context.Background(), c, "SO")
+
+	test.ExpectNil(t, err)
+
+	if r != nil {
+		t.Errorf("Expected no result")
+	}
+
+	drv.colNames = []string{"StrResult"}
+	drv.rowData = [][]driver.Value{{"a"}, {"b"}}
+
+	_, err = SelectOne[testTarget](c, "SO")
+	test.ExpectNotNil(t, err)
+}
+
+func TestSelectIter(t *testing.T) {
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+
+	drv.colNames = []string{"Int64Result"}
+	drv.rowData = [][]driver.Value{{int64(1)}, {int64(2)}, {"not a number"}}
+
+	r, err := SelectIter[testTarget](c, "SI")
+	test.ExpectNil(t, err)
+
+	defer r.Close()
+
+	var seen []*testTarget
+
+	for r.Next() {
+		seen = append(seen, r.Value())
+	}
+
+	test.ExpectInt(t, len(seen), 2)
+	test.ExpectNotNil(t, r.Err())
+
+	if r.Value() != nil {
+		t.Errorf("Expected no current value after an error")
+	}
+
+	if seen[0] == seen[1] {
+		t.Errorf("Expected each row to be bound into a new value")
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How many years ahead to search for a time matching a cron expression before concluding that it never matches
+const cronSearchYears = 5
+
+// Shorthand expressions and the six-field expressions they are equivalent to
+var cronDescriptors = map[string]string{
+	"@YEARLY":   "0 0 0 1 1 *",
+	"@ANNUALLY": "0 0 0 1 1 *",
+	"@MONTHLY":  "0 0 0 1 * *",
+	"@WEEKLY":   "0 0 0 * * 0",
+	"@DAILY":    "0 0 0 * * *",
+	"@MIDNIGHT": "0 0 0 * * *",
+	"@HOURLY":   "0 0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var dayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// cronField describes the permitted values of one of the fields of a cron expression
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondField  = cronField{name: "second", min: 0, max: 59}
+	minuteField  = cronField{name: "minute", min: 0, max: 59}
+	hourField    = cronField{name: "hour", min: 0, max: 23}
+	dayField     = cronField{name: "day of month", min: 1, max: 31}
+	monthField   = cronField{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = cronField{name: "day of week", min: 0, max: 7, names: dayNames}
+)
+
+// bits is a set of the values that match a field of a cron expression
+type bits uint64
+
+func (b bits) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+// nthWeekday matches the nth occurrence of a day of the week in a month (e.g. MON#1 for the first Monday)
+type nthWeekday struct {
+	weekday time.Weekday
+	n       int
+}
+
+// cronSchedule is a parsed cron expression
+type cronSchedule struct {
+	expression string
+	seconds    bits
+	minutes    bits
+	hours      bits
+	days       bits
+	months     bits
+	weekdays   bits
+	nth        []nthWeekday
+	anyDay     bool
+	anyWeekday bool
+}
+
+// parseCron parses a cron expression of the form
+//
+//	[second] minute hour day-of-month month day-of-week
+//
+// or one of the shorthand descriptors (@yearly, @monthly, @weekly, @daily, @hourly).
+func parseCron(expression string) (*cronSchedule, error) {
+
+	m := fmt.Sprintf("Cannot parse cron expression [%s]: ", expression)
+
+	norm := strings.ToUpper(strings.TrimSpace(expression))
+
+	if strings.HasPrefix(norm, "@") {
+
+		if d, found := cronDescriptors[norm]; found {
+			norm = d
+		} else {
+			return nil, errors.New(m + "unknown descriptor " + norm)
+		}
+	}
+
+	fields := strings.Fields(norm)
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%sexpected 5 or 6 fields but found %d", m, len(fields))
+	}
+
+	cs := new(cronSchedule)
+	cs.expression = expression
+
+	var err error
+
+	if cs.seconds, err = parseCronField(fields[0], secondField); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	if cs.minutes, err = parseCronField(fields[1], minuteField); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	if cs.hours, err = parseCronField(fields[2], hourField); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	if cs.days, err = parseCronField(fields[3], dayField); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	if cs.months, err = parseCronField(fields[4], monthField); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	if cs.weekdays, cs.nth, err = parseWeekdayField(fields[5]); err != nil {
+		return nil, errors.New(m + err.Error())
+	}
+
+	cs.anyDay = unrestricted(fields[3])
+	cs.anyWeekday = unrestricted(fields[5])
+
+	return cs, nil
+}
+
+func unrestricted(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseWeekdayField parses the day of week field, which may contain entries like MON#1 (first Monday of the month) as
+// well as the syntax permitted in other fields. Both 0 and 7 mean Sunday.
+func parseWeekdayField(field string) (bits, []nthWeekday, error) {
+
+	var b bits
+	var nth []nthWeekday
+
+	for _, part := range strings.Split(field, ",") {
+
+		if i := strings.Index(part, "#"); i >= 0 {
+
+			d, err := parseCronValue(part[:i], weekdayField)
+
+			if err != nil {
+				return 0, nil, err
+			}
+
+			n, err := strconv.Atoi(part[i+1:])
+
+			if err != nil || n < 1 || n > 5 {
+				return 0, nil, fmt.Errorf("%s is not a valid occurrence of a day in a month (must be 1-5)", part[i+1:])
+			}
+
+			nth = append(nth, nthWeekday{weekday: time.Weekday(d % 7), n: n})
+
+			continue
+		}
+
+		pb, err := parseCronField(part, weekdayField)
+
+		if err != nil {
+			return 0, nil, err
+		}
+
+		b |= pb
+	}
+
+	if b.has(7) {
+		b |= 1
+	}
+
+	return b, nth, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges (a-b), wildcards (* or ?) and steps (*/n, a-b/n or
+// a/n) into the set of values they match.
+func parseCronField(field string, f cronField) (bits, error) {
+
+	var b bits
+
+	for _, part := range strings.Split(field, ",") {
+
+		rangeSpec := part
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+
+			rangeSpec = part[:i]
+
+			var err error
+
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("%s is not a valid step for the %s field (must be a positive integer)", part[i+1:], f.name)
+			}
+		}
+
+		var low, high int
+		var err error
+
+		switch {
+		case unrestricted(rangeSpec):
+			low, high = f.min, f.max
+
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+
+			if low, err = parseCronValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+
+			if high, err = parseCronValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+
+			if high < low {
+				return 0, fmt.Errorf("%s is not a valid range for the %s field (start is after end)", rangeSpec, f.name)
+			}
+
+		default:
+			if low, err = parseCronValue(rangeSpec, f); err != nil {
+				return 0, err
+			}
+
+			high = low
+
+			if step > 1 {
+				// a/n means every nth value starting at a
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			b |= 1 << uint(v)
+		}
+	}
+
+	return b, nil
+}
+
+// parseCronValue converts a number or name into a value that is valid for the supplied field
+func parseCronValue(s string, f cronField) (int, error) {
+
+	if v, found := f.names[s]; found {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s is not a valid value for the %s field (must be %d-%d)", s, f.name, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// next returns the earliest time after the supplied time that matches the schedule, or the zero time if there is no
+// such time in the next few years. Times are matched against the wall-clock time in the supplied time's location (see
+// wallClockIn for the treatment of times that are skipped or repeated when clocks change).
+func (cs *cronSchedule) next(after time.Time) time.Time {
+
+	loc := after.Location()
+
+	w := wallClock(after).Truncate(time.Second).Add(time.Second)
+
+	limit := w.Year() + cronSearchYears
+
+	for w.Year() <= limit {
+
+		y, mo, d := w.Date()
+
+		if !cs.months.has(int(mo)) {
+			w = time.Date(y, mo+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !cs.dayMatches(w) {
+			w = time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		h, mi, s := w.Clock()
+
+		if !cs.hours.has(h) {
+			w = time.Date(y, mo, d, h+1, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !cs.minutes.has(mi) {
+			w = time.Date(y, mo, d, h, mi+1, 0, 0, time.UTC)
+			continue
+		}
+
+		if !cs.seconds.has(s) {
+			w = w.Add(time.Second)
+			continue
+		}
+
+		if t := wallClockIn(w, loc); t.After(after) {
+			return t
+		}
+
+		// The second occurrence of a repeated time
+		w = w.Add(time.Second)
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows the convention of most cron implementations: if both the day of month and day of week fields
+// are restricted, a day matches if it matches either field.
+func (cs *cronSchedule) dayMatches(t time.Time) bool {
+
+	dayMatch := cs.days.has(t.Day())
+	weekdayMatch := cs.weekdays.has(int(t.Weekday()))
+
+	for _, n := range cs.nth {
+		if t.Weekday() == n.weekday && (t.Day()-1)/7+1 == n.n {
+			weekdayMatch = true
+		}
+	}
+
+	switch {
+	case cs.anyDay && cs.anyWeekday:
+		return true
+	case cs.anyDay:
+		return weekdayMatch
+	case cs.anyWeekday:
+		return dayMatch
+	default:
+		return dayMatch || weekdayMatch
+	}
+}
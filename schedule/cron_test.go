@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"testing"
+	"time"
+)
+
+const cronTestFormat = "2006-01-02 15:04:05"
+
+func cronTime(t *testing.T, s string) time.Time {
+
+	ct, err := time.ParseInLocation(cronTestFormat, s, time.UTC)
+
+	if err != nil {
+		t.Fatalf("Unable to parse test time: %s", err)
+	}
+
+	return ct
+}
+
+func TestCronNextRuns(t *testing.T) {
+
+	// 2019-03-01 is a Friday
+	tests := []struct {
+		expression string
+		after      string
+		expected   string
+	}{
+		{"0 9 * * MON-FRI", "2019-03-01 08:00:00", "2019-03-01 09:00:00"},
+		{"0 9 * * MON-FRI", "2019-03-01 09:00:00", "2019-03-04 09:00:00"},
+		{"0 9 * * mon#1", "2019-03-01 00:00:00", "2019-03-04 09:00:00"},
+		{"0 9 * * MON#1", "2019-03-04 09:00:00", "2019-04-01 09:00:00"},
+		{"*/15 * * * *", "2019-03-01 10:07:31", "2019-03-01 10:15:00"},
+		{"30 */20 * * * *", "2019-03-01 10:59:45", "2019-03-01 11:00:30"},
+		{"0 0 12 1,15 * ?", "2019-03-02 00:00:00", "2019-03-15 12:00:00"},
+		{"0 6 * JAN,jul *", "2019-03-01 00:00:00", "2019-07-01 06:00:00"},
+		{"0 0 29 2 *", "2019-03-01 00:00:00", "2020-02-29 00:00:00"},
+		{"0 0 13 * 5", "2019-03-01 00:00:00", "2019-03-08 00:00:00"},
+		{"5/20 8-10 * * 7", "2019-03-01 00:00:00", "2019-03-03 08:05:00"},
+		{"@daily", "2019-03-01 10:00:00", "2019-03-02 00:00:00"},
+		{"@weekly", "2019-03-01 10:00:00", "2019-03-03 00:00:00"},
+		{"@yearly", "2019-03-01 10:00:00", "2020-01-01 00:00:00"},
+	}
+
+	for _, tc := range tests {
+
+		cs, err := parseCron(tc.expression)
+
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %s", tc.expression, err)
+			continue
+		}
+
+		next := cs.next(cronTime(t, tc.after))
+
+		if next.Format(cronTestFormat) != tc.expected {
+			t.Errorf("%s after %s: expected %s actual %s", tc.expression, tc.after, tc.expected, next.Format(cronTestFormat))
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+
+	cs, err := parseCron("0 0 30 FEB *")
+
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if !cs.next(cronTime(t, "2019-03-01 00:00:00")).IsZero() {
+		t.Errorf("Expected no matching time")
+	}
+}
+
+func TestInvalidCronExpressions(t *testing.T) {
+
+	invalid := []string{
+		"", "* * * *", "* * * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"* * * FOO *", "*/0 * * * *", "10-5 * * * *", "* * * * MON#6", "@fortnightly",
+	}
+
+	for _, e := range invalid {
+
+		if _, err := parseCron(e); err == nil {
+			t.Errorf("Expected an error parsing [%s]", e)
+		}
+	}
+}
+
+func TestCronScheduleValidation(t *testing.T) {
+
+	ts := new(TaskScheduler)
+	ts.FrameworkLogManager = newTestLogManager()
+
+	validate := func(every, cron string) error {
+
+		tsk := &Task{Component: "logic", Every: every, Cron: cron}
+
+		return ts.validateAndPrepare(new(logicContainer), tsk)
+	}
+
+	if err := validate("", "0 9 * * MON-FRI"); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+
+	if ts.managedTasks[0].Cron == nil {
+		t.Errorf("Expected cron schedule to be set")
+	}
+
+	if validate("", "") == nil {
+		t.Errorf("Expected error when neither Every nor Cron is set")
+	}
+
+	if validate("1 hour", "0 9 * * *") == nil {
+		t.Errorf("Expected error when both Every and Cron are set")
+	}
+
+	if validate("", "0 9 * * MONDAY") == nil {
+		t.Errorf("Expected error for invalid cron expression")
+	}
+
+	if validate("", "0 0 31 FEB *") == nil {
+		t.Errorf("Expected error for cron expression that never matches")
+	}
+}
+
+func TestCronInvocations(t *testing.T) {
+
+	cs, _ := parseCron("0 9 * * MON-FRI")
+
+	im := newInvocationManager(&Task{Name: "cron-task"})
+	im.Log = new(logging.ConsoleErrorLogger)
+	im.Cron = cs
+
+	im.setFirstInvocation()
+
+	first := im.scheduled.PeekHead()
+
+	if first.runAt.Hour() != 9 || first.runAt.Weekday() == time.Saturday || first.runAt.Weekday() == time.Sunday {
+		t.Errorf("Unexpected first run %v", first.runAt)
+	}
+
+	first.runAt = cronTime(t, "2019-03-01 09:00:00")
+
+	if next := im.addNextInvocation(first); next.Format(cronTestFormat) != "2019-03-04 09:00:00" {
+		t.Errorf("Unexpected next run %v", next)
+	}
+}
+
+func newTestLogManager() *logging.ComponentLoggerManager {
+	return logging.CreateComponentLoggerManager(logging.Fatal, nil, []logging.LogWriter{}, logging.NewNoPrefixFormatter())
+}
+
+// logicContainer returns a component implementing TaskLogic for any name
+type logicContainer struct{}
+
+func (c *logicContainer) ComponentByName(name string) *ioc.Component {
+	return ioc.NewComponent(name, new(nullLogic))
+}
+
+func (c *logicContainer) AllComponents() []*ioc.Component {
+	return []*ioc.Component{}
+}
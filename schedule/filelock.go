@@ -0,0 +1,197 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/instance"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// How long a process waits for another process to finish updating a lease file
+	leaseFileWait = 2 * time.Second
+
+	// How often a process checks whether another process has finished updating a lease file
+	leaseFilePoll = 10 * time.Millisecond
+
+	// How old a guard file must be before it is assumed to have been left behind by a process that failed
+	staleGuardAge = 30 * time.Second
+)
+
+// FileTaskLock is a TaskLockProvider that stores the lease for each task in a file, allowing instances of an
+// application running on the same host (or sharing a file system that supports exclusive file creation) to make sure
+// that each invocation of a task runs on only one instance.
+//
+// Declare as a component and set the LockProvider field of each Task to the component's name:
+//
+//	"taskLock": {
+//	  "type": "schedule.FileTaskLock",
+//	  "Directory": "/var/run/myapp/tasks"
+//	}
+type FileTaskLock struct {
+	// The directory lease files are stored in. Created if it does not exist.
+	Directory string
+
+	holder     string
+	holderOnce sync.Once
+	now        func() time.Time
+}
+
+// RegisterInstanceID implements instance.Receiver, using the ID of the instance to identify the holder of a lease
+func (fl *FileTaskLock) RegisterInstanceID(i *instance.Identifier) {
+	fl.holder = i.ID
+}
+
+// AcquireTaskLock implements TaskLockProvider.AcquireTaskLock
+func (fl *FileTaskLock) AcquireTaskLock(taskID string, scheduledFor time.Time, lease time.Duration) (bool, error) {
+
+	acquired := false
+
+	err := fl.update(taskID, func(l *taskLease, now time.Time) bool {
+
+		if !l.available(scheduledFor, now) {
+			return false
+		}
+
+		l.Holder = fl.lockHolder()
+		l.ScheduledFor = millis(scheduledFor)
+		l.Expires = millis(now.Add(lease))
+
+		acquired = true
+
+		return true
+	})
+
+	return acquired, err
+}
+
+// ReleaseTaskLock implements TaskLockProvider.ReleaseTaskLock
+func (fl *FileTaskLock) ReleaseTaskLock(taskID string, scheduledFor time.Time) error {
+
+	return fl.update(taskID, func(l *taskLease, now time.Time) bool {
+
+		if l.Holder != fl.lockHolder() || l.ScheduledFor != millis(scheduledFor) {
+			// The lease has expired and been acquired by another instance
+			return false
+		}
+
+		l.Expires = millis(now)
+
+		return true
+	})
+}
+
+// update reads the lease for a task and passes it to the supplied function, writing the lease back to its file if the
+// function returns true. Other processes are prevented from updating the lease at the same time by a guard file that
+// exists while the lease is being updated.
+func (fl *FileTaskLock) update(taskID string, f func(l *taskLease, now time.Time) bool) error {
+
+	if fl.Directory == "" {
+		return errors.New("FileTaskLock.Directory must be set")
+	}
+
+	if err := os.MkdirAll(fl.Directory, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(fl.Directory, url.PathEscape(taskID)+".lease")
+	guard := path + ".guard"
+
+	if err := fl.createGuard(guard); err != nil {
+		return err
+	}
+
+	defer os.Remove(guard)
+
+	l := new(taskLease)
+
+	if b, err := os.ReadFile(path); err == nil {
+
+		if err := json.Unmarshal(b, l); err != nil {
+			return fmt.Errorf("unable to read lease file %s: %s", path, err.Error())
+		}
+
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if !f(l, fl.currentTime()) {
+		return nil
+	}
+
+	b, err := json.Marshal(l)
+
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file then rename so a failure cannot leave a partially written lease file
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// createGuard creates the guard file, waiting for any other process that has created the file to remove it.
+func (fl *FileTaskLock) createGuard(guard string) error {
+
+	deadline := time.Now().Add(leaseFileWait)
+
+	for {
+
+		g, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+		if err == nil {
+			return g.Close()
+		}
+
+		if !os.IsExist(err) {
+			return err
+		}
+
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > staleGuardAge {
+			// Left behind by a process that stopped while updating the lease
+			os.Remove(guard)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for another process to remove %s", guard)
+		}
+
+		time.Sleep(leaseFilePoll)
+	}
+}
+
+// lockHolder returns the injected instance ID, or identifies this process if no ID was injected. Invocations of tasks
+// run concurrently, so the default is set only once.
+func (fl *FileTaskLock) lockHolder() string {
+
+	fl.holderOnce.Do(func() {
+		if fl.holder == "" {
+			fl.holder = defaultLockHolder()
+		}
+	})
+
+	return fl.holder
+}
+
+func (fl *FileTaskLock) currentTime() time.Time {
+
+	if fl.now != nil {
+		return fl.now()
+	}
+
+	return time.Now()
+}
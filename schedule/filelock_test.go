@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTaskLock(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "locks")
+	now := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	a := &FileTaskLock{Directory: dir, holder: "a", now: clock}
+	b := &FileTaskLock{Directory: dir, holder: "b", now: clock}
+
+	expectAcquired := func(fl *FileTaskLock, scheduledFor time.Time, expected bool) {
+
+		acquired, err := fl.AcquireTaskLock("report/daily", scheduledFor, time.Minute)
+
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+
+		if acquired != expected {
+			t.Errorf("%s acquiring lock for %v: expected %v actual %v", fl.holder, scheduledFor, expected, acquired)
+		}
+	}
+
+	expectAcquired(a, now, true)
+	expectAcquired(b, now, false)
+
+	// Unexpired lease
+	expectAcquired(b, now.Add(time.Second), false)
+
+	// Only the holder can release a lease
+	b.ReleaseTaskLock("report/daily", now)
+	expectAcquired(b, now.Add(time.Second), false)
+
+	a.ReleaseTaskLock("report/daily", now)
+
+	// The invocation that has already run cannot run again
+	expectAcquired(b, now, false)
+	expectAcquired(b, now.Add(time.Second), true)
+
+	// Lease expires if not released
+	now = now.Add(2 * time.Minute)
+	expectAcquired(a, now, true)
+
+	if _, err := os.Stat(filepath.Join(dir, "report%2Fdaily.lease.guard")); !os.IsNotExist(err) {
+		t.Errorf("Expected guard file to be removed")
+	}
+}
+
+func TestFileTaskLockGuard(t *testing.T) {
+
+	dir := t.TempDir()
+	guard := filepath.Join(dir, "task.lease.guard")
+
+	os.WriteFile(guard, []byte{}, 0644)
+
+	fl := &FileTaskLock{Directory: dir}
+
+	if _, err := fl.AcquireTaskLock("task", time.Now(), time.Minute); err == nil {
+		t.Errorf("Expected error while another process holds the guard file")
+	}
+
+	stale := time.Now().Add(-time.Hour)
+	os.Chtimes(guard, stale, stale)
+
+	if acquired, err := fl.AcquireTaskLock("task", time.Now(), time.Minute); err != nil || !acquired {
+		t.Errorf("Expected stale guard file to be removed (%v %v)", acquired, err)
+	}
+
+	if _, err := new(FileTaskLock).AcquireTaskLock("task", time.Now(), time.Minute); err == nil {
+		t.Errorf("Expected error when no directory is set")
+	}
+}
+
+func TestDefaultLockHolderSetOnce(t *testing.T) {
+
+	fl := &FileTaskLock{Directory: t.TempDir()}
+
+	holders := make(chan string, 10)
+
+	for i := 0; i < cap(holders); i++ {
+		go func() {
+			holders <- fl.lockHolder()
+		}()
+	}
+
+	expected := defaultLockHolder()
+
+	for i := 0; i < cap(holders); i++ {
+		if h := <-holders; h != expected {
+			t.Errorf("Expected holder %s actual %s", expected, h)
+		}
+	}
+}
@@ -34,6 +34,8 @@ type invocation struct {
 	attempt     int
 	maxAttempts int
 	reason      string
+	// The scheduled time of the invocation when it acquired a lock from the task's TaskLockProvider (zero if no lock is held)
+	lockedFor time.Time
 }
 
 func (i *invocation) firstAttempt() bool {
@@ -0,0 +1,98 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// How long a lock is held if a task does not specify a LockLease
+const defaultLockLease = 5 * time.Minute
+
+// TaskLockProvider is implemented by components that prevent an invocation of a task running on more than one instance
+// of an application. A Task uses a TaskLockProvider if the name of the component is set in its LockProvider field.
+type TaskLockProvider interface {
+	// AcquireTaskLock is called before a scheduled invocation (or retry) of a task runs and returns true if the invocation
+	// should run on this instance. scheduledFor is the time the invocation was due to run, which is the same on every
+	// instance for tasks with a Cron expression or an 'at' time. The lock should be treated as released once the lease
+	// has passed, even if ReleaseTaskLock is never called.
+	AcquireTaskLock(taskID string, scheduledFor time.Time, lease time.Duration) (bool, error)
+
+	// ReleaseTaskLock is called after an invocation that acquired the lock finishes.
+	ReleaseTaskLock(taskID string, scheduledFor time.Time) error
+}
+
+// taskLease is the state of the lock for a task, shared by the TaskLockProvider implementations in this package.
+// Times are milliseconds since the Unix epoch.
+type taskLease struct {
+	Holder       string
+	ScheduledFor int64
+	Expires      int64
+}
+
+// available returns true if an invocation scheduled for the supplied time can acquire the lock. The lock is available
+// if no invocation is holding an unexpired lease and no invocation scheduled for the same or a later time has already
+// acquired the lock.
+func (l *taskLease) available(scheduledFor time.Time, now time.Time) bool {
+	return l.ScheduledFor < millis(scheduledFor) && l.Expires <= millis(now)
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// defaultLockHolder identifies this process if an instance ID has not been injected into a TaskLockProvider
+func defaultLockHolder() string {
+
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// acquireLock returns true if the invocation is allowed to run. Manual invocations are always allowed to run.
+func (im *invocationManager) acquireLock(i *invocation) bool {
+
+	t := im.Task
+
+	if t.lockProvider == nil || i.reason == Manual {
+		return true
+	}
+
+	acquired, err := t.lockProvider.AcquireTaskLock(t.ID, i.runAt, t.lockLease)
+
+	if err != nil {
+		im.Log.LogErrorf("Invocation %d of task %s will not run as a lock could not be acquired: %s", i.counter, t.FullName(), err.Error())
+		return false
+	}
+
+	if !acquired {
+		im.Log.LogDebugf("Invocation %d of task %s will not run as the task is running or has run on another instance", i.counter, t.FullName())
+		return false
+	}
+
+	i.lockedFor = i.runAt
+
+	return true
+}
+
+func (im *invocationManager) releaseLock(i *invocation) {
+
+	if i.lockedFor.IsZero() {
+		return
+	}
+
+	t := im.Task
+
+	if err := t.lockProvider.ReleaseTaskLock(t.ID, i.lockedFor); err != nil {
+		im.Log.LogWarnf("Unable to release the lock held by invocation %d of task %s: %s", i.counter, t.FullName(), err.Error())
+	}
+
+	i.lockedFor = time.Time{}
+}
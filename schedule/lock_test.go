@@ -0,0 +1,178 @@
+package schedule
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"testing"
+	"time"
+)
+
+func TestInvocationSkippedWithoutLock(t *testing.T) {
+
+	logic := new(countingLogic)
+	lp := new(recordingLockProvider)
+
+	im := newInvocationManager(&Task{ID: "locked-task", logic: logic, lockProvider: lp, lockLease: time.Minute})
+	im.Log = new(logging.ConsoleErrorLogger)
+
+	runAt := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	i := newInvocation(1, 0, Scheduled)
+	i.runAt = runAt
+	im.running.EnqueueAtTail(i)
+
+	im.runTask(i)
+
+	if logic.count != 0 || im.running.Size() != 0 {
+		t.Errorf("Expected invocation not to run when the lock is not acquired")
+	}
+
+	lp.grant = true
+
+	i = newInvocation(2, 0, Scheduled)
+	i.runAt = runAt
+	im.running.EnqueueAtTail(i)
+
+	im.runTask(i)
+
+	if logic.count != 1 || im.running.Size() != 0 {
+		t.Errorf("Expected invocation to run when the lock is acquired")
+	}
+
+	if lp.acquiredID != "locked-task" || !lp.acquiredFor.Equal(runAt) || lp.lease != time.Minute {
+		t.Errorf("Unexpected lock request %s %v %v", lp.acquiredID, lp.acquiredFor, lp.lease)
+	}
+
+	if !lp.releasedFor.Equal(runAt) || !i.lockedFor.IsZero() {
+		t.Errorf("Expected lock to be released")
+	}
+
+	lp.grant = false
+	lp.err = errors.New("database unavailable")
+
+	im.runTask(newInvocation(3, 0, Scheduled))
+
+	if logic.count != 1 {
+		t.Errorf("Expected invocation not to run when the lock provider fails")
+	}
+
+	im.runTask(newInvocation(4, 0, Manual))
+
+	if logic.count != 2 {
+		t.Errorf("Expected manual invocation to run without a lock")
+	}
+}
+
+func TestLockProviderValidation(t *testing.T) {
+
+	ts := new(TaskScheduler)
+	ts.FrameworkLogManager = newTestLogManager()
+
+	cn := &lockContainer{lp: new(recordingLockProvider)}
+
+	tsk := &Task{Component: "logic", Every: "1 hour", LockProvider: "lock", LockLease: "20 minutes"}
+
+	if err := ts.validateAndPrepare(cn, tsk); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if tsk.lockProvider == nil || tsk.lockLease != 20*time.Minute {
+		t.Errorf("Expected lock provider and lease to be set")
+	}
+
+	tsk = &Task{Component: "logic", Every: "1 hour", LockProvider: "lock"}
+	ts.validateAndPrepare(cn, tsk)
+
+	if tsk.lockLease != defaultLockLease {
+		t.Errorf("Expected default lease, got %v", tsk.lockLease)
+	}
+
+	invalid := []*Task{
+		{Component: "logic", Every: "1 hour", LockProvider: "missing"},
+		{Component: "logic", Every: "1 hour", LockProvider: "logic"},
+		{Component: "logic", Every: "1 hour", LockProvider: "lock", LockLease: "soon"},
+		{Component: "logic", Every: "1 hour", LockLease: "1 minute"},
+	}
+
+	for _, tsk := range invalid {
+		if err := ts.validateAndPrepare(cn, tsk); err == nil {
+			t.Errorf("Expected an error for %v", tsk)
+		}
+	}
+}
+
+func TestLeaseAvailability(t *testing.T) {
+
+	now := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	l := &taskLease{ScheduledFor: millis(now), Expires: millis(now.Add(time.Minute))}
+
+	if l.available(now.Add(time.Hour), now) {
+		t.Errorf("Expected unexpired lease to be unavailable")
+	}
+
+	if l.available(now, now.Add(time.Hour)) {
+		t.Errorf("Expected lease to be unavailable for an invocation that has already acquired it")
+	}
+
+	if !l.available(now.Add(time.Hour), now.Add(time.Minute)) {
+		t.Errorf("Expected expired lease to be available to a later invocation")
+	}
+
+	if !new(taskLease).available(now, now) {
+		t.Errorf("Expected new lease to be available")
+	}
+}
+
+type countingLogic struct {
+	count int
+}
+
+func (cl *countingLogic) ExecuteTask(c chan TaskStatusUpdate) error {
+	cl.count++
+	return nil
+}
+
+type recordingLockProvider struct {
+	grant       bool
+	err         error
+	acquiredID  string
+	acquiredFor time.Time
+	lease       time.Duration
+	releasedFor time.Time
+}
+
+func (lp *recordingLockProvider) AcquireTaskLock(taskID string, scheduledFor time.Time, lease time.Duration) (bool, error) {
+	lp.acquiredID = taskID
+	lp.acquiredFor = scheduledFor
+	lp.lease = lease
+
+	return lp.grant, lp.err
+}
+
+func (lp *recordingLockProvider) ReleaseTaskLock(taskID string, scheduledFor time.Time) error {
+	lp.releasedFor = scheduledFor
+	return nil
+}
+
+// lockContainer returns a TaskLockProvider for the name 'lock', nothing for 'missing' and a TaskLogic for any other name
+type lockContainer struct {
+	lp TaskLockProvider
+}
+
+func (c *lockContainer) ComponentByName(name string) *ioc.Component {
+
+	switch name {
+	case "lock":
+		return ioc.NewComponent(name, c.lp)
+	case "missing":
+		return nil
+	default:
+		return ioc.NewComponent(name, new(nullLogic))
+	}
+}
+
+func (c *lockContainer) AllComponents() []*ioc.Component {
+	return []*ioc.Component{}
+}
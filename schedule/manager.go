@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-const firstRunFormat = "2006-01-02 15:04:05"
+const firstRunFormat = "2006-01-02 15:04:05 MST"
 
 func newInvocationManager(t *Task) *invocationManager {
 
@@ -24,10 +24,12 @@ func newInvocationManager(t *Task) *invocationManager {
 type invocationManager struct {
 	Task      *Task
 	Interval  *interval
+	Cron      *cronSchedule
 	scheduled *invocationQueue
 	running   *invocationQueue
 	State     ioc.ComponentState
 	Log       logging.Logger
+	observers []TaskObserver
 }
 
 func (im *invocationManager) Start() {
@@ -88,6 +90,11 @@ func (im *invocationManager) Start() {
 
 func (im *invocationManager) runTask(i *invocation) {
 
+	if !im.acquireLock(i) {
+		im.running.Remove(i.counter)
+		return
+	}
+
 	i.startedAt = time.Now()
 
 	if im.Log.IsLevelEnabled(logging.Trace) {
@@ -102,17 +109,23 @@ func (im *invocationManager) runTask(i *invocation) {
 		go im.listenForStatusUpdates(i, updates)
 	}
 
+	var err error
+
 	defer func() {
 		if r := recover(); r != nil {
 			im.Log.LogErrorfWithTrace("Panic recovered while executing task %s (invocation %d started at %v)\n %v", im.Task.FullName(), i.counter, i.startedAt, r)
+			err = fmt.Errorf("panic: %v", r)
 		}
 
 		close(updates)
+		im.releaseLock(i)
 		im.running.Remove(i.counter)
 
+		im.notifyObservers(i, err)
+
 	}()
 
-	err := im.Task.logic.ExecuteTask(updates)
+	err = im.Task.logic.ExecuteTask(updates)
 
 	if err != nil {
 
@@ -162,16 +175,38 @@ func (im *invocationManager) attemptRetry(i *invocation) (bool, time.Time) {
 
 }
 
-func (im *invocationManager) listenForStatusUpdates(i *invocation, ch chan TaskStatusUpdate) {
+func (im *invocationManager) summarise(i *invocation) TaskInvocationSummary {
 
 	task := im.Task
 
-	ts := TaskInvocationSummary{
+	return TaskInvocationSummary{
 		InvocationCount: i.counter,
 		StartedAt:       i.startedAt,
 		TaskID:          task.ID,
 		TaskName:        task.Name,
 	}
+}
+
+// notifyObservers informs any TaskObservers that an invocation of the task has finished
+func (im *invocationManager) notifyObservers(i *invocation, err error) {
+
+	if len(im.observers) == 0 {
+		return
+	}
+
+	elapsed := time.Since(i.startedAt)
+	ts := im.summarise(i)
+
+	for _, o := range im.observers {
+		o.TaskCompleted(ts, elapsed, err)
+	}
+}
+
+func (im *invocationManager) listenForStatusUpdates(i *invocation, ch chan TaskStatusUpdate) {
+
+	task := im.Task
+
+	ts := im.summarise(i)
 
 	for {
 		su, ok := <-ch
@@ -215,13 +250,20 @@ func (im *invocationManager) setFirstInvocation() {
 
 	i := newInvocation(1, im.Task.MaxRetries, Scheduled)
 
-	if interval.Mode == OffsetFromStart {
-		i.runAt = time.Now().Add(interval.OffsetFromStart)
+	if im.Cron != nil {
+		i.runAt = im.Cron.next(time.Now().In(im.Task.zone()))
+
+		im.Log.LogInfof("Task '%s' will first run at %s and then as described by the cron expression [%s]", im.Task.FullName(), i.runAt.Format(firstRunFormat), im.Cron.expression)
 	} else {
-		i.runAt = interval.ActualStart
-	}
 
-	im.Log.LogInfof("Task '%s' will first run at %s and intervals of %v thereafter", im.Task.FullName(), i.runAt.Format(firstRunFormat), interval.Frequency)
+		if interval.Mode == OffsetFromStart {
+			i.runAt = time.Now().Add(interval.OffsetFromStart)
+		} else {
+			i.runAt = interval.ActualStart
+		}
+
+		im.Log.LogInfof("Task '%s' will first run at %s and intervals of %v thereafter", im.Task.FullName(), i.runAt.Format(firstRunFormat), interval.Frequency)
+	}
 
 	t := im.Task
 
@@ -235,10 +277,19 @@ func (im *invocationManager) setFirstInvocation() {
 
 func (im *invocationManager) addNextInvocation(previous *invocation) time.Time {
 
-	interval := im.Interval
-
 	i := newInvocation(previous.counter+1, im.Task.MaxRetries, Scheduled)
-	i.runAt = previous.runAt.Add(interval.Frequency)
+
+	if im.Cron != nil {
+		i.runAt = im.Cron.next(previous.runAt)
+
+		if i.runAt.IsZero() {
+			im.Log.LogWarnf("Task '%s' will not run again as its cron expression [%s] has no more matching times", im.Task.FullName(), im.Cron.expression)
+			return i.runAt
+		}
+
+	} else {
+		i.runAt = im.Interval.next(previous.runAt)
+	}
 
 	im.scheduled.EnqueueAtTail(i)
 
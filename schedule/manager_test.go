@@ -1,6 +1,7 @@
 package schedule
 
 import (
+	"errors"
 	"github.com/graniticio/granitic/v2/logging"
 	"testing"
 	"time"
@@ -34,6 +35,37 @@ func TestInvocationManager(t *testing.T) {
 
 }
 
+func TestObserversNotified(t *testing.T) {
+
+	tsk := new(Task)
+	tsk.Name = "failing-task"
+	tsk.logic = new(failingLogic)
+
+	o := new(recordingObserver)
+
+	im := newInvocationManager(tsk)
+	im.Log = new(logging.ConsoleErrorLogger)
+	im.observers = []TaskObserver{o}
+
+	im.runTask(newInvocation(1, 0, "Test"))
+
+	if o.summary.TaskName != "failing-task" || o.summary.InvocationCount != 1 {
+		t.Errorf("Unexpected summary %v", o.summary)
+	}
+
+	if o.err == nil {
+		t.Errorf("Expected error to be passed to observer")
+	}
+
+	tsk.logic = new(panickingLogic)
+
+	im.runTask(newInvocation(2, 0, "Test"))
+
+	if o.err == nil || o.summary.InvocationCount != 2 {
+		t.Errorf("Expected panic to be passed to observer as an error")
+	}
+}
+
 func TestErrorCreation(t *testing.T) {
 
 	e := NewAllowRetryErrorf("Error = %s", "A")
@@ -50,3 +82,25 @@ type nullLogic struct{}
 func (nl *nullLogic) ExecuteTask(c chan TaskStatusUpdate) error {
 	return nil
 }
+
+type failingLogic struct{}
+
+func (fl *failingLogic) ExecuteTask(c chan TaskStatusUpdate) error {
+	return errors.New("failed")
+}
+
+type panickingLogic struct{}
+
+func (pl *panickingLogic) ExecuteTask(c chan TaskStatusUpdate) error {
+	panic("panicked")
+}
+
+type recordingObserver struct {
+	summary TaskInvocationSummary
+	err     error
+}
+
+func (ro *recordingObserver) TaskCompleted(summary TaskInvocationSummary, elapsed time.Duration, err error) {
+	ro.summary = summary
+	ro.err = err
+}
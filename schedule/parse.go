@@ -154,17 +154,19 @@ func configureRunAtModifier(offset string, i *interval, now time.Time) error {
 		return err
 	}
 
-	i.ActualStart = calculateFirstRun(te, i.Frequency)
+	i.WallClock = te
 	i.Mode = ActualStartTime
+	i.ActualStart = calculateFirstRun(i, now)
 
 	return nil
 }
 
-func calculateFirstRun(te timeElements, freq time.Duration) time.Time {
+// calculateFirstRun finds the first time after now (in now's location) that matches the 'at' time of the interval
+func calculateFirstRun(i *interval, now time.Time) time.Time {
 
-	now := time.Now()
+	te := i.WallClock
 
-	date := now.Format("2006-01-02")
+	y, mo, d := now.Date()
 
 	if te.hour == -1 {
 		te.hour = now.Hour()
@@ -174,12 +176,10 @@ func calculateFirstRun(te timeElements, freq time.Duration) time.Time {
 		te.minute = now.Minute()
 	}
 
-	proposedTime := fmt.Sprintf("%s %02d:%02d:%02d", date, te.hour, te.minute, te.second)
+	runTime := wallClockIn(time.Date(y, mo, d, te.hour, te.minute, te.second, 0, time.UTC), now.Location())
 
-	runTime, _ := time.Parse("2006-01-02 15:04:05", proposedTime)
-
-	if runTime.Before(time.Now()) {
-		runTime = runTime.Add(freq)
+	if runTime.Before(now) {
+		runTime = i.next(runTime)
 	}
 
 	return runTime
@@ -293,6 +293,23 @@ type interval struct {
 	ActualStart     time.Time
 	Frequency       time.Duration
 	CalculatedAt    time.Time
+	WallClock       timeElements
+}
+
+// next returns the time of the run after the supplied run. Intervals of a whole number of days with an 'at' time stay at
+// that time of day when clocks change (see wallClockIn for the treatment of times that are skipped or repeated). Other
+// intervals are a fixed amount of elapsed time.
+func (i *interval) next(previous time.Time) time.Time {
+
+	if i.Mode != ActualStartTime || i.Frequency%dayDuration != 0 {
+		return previous.Add(i.Frequency)
+	}
+
+	y, mo, d := previous.Date()
+	days := int(i.Frequency / dayDuration)
+	te := i.WallClock
+
+	return wallClockIn(time.Date(y, mo, d+days, te.hour, te.minute, te.second, 0, time.UTC), previous.Location())
 }
 
 type intervalMode int
@@ -0,0 +1,205 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTaskLockTable is the name of the table used by RdbmsTaskLock if its Table field is not set
+const DefaultTaskLockTable = "task_lock"
+
+// RdbmsTaskLock is a TaskLockProvider that stores the lease for each task as a row in a database table, allowing
+// instances of an application that share a database to make sure that each invocation of a task runs on only one
+// instance. The table must be created before the application starts:
+//
+//	CREATE TABLE task_lock (
+//	  task_id VARCHAR(255) NOT NULL PRIMARY KEY,
+//	  holder VARCHAR(255) NOT NULL,
+//	  scheduled_for BIGINT NOT NULL,
+//	  expires_at BIGINT NOT NULL
+//	)
+//
+// Times are stored as milliseconds since the Unix epoch and leases are compared with the clock of each instance, so the
+// clocks of the hosts running your instances should be synchronised.
+//
+// Declare as a component (the RdbmsAccess facility must be enabled) and set the LockProvider field of each Task to the
+// component's name:
+//
+//	"taskLock": {
+//	  "type": "schedule.RdbmsTaskLock"
+//	}
+type RdbmsTaskLock struct {
+	// Used to create clients connected to the database holding the lease table. Automatically injected by the
+	// RdbmsAccess facility.
+	DbClientManager rdbms.ClientManager
+
+	// The name of the table holding leases (defaults to DefaultTaskLockTable)
+	Table string
+
+	holder     string
+	holderOnce sync.Once
+	now        func() time.Time
+}
+
+// RegisterInstanceID implements instance.Receiver, using the ID of the instance to identify the holder of a lease
+func (rl *RdbmsTaskLock) RegisterInstanceID(i *instance.Identifier) {
+	rl.holder = i.ID
+}
+
+// AcquireTaskLock implements TaskLockProvider.AcquireTaskLock
+func (rl *RdbmsTaskLock) AcquireTaskLock(taskID string, scheduledFor time.Time, lease time.Duration) (bool, error) {
+
+	c, id, holder, err := rl.prepare(taskID)
+
+	if err != nil {
+		return false, err
+	}
+
+	now := rl.currentTime()
+	sf := millis(scheduledFor)
+	expires := millis(now.Add(lease))
+
+	// Take over an existing lease if it is available (see taskLease.available)
+	update := fmt.Sprintf("UPDATE %s SET holder = %s, scheduled_for = %d, expires_at = %d WHERE task_id = %s AND scheduled_for < %d AND expires_at <= %d",
+		rl.table(), holder, sf, expires, id, sf, millis(now))
+
+	r, err := c.Exec(update)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := r.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	if n > 0 {
+		return true, nil
+	}
+
+	// No rows were updated because the lease is not available or no lease exists for the task yet
+	if exists, err := rl.leaseExists(c, id); err != nil || exists {
+		return false, err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (task_id, holder, scheduled_for, expires_at) VALUES (%s, %s, %d, %d)",
+		rl.table(), id, holder, sf, expires)
+
+	if _, err = c.Exec(insert); err != nil {
+
+		if exists, _ := rl.leaseExists(c, id); exists {
+			// Another instance inserted a lease first
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseTaskLock implements TaskLockProvider.ReleaseTaskLock
+func (rl *RdbmsTaskLock) ReleaseTaskLock(taskID string, scheduledFor time.Time) error {
+
+	c, id, holder, err := rl.prepare(taskID)
+
+	if err != nil {
+		return err
+	}
+
+	release := fmt.Sprintf("UPDATE %s SET expires_at = %d WHERE task_id = %s AND holder = %s AND scheduled_for = %d",
+		rl.table(), millis(rl.currentTime()), id, holder, millis(scheduledFor))
+
+	_, err = c.Exec(release)
+
+	return err
+}
+
+// prepare creates a client and returns the task ID and holder as quoted SQL strings
+func (rl *RdbmsTaskLock) prepare(taskID string) (rdbms.Client, string, string, error) {
+
+	if rl.DbClientManager == nil {
+		return nil, "", "", errors.New("RdbmsTaskLock.DbClientManager has not been set")
+	}
+
+	id, err := sqlString(taskID)
+
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	holder, err := sqlString(rl.lockHolder())
+
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	c, err := rl.DbClientManager.Client()
+
+	return c, id, holder, err
+}
+
+// lockHolder returns the injected instance ID, or identifies this process if no ID was injected. Invocations of tasks
+// run concurrently, so the default is set only once.
+func (rl *RdbmsTaskLock) lockHolder() string {
+
+	rl.holderOnce.Do(func() {
+		if rl.holder == "" {
+			rl.holder = defaultLockHolder()
+		}
+	})
+
+	return rl.holder
+}
+
+func (rl *RdbmsTaskLock) leaseExists(c rdbms.Client, id string) (bool, error) {
+
+	var count int64
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE task_id = %s", rl.table(), id)
+
+	if err := c.QueryRow(query).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (rl *RdbmsTaskLock) table() string {
+
+	if rl.Table == "" {
+		return DefaultTaskLockTable
+	}
+
+	return rl.Table
+}
+
+func (rl *RdbmsTaskLock) currentTime() time.Time {
+
+	if rl.now != nil {
+		return rl.now()
+	}
+
+	return time.Now()
+}
+
+// sqlString quotes the supplied value as an SQL string. Values containing quotes or backslashes are rejected as they are
+// escaped differently by different databases.
+func sqlString(s string) (string, error) {
+
+	if strings.ContainsAny(s, `'\`) {
+		return "", fmt.Errorf("%s cannot be used with RdbmsTaskLock as it contains a quote or backslash", s)
+	}
+
+	return "'" + s + "'", nil
+}
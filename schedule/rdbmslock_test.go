@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/test/rdbmstest"
+	"testing"
+	"time"
+)
+
+const (
+	testAcquire = "UPDATE task_lock SET holder = 'a', scheduled_for = 1551430800000, expires_at = 1551430860000 WHERE task_id = 'report' AND scheduled_for < 1551430800000 AND expires_at <= 1551430800000"
+	testExists  = "SELECT COUNT(*) FROM task_lock WHERE task_id = 'report'"
+	testInsert  = "INSERT INTO task_lock (task_id, holder, scheduled_for, expires_at) VALUES ('report', 'a', 1551430800000, 1551430860000)"
+	testRelease = "UPDATE task_lock SET expires_at = 1551430800000 WHERE task_id = 'report' AND holder = 'a' AND scheduled_for = 1551430800000"
+)
+
+func TestRdbmsTaskLock(t *testing.T) {
+
+	now := time.Date(2019, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	cm := rdbmstest.NewClientManager()
+	rl := &RdbmsTaskLock{DbClientManager: cm, holder: "a", now: func() time.Time { return now }}
+
+	expectAcquired := func(expected bool, statements ...string) {
+
+		acquired, err := rl.AcquireTaskLock("report", now, time.Minute)
+
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+
+		if acquired != expected {
+			t.Errorf("Expected %v actual %v", expected, acquired)
+		}
+
+		e := cm.Provider.Executions()
+
+		if len(e) != len(statements) {
+			t.Fatalf("Expected %d statements, %d executed", len(statements), len(e))
+		}
+
+		for i, s := range statements {
+			if e[i].SQL != s {
+				t.Errorf("Unexpected statement %s", e[i].SQL)
+			}
+		}
+	}
+
+	// Existing lease available
+	cm.Provider.Script(testAcquire, rdbmstest.Affected(1))
+	expectAcquired(true, testAcquire)
+
+	// Existing lease held by another invocation
+	cm.Provider.Reset()
+	cm.Provider.Script(testAcquire, rdbmstest.Affected(0))
+	cm.Provider.Script(testExists, rdbmstest.Rows([]string{"count"}, []interface{}{1}))
+	expectAcquired(false, testAcquire, testExists)
+
+	// No lease for the task
+	cm.Provider.Reset()
+	cm.Provider.Script(testExists, rdbmstest.Rows([]string{"count"}, []interface{}{0}))
+	cm.Provider.Script(testInsert, rdbmstest.Affected(1))
+	expectAcquired(true, testAcquire, testExists, testInsert)
+
+	// Another instance inserted a lease first
+	cm.Provider.Reset()
+	cm.Provider.Script(testExists, rdbmstest.Rows([]string{"count"}, []interface{}{0}), rdbmstest.Rows([]string{"count"}, []interface{}{1}))
+	cm.Provider.Script(testInsert, rdbmstest.Failed(errors.New("duplicate key")))
+	expectAcquired(false, testAcquire, testExists, testInsert, testExists)
+
+	cm.Provider.Reset()
+
+	if err := rl.ReleaseTaskLock("report", now); err != nil || cm.Provider.Executions()[0].SQL != testRelease {
+		t.Errorf("Unexpected release %v %v", err, cm.Provider.Executions())
+	}
+
+	if _, err := rl.AcquireTaskLock("o'clock", now, time.Minute); err == nil {
+		t.Errorf("Expected error for task ID containing a quote")
+	}
+}
@@ -1,4 +1,67 @@
 // Copyright 2018-2019 Granitic. All rights reserved.
 // Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
 
+// Package schedule provides the TaskScheduler, which invokes components implementing TaskLogic according to the schedules
+// described by Task components.
+//
+// Every
+//
+// The Every field of a Task is an English expression of how frequently the task should run, optionally with the time at
+// which it should run:
+//
+//	10 seconds
+//	1 hour at 00:30:00
+//	day at 09:00
+//
+// Cron
+//
+// The Cron field of a Task is an alternative to Every, accepting a standard cron expression with five fields (minute,
+// hour, day of month, month, day of week) or six fields (with seconds first):
+//
+//	0 9 * * MON-FRI       09:00 on weekdays
+//	0 9 * * MON#1         09:00 on the first Monday of each month
+//	*/15 8-18 * * *       every 15 minutes between 08:00 and 18:45
+//	30 0 0 1,15 * *       00:00:30 on the 1st and 15th of each month
+//
+// Each field can be a value, a wildcard (* or ?), a range (a-b), a step (*/n, a-b/n or a/n) or a comma separated list of
+// these. Months and days of the week can be given as three letter names (JAN-DEC, SUN-SAT) and both 0 and 7 mean Sunday.
+// The day of week field also accepts day#n, meaning the nth occurrence of that day in the month. As with most cron
+// implementations, if both the day of month and day of week fields are restricted, a day matching either field matches.
+//
+// The shorthand expressions @yearly (or @annually), @monthly, @weekly, @daily (or @midnight) and @hourly are also
+// accepted.
+//
+// Exactly one of Every and Cron must be set. Expressions are validated when the TaskScheduler starts and an expression
+// that can never match (e.g. 0 0 30 FEB *) prevents the application from starting.
+//
+// Time zones and clock changes
+//
+// Times in Every and Cron are wall-clock times in the time zone named by the TimeZone field of a Task (an IANA name
+// like Europe/London), or the local time zone of the process if TimeZone is not set. When clocks change:
+//
+//	A time that is skipped when clocks go forward runs at the moment the clocks change (a task due at 02:30 runs
+//	at 03:00 if clocks go forward from 02:00 to 03:00). Several skipped times result in a single run.
+//
+//	A time that occurs twice when clocks go back runs only at its first occurrence.
+//
+//	Every expressions with an 'at' time and a whole number of days (e.g. day at 09:00) stay at that time of day.
+//	Other Every expressions (e.g. 2 hours) are a fixed amount of elapsed time, unaffected by clock changes.
+//
+// Running tasks on one instance
+//
+// If several instances of an application run the same Task, each instance runs every invocation of the task. Setting
+// the LockProvider field of a Task to the name of a component implementing TaskLockProvider causes each scheduled
+// invocation (and retry) to run only if that component grants a lock. Two implementations are provided:
+//
+//	RdbmsTaskLock stores a lease for each task in a database table, for instances sharing a database.
+//
+//	FileTaskLock stores a lease for each task in a file, for instances running on the same host.
+//
+// A lock is granted to an invocation if no other invocation holds an unexpired lease and no invocation scheduled for
+// the same or a later time has already been granted the lock. Leases are released when an invocation finishes, or
+// expire after the duration in the task's LockLease field (5 minutes by default) if an instance stops while running the
+// task. Tasks with a Cron expression or an 'at' time are scheduled for the same time on every instance, so each
+// invocation runs exactly once. Other tasks are scheduled relative to the time each instance started, so the lock only
+// prevents invocations overlapping. Invocations that are not granted a lock, or where the TaskLockProvider returns an
+// error, do not run. Manual invocations do not use the lock.
 package schedule
@@ -20,6 +20,13 @@ type TaskScheduler struct {
 	// Logger used by Granitic framework components. Automatically injected.
 	FrameworkLogger     logging.Logger
 	FrameworkLogManager *logging.ComponentLoggerManager
+	observers           []TaskObserver
+}
+
+// AddObserver registers a component that will be notified whenever an invocation of any task finishes. Must be called
+// before the TaskScheduler is started. Components in the IoC container that implement TaskObserver are registered automatically.
+func (ts *TaskScheduler) AddObserver(o TaskObserver) {
+	ts.observers = append(ts.observers, o)
 }
 
 // Container implements ioc.ContainerAccessor.Container
@@ -36,6 +43,16 @@ func (ts *TaskScheduler) StartComponent() error {
 		return nil
 	}
 
+	ts.FrameworkLogger.LogDebugf("Searching for schedule.TaskObserver components")
+
+	for _, component := range ts.componentContainer.AllComponents() {
+
+		if o, found := component.Instance.(TaskObserver); found {
+			ts.FrameworkLogger.LogDebugf("Found TaskObserver %s", component.Name)
+			ts.AddObserver(o)
+		}
+	}
+
 	ts.FrameworkLogger.LogDebugf("Searching for schedule.Task components")
 
 	for _, component := range ts.componentContainer.AllComponents() {
@@ -84,8 +101,13 @@ func (ts *TaskScheduler) validateAndPrepare(cn ioc.ComponentLookup, task *Task)
 		return err
 	}
 
-	if task.Every == "" {
-		m := fmt.Sprintf("You must set the 'Every' field to set an execution interval")
+	if task.Every == "" && task.Cron == "" {
+		m := fmt.Sprintf("You must set either the 'Every' or the 'Cron' field to set an execution interval")
+		return errors.New(m)
+	}
+
+	if task.Every != "" && task.Cron != "" {
+		m := fmt.Sprintf("The 'Every' and 'Cron' fields cannot both be set")
 		return errors.New(m)
 	}
 
@@ -112,17 +134,52 @@ func (ts *TaskScheduler) validateAndPrepare(cn ioc.ComponentLookup, task *Task)
 
 	}
 
+	if err := ts.findLockProvider(cn, task); err != nil {
+		return err
+	}
+
+	if task.TimeZone != "" {
+
+		loc, err := time.LoadLocation(task.TimeZone)
+
+		if err != nil {
+			m := fmt.Sprintf("The 'TimeZone' field [%s] is not a valid time zone name: %s", task.TimeZone, err.Error())
+			return errors.New(m)
+		}
+
+		task.location = loc
+	}
+
+	now := time.Now().In(task.zone())
+
 	tm := newInvocationManager(task)
-	ts.managedTasks = append(ts.managedTasks, tm)
-	tm.Log = ts.FrameworkLogManager.CreateLogger(task.Component + "TaskManager")
 
-	if interval, err := parseEvery(task.Every); err == nil {
+	if task.Cron != "" {
+
+		cs, err := parseCron(task.Cron)
+
+		if err != nil {
+			return err
+		}
+
+		if cs.next(now).IsZero() {
+			m := fmt.Sprintf("The cron expression [%s] never matches a date and time", task.Cron)
+			return errors.New(m)
+		}
+
+		tm.Cron = cs
+
+	} else if interval, err := parseEveryFromGivenNow(task.Every, now); err == nil {
 		tm.Interval = interval
 	} else {
 
 		return err
 	}
 
+	tm.observers = ts.observers
+	ts.managedTasks = append(ts.managedTasks, tm)
+	tm.Log = ts.FrameworkLogManager.CreateLogger(task.Component + "TaskManager")
+
 	return nil
 }
 
@@ -170,6 +227,48 @@ func (ts *TaskScheduler) findLogic(cn ioc.ComponentLookup, task *Task) error {
 	return nil
 }
 
+func (ts *TaskScheduler) findLockProvider(cn ioc.ComponentLookup, task *Task) error {
+
+	if task.LockProvider == "" {
+
+		if task.LockLease != "" {
+			return errors.New("The 'LockLease' field can only be set if 'LockProvider' is set")
+		}
+
+		return nil
+	}
+
+	lc := cn.ComponentByName(task.LockProvider)
+
+	if lc == nil {
+		m := fmt.Sprintf("LockProvider %s does not exist (no component with that name)", task.LockProvider)
+		return errors.New(m)
+	}
+
+	lp, okay := lc.Instance.(TaskLockProvider)
+
+	if !okay {
+		m := fmt.Sprintf("LockProvider %s does not implement schedule.TaskLockProvider", task.LockProvider)
+		return errors.New(m)
+	}
+
+	task.lockProvider = lp
+	task.lockLease = defaultLockLease
+
+	if task.LockLease != "" {
+
+		lease, err := parseNaturalToDuration(task.LockLease)
+
+		if err != nil {
+			return err
+		}
+
+		task.lockLease = lease
+	}
+
+	return nil
+}
+
 // PrepareToStop calls the same method of each of the managed Tasks
 func (ts *TaskScheduler) PrepareToStop() {
 
@@ -24,6 +24,11 @@ type Task struct {
 	NoWarnOnOverlap bool
 	// A human-readable expression (in English) of how frequently the task should be run - see package docs
 	Every string
+	// A cron expression describing when the task should be run (an alternative to Every) - see package docs
+	Cron string
+	// The IANA name of the time zone (e.g. Europe/London) that times in Every and Cron are expressed in. Defaults to the
+	// local time zone of the process
+	TimeZone string
 
 	// If set to true, any status updates messages sent from the task to the scheduler will be logged
 	LogStatusMessages bool
@@ -41,11 +46,25 @@ type Task struct {
 	// Must be set if MaxRetries > 0
 	RetryInterval string
 
+	// The name of a component implementing TaskLockProvider that must grant a lock before each invocation of this task runs
+	// (used to make sure an invocation runs on only one instance of an application)
+	LockProvider string
+
+	// A human-readable expression (in English) of the maximum time a lock is held if it is not released (e.g. 30 minutes).
+	// Defaults to 5 minutes
+	LockLease string
+
 	receiver TaskStatusUpdateReceiver
 
 	logic TaskLogic
 
 	retryWait time.Duration
+
+	lockProvider TaskLockProvider
+
+	lockLease time.Duration
+
+	location *time.Location
 }
 
 // FullName returns either task name + ID, just task name or just ID depending on which fields are set
@@ -87,6 +106,14 @@ type TaskStatusUpdateReceiver interface {
 	Receive(summary TaskInvocationSummary, update TaskStatusUpdate)
 }
 
+// TaskObserver is implemented by components that want to be notified whenever an invocation of any scheduled task
+// finishes. Any component implementing this interface is automatically found by the TaskScheduler.
+type TaskObserver interface {
+	// TaskCompleted is called after an invocation finishes. err is the error returned by the task's logic (or an error
+	// describing a recovered panic) or nil if the invocation succeeded.
+	TaskCompleted(summary TaskInvocationSummary, elapsed time.Duration, err error)
+}
+
 // TaskInvocationSummary meta-data about a task invocation
 type TaskInvocationSummary struct {
 	TaskName        string
@@ -0,0 +1,70 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package schedule
+
+import (
+	"time"
+)
+
+// wallClock returns the date and time shown by a clock in t's location at the instant t, as a time in UTC. Times in
+// UTC are not affected by clocks changing, so can be safely used for calendar arithmetic.
+func wallClock(t time.Time) time.Time {
+
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+
+	return time.Date(y, mo, d, h, mi, s, t.Nanosecond(), time.UTC)
+}
+
+// wallClockIn returns the instant at which clocks in loc show the date and time of the supplied wall-clock time (see
+// wallClock).
+//
+// If clocks go back and the wall-clock time occurs twice, the first occurrence is returned. If clocks go forward and the
+// wall-clock time does not occur at all, the instant at which the clocks changed is returned.
+func wallClockIn(w time.Time, loc *time.Location) time.Time {
+
+	y, mo, d := w.Date()
+	h, mi, s := w.Clock()
+
+	t := time.Date(y, mo, d, h, mi, s, w.Nanosecond(), loc)
+
+	start, end := t.ZoneBounds()
+
+	if actual := wallClock(t); actual.After(w) {
+		// The time was skipped and t is after the clocks went forward
+		return start
+	} else if actual.Before(w) {
+		// The time was skipped and t is before the clocks went forward
+		return end
+	}
+
+	if start.IsZero() {
+		// The offset from UTC has not changed before t
+		return t
+	}
+
+	_, offset := t.Zone()
+	_, previousOffset := start.Add(-time.Nanosecond).Zone()
+
+	if previousOffset > offset {
+		// Clocks went back at the start of this period, so the time might have already occurred before they changed
+		earlier := t.Add(-time.Duration(previousOffset-offset) * time.Second)
+
+		if earlier.Before(start) {
+			return earlier
+		}
+	}
+
+	return t
+}
+
+// zone returns the location that the task's schedule is based on
+func (t *Task) zone() *time.Location {
+
+	if t.location == nil {
+		return time.Local
+	}
+
+	return t.location
+}
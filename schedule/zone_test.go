@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// In America/New_York clocks went forward from 02:00 to 03:00 on 2019-03-10 and back from 02:00 to 01:00 on 2019-11-03
+const dstZone = "America/New_York"
+
+func zoneTime(t *testing.T, s string) time.Time {
+
+	loc, err := time.LoadLocation(dstZone)
+
+	if err != nil {
+		t.Fatalf("Unable to load time zone: %s", err)
+	}
+
+	zt, err := time.ParseInLocation(cronTestFormat, s, loc)
+
+	if err != nil {
+		t.Fatalf("Unable to parse test time: %s", err)
+	}
+
+	return zt
+}
+
+func expectInstant(t *testing.T, description string, actual time.Time, expectedUTC string) {
+
+	if a := actual.UTC().Format(cronTestFormat); a != expectedUTC {
+		t.Errorf("%s: expected %s UTC actual %s UTC (%s)", description, expectedUTC, a, actual.Format(firstRunFormat))
+	}
+}
+
+func TestWallClockIn(t *testing.T) {
+
+	loc := zoneTime(t, "2019-01-01 00:00:00").Location()
+
+	expectInstant(t, "normal time", wallClockIn(cronTime(t, "2019-06-01 09:00:00"), loc), "2019-06-01 13:00:00")
+	expectInstant(t, "skipped time", wallClockIn(cronTime(t, "2019-03-10 02:30:00"), loc), "2019-03-10 07:00:00")
+	expectInstant(t, "repeated time", wallClockIn(cronTime(t, "2019-11-03 01:30:00"), loc), "2019-11-03 05:30:00")
+	expectInstant(t, "after repeated time", wallClockIn(cronTime(t, "2019-11-03 02:00:00"), loc), "2019-11-03 07:00:00")
+	expectInstant(t, "UTC", wallClockIn(cronTime(t, "2019-03-10 02:30:00"), time.UTC), "2019-03-10 02:30:00")
+}
+
+func TestDailyIntervalAcrossClockChanges(t *testing.T) {
+
+	i := &interval{
+		Mode:      ActualStartTime,
+		Frequency: dayDuration,
+		WallClock: timeElements{hour: 9},
+	}
+
+	next := i.next(zoneTime(t, "2019-03-09 09:00:00"))
+	expectInstant(t, "09:00 after clocks go forward", next, "2019-03-10 13:00:00")
+
+	next = i.next(zoneTime(t, "2019-11-02 09:00:00"))
+	expectInstant(t, "09:00 after clocks go back", next, "2019-11-03 14:00:00")
+
+	i.WallClock = timeElements{hour: 2, minute: 30}
+
+	next = i.next(zoneTime(t, "2019-03-09 02:30:00"))
+	expectInstant(t, "skipped 02:30", next, "2019-03-10 07:00:00")
+
+	next = i.next(next)
+	expectInstant(t, "02:30 after skipped run", next, "2019-03-11 06:30:00")
+
+	i.WallClock = timeElements{hour: 1, minute: 30}
+
+	next = i.next(zoneTime(t, "2019-11-02 01:30:00"))
+	expectInstant(t, "repeated 01:30", next, "2019-11-03 05:30:00")
+
+	next = i.next(next)
+	expectInstant(t, "01:30 after repeated run", next, "2019-11-04 06:30:00")
+
+	i.Frequency = time.Hour
+
+	next = i.next(zoneTime(t, "2019-11-03 01:30:00"))
+	expectInstant(t, "hourly interval is elapsed time", next, "2019-11-03 06:30:00")
+}
+
+func TestFirstRunInTimeZone(t *testing.T) {
+
+	i, err := parseEveryFromGivenNow("day at 02:30", zoneTime(t, "2019-03-10 01:00:00"))
+
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expectInstant(t, "first run at skipped time", i.ActualStart, "2019-03-10 07:00:00")
+
+	i, _ = parseEveryFromGivenNow("day at 09:00", zoneTime(t, "2019-06-01 10:00:00"))
+	expectInstant(t, "first run tomorrow", i.ActualStart, "2019-06-02 13:00:00")
+}
+
+func TestCronAcrossClockChanges(t *testing.T) {
+
+	daily, _ := parseCron("30 2 * * *")
+
+	next := daily.next(zoneTime(t, "2019-03-10 00:00:00"))
+	expectInstant(t, "cron skipped 02:30", next, "2019-03-10 07:00:00")
+
+	next = daily.next(next)
+	expectInstant(t, "cron 02:30 after skipped run", next, "2019-03-11 06:30:00")
+
+	daily, _ = parseCron("30 1 * * *")
+
+	next = daily.next(zoneTime(t, "2019-11-03 00:00:00"))
+	expectInstant(t, "cron repeated 01:30", next, "2019-11-03 05:30:00")
+
+	next = daily.next(next)
+	expectInstant(t, "cron 01:30 runs once", next, "2019-11-04 06:30:00")
+
+	halfHourly, _ := parseCron("*/30 * * * *")
+
+	next = halfHourly.next(zoneTime(t, "2019-11-03 01:30:00"))
+	expectInstant(t, "cron repeated hour runs once", next, "2019-11-03 07:00:00")
+
+	next = halfHourly.next(zoneTime(t, "2019-03-10 01:30:00"))
+	expectInstant(t, "cron half hourly over skipped hour", next, "2019-03-10 07:00:00")
+
+	next = halfHourly.next(next)
+	expectInstant(t, "cron half hourly after skipped hour", next, "2019-03-10 07:30:00")
+}
+
+func TestTimeZoneValidation(t *testing.T) {
+
+	ts := new(TaskScheduler)
+	ts.FrameworkLogManager = newTestLogManager()
+
+	tsk := &Task{Component: "logic", Cron: "0 9 * * *", TimeZone: "Europe/London"}
+
+	if err := ts.validateAndPrepare(new(logicContainer), tsk); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if tsk.zone().String() != "Europe/London" {
+		t.Errorf("Unexpected location %s", tsk.zone())
+	}
+
+	tsk = &Task{Component: "logic", Cron: "0 9 * * *", TimeZone: "Mars/Olympus_Mons"}
+
+	if err := ts.validateAndPrepare(new(logicContainer), tsk); err == nil {
+		t.Errorf("Expected error for invalid time zone")
+	}
+
+	if new(Task).zone() != time.Local {
+		t.Errorf("Expected tasks without a time zone to use the local time zone")
+	}
+}
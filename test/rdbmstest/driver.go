@@ -0,0 +1,220 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbmstest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// boundQuery is passed to the driver as the only argument of a statement built from a query ID, so that the query ID
+// and parameters can be recorded.
+type boundQuery struct {
+	qid    string
+	sql    string
+	params map[string]interface{}
+}
+
+func boundQueryFromArgs(args []driver.NamedValue) *boundQuery {
+
+	if len(args) != 1 {
+		return nil
+	}
+
+	bq, _ := args[0].Value.(*boundQuery)
+
+	return bq
+}
+
+type connector struct {
+	dp *DatabaseProvider
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{dp: c.dp}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+type testDriver struct{}
+
+func (d testDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("connections can only be opened with DatabaseProvider.Database")
+}
+
+// conn is a connection to a DatabaseProvider. tx is the position of the connection's open transaction in the
+// DatabaseProvider's list of transactions (or zero).
+type conn struct {
+	dp *DatabaseProvider
+	tx int
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+
+	if c.tx != 0 {
+		return nil, errors.New("connection already has an open transaction")
+	}
+
+	c.tx = c.dp.begin()
+
+	return &tx{c: c}, nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+
+	if _, found := nv.Value.(*boundQuery); found {
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+
+	r, err := c.dp.execute(c.tx, query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{r: r}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+
+	r, err := c.dp.execute(c.tx, query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{r: r}, nil
+}
+
+type stmt struct {
+	c     *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+
+	nv := make([]driver.NamedValue, len(args))
+
+	for i, a := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: a}
+	}
+
+	return nv
+}
+
+type tx struct {
+	c *conn
+}
+
+func (t *tx) Commit() error {
+	t.c.dp.end(t.c.tx, true)
+	t.c.tx = 0
+
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.c.dp.end(t.c.tx, false)
+	t.c.tx = 0
+
+	return nil
+}
+
+type result struct {
+	r *Result
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.r.LastInsertID, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.r.RowsAffected, nil
+}
+
+type rows struct {
+	r    *Result
+	next int
+}
+
+func (r *rows) Columns() []string {
+	return r.r.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+
+	if r.next >= len(r.r.Rows) {
+		return io.EOF
+	}
+
+	row := r.r.Rows[r.next]
+	r.next++
+
+	if len(row) != len(dest) {
+		return fmt.Errorf("scripted row has %d values but the result has %d columns", len(row), len(dest))
+	}
+
+	for i, v := range row {
+
+		cv, err := driver.DefaultParameterConverter.ConvertValue(v)
+
+		if err != nil {
+			return err
+		}
+
+		dest[i] = cv
+	}
+
+	return nil
+}
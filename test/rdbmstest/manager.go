@@ -0,0 +1,130 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package rdbmstest
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"sync"
+)
+
+// NewClientManager creates a ClientManager with a new DatabaseProvider.
+func NewClientManager() *ClientManager {
+
+	cm := new(ClientManager)
+	cm.Provider = new(DatabaseProvider)
+
+	return cm
+}
+
+// ClientManager is an implementation of rdbms.ClientManager that creates ManagedClients connected to a DatabaseProvider.
+type ClientManager struct {
+	// The DatabaseProvider statements are sent to. Created when the first client is created if not set.
+	Provider *DatabaseProvider
+
+	// If set, queries are built with this QueryManager before they are sent to Provider.
+	Templates dsquery.QueryManager
+
+	// Passed to the RowBinder used by clients (see rdbms.RowBinder.SnakeCaseColumns).
+	SnakeCaseColumns bool
+
+	mu      sync.Mutex
+	manager *rdbms.GraniticRdbmsClientManager
+}
+
+// Client implements rdbms.ClientManager.Client
+func (cm *ClientManager) Client() (rdbms.Client, error) {
+	return cm.clientManager().Client()
+}
+
+// ClientFromContext implements rdbms.ClientManager.ClientFromContext
+func (cm *ClientManager) ClientFromContext(ctx context.Context) (rdbms.Client, error) {
+	return cm.clientManager().ClientFromContext(ctx)
+}
+
+// clientManager lazily creates the GraniticRdbmsClientManager that clients are created by.
+func (cm *ClientManager) clientManager() *rdbms.GraniticRdbmsClientManager {
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if cm.manager != nil {
+		return cm.manager
+	}
+
+	if cm.Provider == nil {
+		cm.Provider = new(DatabaseProvider)
+	}
+
+	conf := new(rdbms.ClientManagerConfig)
+	conf.Provider = cm.Provider
+	conf.ClientName = "rdbmstestClient"
+	conf.DisableStatementReuse = true
+	conf.DisableQueryStats = true
+	conf.SnakeCaseColumns = cm.SnakeCaseColumns
+
+	log := logging.CreateAnonymousLogger(conf.ClientName, logging.Fatal)
+
+	m := new(rdbms.GraniticRdbmsClientManager)
+	m.DisableAutoInjection = true
+	m.Configuration = conf
+	m.QueryManager = &recordingQueryManager{templates: cm.Templates}
+	m.FrameworkLogger = log
+	m.SharedLog = log
+
+	// Cannot fail - no health checks or read replicas are configured
+	m.StartComponent()
+
+	cm.manager = m
+
+	return m
+}
+
+// recordingQueryManager passes the query ID and parameters of each query to the driver as a single bound argument.
+type recordingQueryManager struct {
+	templates dsquery.QueryManager
+}
+
+func (qm *recordingQueryManager) BindsParameters() bool {
+	return true
+}
+
+func (qm *recordingQueryManager) BuildParameterisedQueryFromID(qid string, params map[string]interface{}) (string, []interface{}, error) {
+
+	bq := &boundQuery{qid: qid, params: params}
+
+	if qm.templates != nil {
+
+		q, err := qm.templates.BuildQueryFromID(qid, params)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		bq.sql = q
+	}
+
+	return qid, []interface{}{bq}, nil
+}
+
+func (qm *recordingQueryManager) BuildQueryFromID(qid string, params map[string]interface{}) (string, error) {
+
+	if qm.templates != nil {
+		return qm.templates.BuildQueryFromID(qid, params)
+	}
+
+	return qid, nil
+}
+
+func (qm *recordingQueryManager) FragmentFromID(qid string) (string, error) {
+
+	if qm.templates != nil {
+		return qm.templates.FragmentFromID(qid)
+	}
+
+	return "", errors.New("fragments are only available if ClientManager.Templates is set")
+}
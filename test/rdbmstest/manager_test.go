@@ -0,0 +1,139 @@
+package rdbmstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+type artist struct {
+	ID   int64
+	Name string
+}
+
+func TestSelectBindsScriptedRows(t *testing.T) {
+
+	cm := NewClientManager()
+	cm.Provider.Script("ARTISTS", Rows([]string{"ID", "Name"}, []interface{}{1, "Ann"}, []interface{}{2, "Bob"}))
+
+	c, err := cm.Client()
+	test.ExpectNil(t, err)
+
+	r, err := c.SelectBindQIDParam("ARTISTS", "genre", "jazz", new(artist))
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(r), 2)
+	test.ExpectString(t, r[1].(*artist).Name, "Bob")
+
+	e := cm.Provider.ExecutionsOf("ARTISTS")
+	test.ExpectInt(t, len(e), 1)
+	test.ExpectString(t, e[0].Params["genre"].(string), "jazz")
+	test.ExpectInt(t, e[0].Transaction, 0)
+}
+
+func TestScriptedResultsReturnedInOrder(t *testing.T) {
+
+	cm := NewClientManager()
+	cm.Provider.Script("INSERT_ARTIST", InsertedID(10), Failed(errors.New("duplicate")))
+
+	c, _ := cm.ClientFromContext(context.Background())
+
+	var id int64
+
+	test.ExpectNil(t, c.InsertCaptureQIDParams("INSERT_ARTIST", &id, map[string]interface{}{"name": "Ann"}))
+	test.ExpectInt(t, int(id), 10)
+
+	test.ExpectNotNil(t, c.InsertCaptureQIDParams("INSERT_ARTIST", &id))
+	test.ExpectNotNil(t, c.InsertCaptureQIDParams("INSERT_ARTIST", &id))
+
+	// Unscripted statements succeed without affecting any rows
+	r, err := c.UpdateQIDParams("UPDATE_ARTIST")
+	test.ExpectNil(t, err)
+
+	n, _ := r.RowsAffected()
+	test.ExpectInt(t, int(n), 0)
+
+	test.ExpectInt(t, len(cm.Provider.Executions()), 4)
+}
+
+func TestTransactionsRecorded(t *testing.T) {
+
+	cm := NewClientManager()
+	c, _ := cm.Client()
+
+	err := c.WithTransaction(nil, nil, func(tc rdbms.Client) error {
+		_, err := tc.DeleteQIDParams("DELETE_ARTIST")
+		return err
+	})
+
+	test.ExpectNil(t, err)
+
+	c.StartTransaction()
+	c.InsertQIDParams("INSERT_ARTIST")
+	c.Rollback()
+
+	c, _ = cm.Client()
+	c.StartTransaction()
+
+	tx := cm.Provider.Transactions()
+	test.ExpectInt(t, len(tx), 3)
+	test.ExpectBool(t, tx[0].Committed, true)
+	test.ExpectBool(t, tx[1].RolledBack, true)
+	test.ExpectBool(t, tx[2].Open(), true)
+
+	test.ExpectInt(t, cm.Provider.ExecutionsOf("DELETE_ARTIST")[0].Transaction, 1)
+	test.ExpectInt(t, cm.Provider.ExecutionsOf("INSERT_ARTIST")[0].Transaction, 2)
+}
+
+func TestPassthroughStatements(t *testing.T) {
+
+	cm := new(ClientManager)
+	c, _ := cm.Client()
+
+	cm.Provider.Script("SELECT COUNT(*) FROM artist WHERE genre = ?", Rows([]string{"count"}, []interface{}{3}))
+
+	var count int
+
+	test.ExpectNil(t, c.QueryRow("SELECT COUNT(*) FROM artist WHERE genre = ?", "jazz").Scan(&count))
+	test.ExpectInt(t, count, 3)
+
+	e := cm.Provider.Executions()[0]
+	test.ExpectString(t, e.QueryID, "")
+	test.ExpectString(t, e.Args[0].(string), "jazz")
+
+	cm.Provider.Reset()
+	test.ExpectInt(t, len(cm.Provider.Executions()), 0)
+}
+
+func TestTemplatesUsedToBuildQueries(t *testing.T) {
+
+	cm := NewClientManager()
+	cm.Templates = new(stubQueryManager)
+
+	c, _ := cm.Client()
+
+	_, err := c.DeleteQIDParam("DELETE_ARTIST", "id", 4)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, cm.Provider.Executions()[0].SQL, "DELETE_ARTIST id=4")
+
+	_, err = c.DeleteQIDParams("MISSING")
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, len(cm.Provider.Executions()), 1)
+}
+
+type stubQueryManager struct{}
+
+func (qm *stubQueryManager) BuildQueryFromID(qid string, params map[string]interface{}) (string, error) {
+
+	if qid == "MISSING" {
+		return "", errors.New("no such query")
+	}
+
+	return fmt.Sprintf("%s id=%v", qid, params["id"]), nil
+}
+
+func (qm *stubQueryManager) FragmentFromID(qid string) (string, error) {
+	return qid, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package rdbmstest provides an in-memory DatabaseProvider and ClientManager for unit testing components that use the
+rdbms package, without needing access to a real database.
+
+Statements executed by a ManagedClient created by a ClientManager from this package are sent to a DatabaseProvider that
+records the query ID and parameters of each statement and returns results that have been scripted by your test:
+
+	cm := rdbmstest.NewClientManager()
+	cm.Provider.Script("ARTIST_BY_ID", rdbmstest.Rows([]string{"id", "name"}, []interface{}{1, "Ann"}))
+
+	dao := new(ArtistDAO)
+	dao.DBClientManager = cm
+
+	a, err := dao.Load(1)
+
+	e := cm.Provider.ExecutionsOf("ARTIST_BY_ID")
+	// e[0].Params["id"] == 1
+
+Because clients are real ManagedClients, results are bound to your types with rdbms.RowBinder and transactions
+behave as they would with a real database. The transactions started are recorded (see DatabaseProvider.Transactions) so
+tests can check that work was committed or rolled back.
+
+The zero values of ClientManager and DatabaseProvider are ready to use, so they can be declared as components in a
+component definition file used by tests and injected into the components under test with grnc-bind:
+
+	"packages": [
+	  "github.com/graniticio/granitic/v2/test/rdbmstest"
+	],
+
+	"components": {
+	  "testDatabase": {
+	    "type": "rdbmstest.DatabaseProvider"
+	  },
+	  "artistDAO": {
+	    "type": "ArtistDAO",
+	    "DBClientManager": {
+	      "type": "rdbmstest.ClientManager",
+	      "Provider": "ref:testDatabase"
+	    }
+	  }
+	}
+
+Query templates
+
+By default, queries are not built from templates - a statement is identified only by its query ID. If a
+dsquery.QueryManager is set as ClientManager.Templates, each query is also built with that QueryManager so that missing
+templates and required parameters cause the same errors they would in your application. The built query is recorded
+as Execution.SQL.
+*/
+package rdbmstest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+)
+
+// Rows creates a Result returning the supplied rows. Each row must have one value for each column.
+func Rows(columns []string, rows ...[]interface{}) *Result {
+	return &Result{Columns: columns, Rows: rows}
+}
+
+// Affected creates a Result for a statement that modified the supplied number of rows.
+func Affected(rows int64) *Result {
+	return &Result{RowsAffected: rows}
+}
+
+// InsertedID creates a Result for an INSERT that created a row with the supplied ID.
+func InsertedID(id int64) *Result {
+	return &Result{LastInsertID: id, RowsAffected: 1}
+}
+
+// Failed creates a Result that causes the statement to return the supplied error.
+func Failed(err error) *Result {
+	return &Result{Err: err}
+}
+
+// Result is the scripted outcome of executing a statement.
+type Result struct {
+	// The names of the columns returned by a query.
+	Columns []string
+
+	// The rows returned by a query. Values are converted with driver.DefaultParameterConverter.
+	Rows [][]interface{}
+
+	// The value of sql.Result.LastInsertId
+	LastInsertID int64
+
+	// The value of sql.Result.RowsAffected
+	RowsAffected int64
+
+	// If set, the statement returns this error instead of a result.
+	Err error
+}
+
+// Execution is a record of a statement sent to a DatabaseProvider.
+type Execution struct {
+	// The ID of the query the statement was built from. Empty if the statement was executed with the Exec, Query or
+	// QueryRow methods of ManagedClient.
+	QueryID string
+
+	// The text of the statement if it was not built from a query ID, or the query built by ClientManager.Templates.
+	SQL string
+
+	// The parameters supplied to the query.
+	Params map[string]interface{}
+
+	// The arguments supplied with a statement that was not built from a query ID.
+	Args []interface{}
+
+	// The position (starting at 1) in DatabaseProvider.Transactions of the transaction the statement was executed in.
+	// Zero if the statement was executed outside of a transaction.
+	Transaction int
+}
+
+// Transaction is a record of a transaction started on a DatabaseProvider.
+type Transaction struct {
+	// Whether or not the transaction was committed.
+	Committed bool
+
+	// Whether or not the transaction was rolled back.
+	RolledBack bool
+}
+
+// Open returns true if the transaction has not been committed or rolled back.
+func (t Transaction) Open() bool {
+	return !t.Committed && !t.RolledBack
+}
+
+// DatabaseProvider is an implementation of rdbms.DatabaseProvider that records the statements executed against it and
+// returns scripted results. It is goroutine safe.
+type DatabaseProvider struct {
+	mu           sync.Mutex
+	db           *sql.DB
+	scripts      map[string][]*Result
+	executions   []Execution
+	transactions []Transaction
+}
+
+// Database implements rdbms.DatabaseProvider.Database
+func (dp *DatabaseProvider) Database() (*sql.DB, error) {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	if dp.db == nil {
+		dp.db = sql.OpenDB(&connector{dp: dp})
+	}
+
+	return dp.db, nil
+}
+
+// Script sets the results returned when the statement with the supplied key is executed. The key is the query ID of
+// a statement built from a query template or the text of a statement executed with Exec, Query or QueryRow.
+//
+// Results are returned in order, with the last result returned for all subsequent executions. Statements that have
+// not been scripted return no rows and report no rows affected.
+func (dp *DatabaseProvider) Script(key string, results ...*Result) {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	if dp.scripts == nil {
+		dp.scripts = make(map[string][]*Result)
+	}
+
+	dp.scripts[key] = results
+}
+
+// Executions returns all of the statements executed, in the order they were executed.
+func (dp *DatabaseProvider) Executions() []Execution {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	return append([]Execution(nil), dp.executions...)
+}
+
+// ExecutionsOf returns the statements built from the supplied query ID, in the order they were executed.
+func (dp *DatabaseProvider) ExecutionsOf(qid string) []Execution {
+
+	var matched []Execution
+
+	for _, e := range dp.Executions() {
+		if e.QueryID == qid {
+			matched = append(matched, e)
+		}
+	}
+
+	return matched
+}
+
+// Transactions returns all of the transactions started, in the order they were started.
+func (dp *DatabaseProvider) Transactions() []Transaction {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	return append([]Transaction(nil), dp.transactions...)
+}
+
+// Reset discards all scripted results and the records of executed statements and transactions.
+func (dp *DatabaseProvider) Reset() {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	dp.scripts = nil
+	dp.executions = nil
+	dp.transactions = nil
+}
+
+// execute records a statement and returns its scripted result.
+func (dp *DatabaseProvider) execute(tx int, query string, args []driver.NamedValue) (*Result, error) {
+
+	e := Execution{Transaction: tx}
+
+	if bq := boundQueryFromArgs(args); bq != nil {
+		e.QueryID = bq.qid
+		e.SQL = bq.sql
+		e.Params = bq.params
+	} else {
+		e.SQL = query
+
+		for _, a := range args {
+			e.Args = append(e.Args, a.Value)
+		}
+	}
+
+	key := e.QueryID
+
+	if key == "" {
+		key = e.SQL
+	}
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	dp.executions = append(dp.executions, e)
+
+	r := new(Result)
+
+	if scripted := dp.scripts[key]; len(scripted) > 0 {
+		r = scripted[0]
+
+		if len(scripted) > 1 {
+			dp.scripts[key] = scripted[1:]
+		}
+	}
+
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	return r, nil
+}
+
+// begin records a new transaction and returns its position in the list of transactions.
+func (dp *DatabaseProvider) begin() int {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	dp.transactions = append(dp.transactions, Transaction{})
+
+	return len(dp.transactions)
+}
+
+// end records the outcome of a transaction.
+func (dp *DatabaseProvider) end(tx int, committed bool) {
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+
+	t := &dp.transactions[tx-1]
+
+	t.Committed = committed
+	t.RolledBack = !committed
+}
@@ -0,0 +1,35 @@
+package rdbmstest
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestProviderUsableDirectly(t *testing.T) {
+
+	dp := new(DatabaseProvider)
+	dp.Script("SELECT name FROM artist", Rows([]string{"name"}, []interface{}{"Ann"}, []interface{}{"Bob", "extra"}))
+
+	db, err := dp.Database()
+	test.ExpectNil(t, err)
+
+	r, err := db.Query("SELECT name FROM artist")
+	test.ExpectNil(t, err)
+
+	var name string
+
+	test.ExpectBool(t, r.Next(), true)
+	test.ExpectNil(t, r.Scan(&name))
+	test.ExpectString(t, name, "Ann")
+
+	// The second scripted row has too many values
+	test.ExpectBool(t, r.Next(), false)
+	test.ExpectNotNil(t, r.Err())
+
+	tx, _ := db.Begin()
+	tx.Exec("DELETE FROM artist")
+	tx.Commit()
+
+	test.ExpectInt(t, dp.Executions()[1].Transaction, 1)
+	test.ExpectBool(t, dp.Transactions()[0].Committed, true)
+}
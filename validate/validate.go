@@ -126,6 +126,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/types"
@@ -163,6 +164,11 @@ const commonOpLen = "LEN"
 
 const lengthPattern = "^(\\d*)-(\\d*)$"
 
+// RuleEvent is the ID of the instrumentation event started by RuleValidator each time a rule is applied to a field. The
+// component name of the RuleValidator and the name of the field are passed to the event as metadata. Rules that are
+// skipped (because a parent object is invalid or an earlier rule stopped validation) do not generate an event.
+const RuleEvent = "validate-rule"
+
 // SubjectContext is a wrapper for an object (the subject) to be validated
 type SubjectContext struct {
 	//An instance of a object to be validated.
@@ -404,7 +410,9 @@ Rules:
 			continue
 		}
 
+		endRule := instrument.Event(ctx, RuleEvent, ov.componentName, f)
 		r, err := vl.validationRule.Validate(vc)
+		endRule()
 
 		if err != nil {
 			return nil, err
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
@@ -69,6 +70,56 @@ func TestConfigParsing(t *testing.T) {
 
 }
 
+func TestRulesInstrumented(t *testing.T) {
+
+	ov, u := validatorAndUser(t)
+	ov.componentName = "profileValidator"
+
+	ri := new(ruleRecorder)
+	ctx := instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	sc := new(SubjectContext)
+	sc.Subject = u
+
+	_, err := ov.Validate(ctx, sc)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(ri.fields), len(ov.validatorChain))
+
+	for i, vl := range ov.validatorChain {
+		test.ExpectString(t, ri.fields[i], vl.field)
+	}
+
+	for _, n := range ri.validators {
+		test.ExpectString(t, n, "profileValidator")
+	}
+}
+
+type ruleRecorder struct {
+	validators []string
+	fields     []string
+}
+
+func (rr *ruleRecorder) StartEvent(id string, metadata ...interface{}) instrument.EndEvent {
+
+	if id == RuleEvent && len(metadata) == 2 {
+		rr.validators = append(rr.validators, metadata[0].(string))
+		rr.fields = append(rr.fields, metadata[1].(string))
+	}
+
+	return func() {}
+}
+
+func (rr *ruleRecorder) Fork(ctx context.Context) (context.Context, instrument.Instrumentor) {
+	return ctx, rr
+}
+
+func (rr *ruleRecorder) Integrate(instrumentor instrument.Instrumentor) {
+}
+
+func (rr *ruleRecorder) Amend(additional instrument.Additional, value interface{}) {
+}
+
 func validatorAndUser(t *testing.T) (*RuleValidator, *User) {
 	ca := LoadTestConfig()
 
@@ -56,6 +56,25 @@ import (
 
 const processPayloadFunc = "ProcessPayload"
 
+// IDs of the instrumentation events started by WsHandler for each phase of request processing. The component name of
+// the handler is passed as metadata to each event.
+const (
+	// IdentifyEvent covers the identification and authentication of the caller by the handler's UserIdentifier
+	IdentifyEvent = "identify"
+	// AccessEvent covers the check made by the handler's AccessChecker
+	AccessEvent = "access"
+	// UnmarshalEvent covers the unmarshalling of the request body
+	UnmarshalEvent = "unmarshal"
+	// BindEvent covers the parsing and binding of query and path parameters
+	BindEvent = "bind"
+	// ValidateEvent covers automatic and custom validation of the request
+	ValidateEvent = "validate"
+	// ProcessEvent covers the execution of the handler's Logic component and any PostProcessor
+	ProcessEvent = "process"
+	// WriteEvent covers the writing of the response
+	WriteEvent = "write"
+)
+
 // WsRequestProcessor specifies the minimum required of a component to be considered a 'logic' component suitable for
 // use by a WsHandler.
 type WsRequestProcessor interface {
@@ -272,9 +291,14 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 	}
 
 	//Unmarshall body, query parameters and path parameters
+	endUnmarshal := instrument.Event(ctx, UnmarshalEvent, wh.ComponentName())
 	wh.unmarshall(ctx, req, wsReq)
+	endUnmarshal()
+
+	endBind := instrument.Event(ctx, BindEvent, wh.ComponentName())
 	wh.processQueryParams(ctx, req, wsReq)
 	wh.processPathParams(req, wsReq)
+	endBind()
 
 	if wsReq.HasFrameworkErrors() && !wh.DeferFrameworkErrors {
 		wh.handleFrameworkErrors(ctx, w, wsReq)
@@ -290,7 +314,9 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 	var errors ws.ServiceErrors
 	errors.ErrorFinder = wh.ErrorFinder
 
+	endValidate := instrument.Event(ctx, ValidateEvent, wh.ComponentName())
 	wh.validateRequest(ctx, wsReq, &errors)
+	endValidate()
 
 	if errors.HasErrors() {
 		wh.writeErrorResponse(ctx, &errors, w, wsReq)
@@ -452,7 +478,9 @@ func (wh *WsHandler) checkAccess(ctx context.Context, w *httpendpoint.HTTPRespon
 		return true
 	}
 
+	endAccess := instrument.Event(ctx, AccessEvent, wh.ComponentName())
 	allowed := ac.Allowed(ctx, wsReq)
+	endAccess()
 
 	if allowed {
 		return true
@@ -473,7 +501,10 @@ func (wh *WsHandler) identifyAndAuthenticate(ctx context.Context, w *httpendpoin
 
 	if wh.UserIdentifier != nil {
 
+		endIdentify := instrument.Event(ctx, IdentifyEvent, wh.ComponentName())
 		i, ctx = wh.UserIdentifier.Identify(ctx, req)
+		endIdentify()
+
 		wsReq.UserIdentity = i
 
 		if wh.RequireAuthentication && !i.Authenticated() {
@@ -553,22 +584,7 @@ func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpen
 
 	wsRes := ws.NewResponse(wh.ErrorFinder)
 
-	if wh.genericProcessor != nil {
-		//Logic component implements WsRequestProcessor
-		wh.genericProcessor.Process(ctx, request, wsRes)
-	} else {
-		//Call the ProcessPayload method via reflection which allows us to pass in the body of the response as a typed object
-		//without knowing the type at compile time
-		method := reflect.ValueOf(wh.Logic).MethodByName(processPayloadFunc)
-
-		va := []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(request), reflect.ValueOf(wsRes), reflect.ValueOf(request.RequestBody)}
-
-		method.Call(va)
-	}
-
-	if wh.PostProcessor != nil {
-		wh.PostProcessor.PostProcess(ctx, wh.ComponentName(), request, wsRes)
-	}
+	wh.invokeLogic(ctx, request, wsRes)
 
 	state := new(ws.ProcessState)
 	state.Identity = request.UserIdentity
@@ -584,18 +600,44 @@ func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpen
 
 	var err error
 
+	endWrite := instrument.Event(ctx, WriteEvent, wh.ComponentName())
+
 	if wsRes.HTTPStatus < 300 {
 		err = wh.ResponseWriter.Write(ctx, state, ws.Normal)
 	} else {
 		err = wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
 	}
 
+	endWrite()
+
 	if err != nil {
 		wh.Log.LogErrorfCtx(ctx, "Problem writing response: %s", err.Error())
 	}
 
 }
 
+func (wh *WsHandler) invokeLogic(ctx context.Context, request *ws.Request, wsRes *ws.Response) {
+
+	defer instrument.Event(ctx, ProcessEvent, wh.ComponentName())()
+
+	if wh.genericProcessor != nil {
+		//Logic component implements WsRequestProcessor
+		wh.genericProcessor.Process(ctx, request, wsRes)
+	} else {
+		//Call the ProcessPayload method via reflection which allows us to pass in the body of the response as a typed object
+		//without knowing the type at compile time
+		method := reflect.ValueOf(wh.Logic).MethodByName(processPayloadFunc)
+
+		va := []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(request), reflect.ValueOf(wsRes), reflect.ValueOf(request.RequestBody)}
+
+		method.Call(va)
+	}
+
+	if wh.PostProcessor != nil {
+		wh.PostProcessor.PostProcess(ctx, wh.ComponentName(), request, wsRes)
+	}
+}
+
 func (wh *WsHandler) writeErrorResponse(ctx context.Context, errors *ws.ServiceErrors, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request) {
 
 	l := wh.Log
@@ -627,7 +669,9 @@ func (wh *WsHandler) writeErrorResponse(ctx context.Context, errors *ws.ServiceE
 
 	}
 
+	endWrite := instrument.Event(ctx, WriteEvent, wh.ComponentName())
 	err := wh.ResponseWriter.Write(ctx, state, ws.Error)
+	endWrite()
 
 	if err != nil {
 		l.LogErrorfCtx(ctx, "Problem writing an HTTP response that was already in error", err)
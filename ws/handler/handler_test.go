@@ -8,11 +8,14 @@ import (
 	"bytes"
 	"context"
 	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/ws"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -62,6 +65,95 @@ func TestAllOptionalPhases(t *testing.T) {
 
 }
 
+func TestPhasesInstrumented(t *testing.T) {
+
+	l := new(AllPhasesLogic)
+
+	h, req := GetHandler(t)
+
+	h.Logic = l
+	err := h.StartComponent()
+
+	test.ExpectNil(t, err)
+
+	ri := new(eventRecorder)
+	ctx := instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(ctx, w, req)
+
+	test.ExpectString(t, strings.Join(ri.ended, ","), strings.Join([]string{UnmarshalEvent, BindEvent, ValidateEvent, ProcessEvent, WriteEvent}, ","))
+
+	for id, md := range ri.metadata {
+		if len(md) != 1 || md[0] != "testHandler" {
+			t.Errorf("Expected handler name as metadata for event %s, got %v", id, md)
+		}
+	}
+}
+
+func TestIdentifyAndAccessInstrumented(t *testing.T) {
+
+	h, req := GetHandler(t)
+
+	h.Logic = new(AllPhasesLogic)
+	h.UserIdentifier = new(anonymousIdentifier)
+	h.AccessChecker = new(denyingAccessChecker)
+
+	err := h.StartComponent()
+
+	test.ExpectNil(t, err)
+
+	ri := new(eventRecorder)
+	ctx := instrument.AddInstrumentorToContext(context.Background(), ri)
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(ctx, w, req)
+
+	test.ExpectString(t, strings.Join(ri.ended, ","), strings.Join([]string{IdentifyEvent, AccessEvent}, ","))
+}
+
+type anonymousIdentifier struct{}
+
+func (ai *anonymousIdentifier) Identify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+	return iam.NewAnonymousIdentity(), ctx
+}
+
+type denyingAccessChecker struct{}
+
+func (dac *denyingAccessChecker) Allowed(ctx context.Context, r *ws.Request) bool {
+	return false
+}
+
+type eventRecorder struct {
+	ended    []string
+	metadata map[string][]interface{}
+}
+
+func (er *eventRecorder) StartEvent(id string, metadata ...interface{}) instrument.EndEvent {
+
+	if er.metadata == nil {
+		er.metadata = make(map[string][]interface{})
+	}
+
+	er.metadata[id] = metadata
+
+	return func() {
+		er.ended = append(er.ended, id)
+	}
+}
+
+func (er *eventRecorder) Fork(ctx context.Context) (context.Context, instrument.Instrumentor) {
+	return ctx, er
+}
+
+func (er *eventRecorder) Integrate(instrumentor instrument.Instrumentor) {
+}
+
+func (er *eventRecorder) Amend(additional instrument.Additional, value interface{}) {
+}
+
 func TestHandlerWithProcessPayload(t *testing.T) {
 
 	l := new(mockLogic)
@@ -7,12 +7,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"text/template"
@@ -35,6 +38,11 @@ type TemplatedXMLResponseWriter struct {
 	// The path (absolute or relative to application working directory) where unpopulated template files can be found.
 	TemplateDir string
 
+	// An optional filesystem (normally an embed.FS compiled into your application's executable) that templates are
+	// loaded from instead of the operating system's filesystem. If set, TemplateDir is a slash-separated path relative
+	// to the root of this filesystem.
+	TemplateFS fs.FS
+
 	// A map from an HTTP status code (e.g. '404') to the name of the template to be used to render that type of response.
 	StatusTemplates map[string]string
 	templates       *template.Template
@@ -199,6 +207,11 @@ func (rw *TemplatedXMLResponseWriter) StartComponent() error {
 }
 
 func (rw *TemplatedXMLResponseWriter) preLoadTemplates(baseDir string) error {
+
+	if rw.TemplateFS != nil {
+		return rw.preLoadTemplatesFromFS(baseDir)
+	}
+
 	if tp, err := rw.templatePaths(rw.TemplateDir); err != nil {
 		m := fmt.Sprintf("Problem converting template directory into a list of file paths %s: %s", baseDir, err)
 		return errors.New(m)
@@ -210,6 +223,50 @@ func (rw *TemplatedXMLResponseWriter) preLoadTemplates(baseDir string) error {
 	return nil
 }
 
+// preLoadTemplatesFromFS parses all of the files found under baseDir in TemplateFS. As with template.ParseFiles, each
+// template is named after the base name of the file it was loaded from.
+func (rw *TemplatedXMLResponseWriter) preLoadTemplatesFromFS(baseDir string) error {
+
+	tp, err := config.FileListFromFS(rw.TemplateFS, baseDir)
+
+	if err != nil {
+		m := fmt.Sprintf("Problem converting template directory into a list of file paths %s: %s", baseDir, err)
+		return errors.New(m)
+	}
+
+	if len(tp) == 0 {
+		return fmt.Errorf("no template files found in %s", baseDir)
+	}
+
+	var first *template.Template
+
+	for _, p := range tp {
+
+		b, err := fs.ReadFile(rw.TemplateFS, p)
+
+		if err != nil {
+			return fmt.Errorf("Problem reading template file %s: %s", p, err)
+		}
+
+		var t *template.Template
+
+		if name := path.Base(p); first == nil {
+			first = template.New(name)
+			t = first
+		} else {
+			t = first.New(name)
+		}
+
+		if _, err := t.Parse(string(b)); err != nil {
+			return fmt.Errorf("Problem parsing template files: %s", err)
+		}
+	}
+
+	rw.templates = first
+
+	return nil
+}
+
 func (rw *TemplatedXMLResponseWriter) templatePaths(baseDir string) ([]string, error) {
 	var di []os.FileInfo
 	var err error
@@ -1,9 +1,11 @@
 package xml
 
 import (
+	"bytes"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
 	"testing"
+	"testing/fstest"
 )
 
 func TestAbnormalStatusWriting(t *testing.T) {
@@ -21,3 +23,30 @@ func TestAbnormalStatusWriting(t *testing.T) {
 	}
 
 }
+
+func TestTemplatesFromFS(t *testing.T) {
+
+	xm := new(TemplatedXMLResponseWriter)
+
+	xm.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	xm.TemplateFS = fstest.MapFS{
+		"resource/xml/abnormal":    {Data: []byte(`<error>{{template "code" .}}</error>`)},
+		"resource/xml/errors/code": {Data: []byte(`<code>{{.}}</code>`)},
+	}
+	xm.TemplateDir = "resource/xml"
+	xm.AbnormalTemplate = "abnormal"
+
+	test.ExpectNil(t, xm.StartComponent())
+
+	var b bytes.Buffer
+
+	test.ExpectNil(t, xm.templates.ExecuteTemplate(&b, "abnormal", 500))
+	test.ExpectString(t, b.String(), "<error><code>500</code></error>")
+
+	xm = new(TemplatedXMLResponseWriter)
+	xm.TemplateFS = fstest.MapFS{}
+	xm.TemplateDir = "resource/xml"
+	xm.AbnormalTemplate = "abnormal"
+
+	test.ExpectNotNil(t, xm.StartComponent())
+}